@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag" // 検出した値のフラグへの反映に使用
+	"log"  // 検出した値の表示に使用
+	"os"   // ワークフローの環境変数の読み込みに使用
+)
+
+// detectActions は GitHub Actions のワークフロー（GITHUB_ACTIONS=true）で実行している場合に、
+// コマンドライン・環境変数・設定ファイルのどれでも指定されていないリポジトリを、
+// ワークフローの環境変数 GITHUB_REPOSITORY から補い、補った値を表示します。
+// APIのURLとトークンは GITHUB_API_URL・GITHUB_TOKEN から読み込みます（ワークフロー以外でも使える環境変数のため、ここではAPIのURLの表示だけを行います）。
+// ワークフロー以外では何もしません。
+//
+// パラメータ:
+//   - env: 環境変数から値を反映したフラグ名 → 環境変数名（補った値を追加する）
+//   - needRepo: 対象のリポジトリが指定されていないか（位置引数・--owner/--repo・--repos-file・設定ファイルの repos: のどれもない）
+//
+// 戻り値:
+//   - error: 検出した値が不正な場合のエラー情報
+func detectActions(env map[string]string, needRepo bool) error {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil
+	}
+	if v := os.Getenv("GITHUB_REPOSITORY"); v != "" && needRepo {
+		if err := flag.Set("repo", v); err != nil {
+			return err
+		}
+		env["repo"] = "GITHUB_REPOSITORY"
+		log.Printf("Detected GitHub Actions: using repository %s from $GITHUB_REPOSITORY", v)
+	}
+	if env["api-url"] == "GITHUB_API_URL" {
+		log.Printf("Detected GitHub Actions: using API URL %s from $GITHUB_API_URL", os.Getenv("GITHUB_API_URL"))
+	}
+	return nil
+}
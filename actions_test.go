@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http" // 送られた Authorization ヘッダーの記録に使用
+	"strings"  // 出力とリクエストのパスの確認に使用
+	"sync"     // 記録したヘッダーの保護に使用
+	"testing"  // テストの実行に使用
+)
+
+// actionsSecret はテスト用の GITHUB_TOKEN の値です（出力に含まれないことを確認するため、他の文字列と重ならない値にします）。
+const actionsSecret = "actions-secret-7f3a"
+
+// actionsEnv は GitHub Actions の実行環境と同じ環境変数を返します。
+func actionsEnv(apiURL string) []string {
+	return []string{
+		"GITHUB_ACTIONS=true",
+		"GITHUB_REPOSITORY=acme/widgets",
+		"GITHUB_API_URL=" + apiURL,
+		"GITHUB_TOKEN=" + actionsSecret,
+	}
+}
+
+// recordAuth は受け取ったリクエストの Authorization ヘッダーを記録するように、テスト用のサーバーを設定します。
+func recordAuth(mu *sync.Mutex, auth *[]string) func(*fakeGitHub) {
+	return func(f *fakeGitHub) {
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			mu.Lock()
+			*auth = append(*auth, r.Header.Get("Authorization"))
+			mu.Unlock()
+			return false
+		}
+	}
+}
+
+// TestDetectActions は GitHub Actions の中で、フラグを指定しなくても GITHUB_REPOSITORY・GITHUB_API_URL・GITHUB_TOKEN から
+// リポジトリ・APIのURL・トークンを解決し、明示したフラグがそれらより優先されることを確認します。
+func TestDetectActions(t *testing.T) {
+	prs := []map[string]interface{}{fakePR(1, "2024-05-01T00:00:00Z")}
+	comments := map[int][]map[string]interface{}{1: pagedComments(1, 2)}
+
+	t.Run("print-config", func(t *testing.T) {
+		code, out := runToolEnv(t, t.TempDir(), actionsEnv("https://ghes.example.com/api/v3"), "--print-config")
+		if code != 0 {
+			t.Fatalf("exited with %d:\n%s", code, out)
+		}
+		for _, want := range []string{
+			"repo: acme/widgets # environment ($GITHUB_REPOSITORY)",
+			"api-url: 'https://ghes.example.com/api/v3' # environment ($GITHUB_API_URL)",
+			"token: '<redacted>' # environment ($GITHUB_TOKEN)",
+			"  - repo: acme/widgets\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output lacks %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, actionsSecret) {
+			t.Error("the token value was printed")
+		}
+	})
+
+	t.Run("detected", func(t *testing.T) {
+		var mu sync.Mutex
+		var auth []string
+		server := newFakeGitHub(t, prs, comments, func(f *fakeGitHub) { f.prefix = "/api/v3" }, recordAuth(&mu, &auth))
+		code, out := runToolEnv(t, t.TempDir(), actionsEnv(server.apiURL()), "--count", "1", "--no-cache")
+		if code != 0 {
+			t.Fatalf("exited with %d:\n%s", code, out)
+		}
+		if !strings.Contains(out, "Detected GitHub Actions: using repository acme/widgets from $GITHUB_REPOSITORY") ||
+			!strings.Contains(out, "Detected GitHub Actions: using API URL") || !strings.Contains(out, "Using the token from $GITHUB_TOKEN") {
+			t.Errorf("the detected settings were not logged:\n%s", out)
+		}
+		if strings.Contains(out, actionsSecret) {
+			t.Error("the token value was printed")
+		}
+		server.mu.Lock()
+		requests := append([]string(nil), server.requests...)
+		server.mu.Unlock()
+		if len(requests) == 0 {
+			t.Fatal("no requests reached $GITHUB_API_URL")
+		}
+		for _, r := range requests {
+			if !strings.HasPrefix(r, "/api/v3/repos/acme/widgets") && !strings.HasPrefix(r, "/api/v3/rate_limit") {
+				t.Errorf("request %s is not for acme/widgets under $GITHUB_API_URL", r)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(auth) == 0 {
+			t.Fatal("no Authorization headers were recorded")
+		}
+		for _, a := range auth {
+			if a != "token "+actionsSecret {
+				t.Error("a request was not authenticated with $GITHUB_TOKEN")
+				break
+			}
+		}
+	})
+
+	t.Run("explicit flags win", func(t *testing.T) {
+		var mu sync.Mutex
+		var auth []string
+		detected := newFakeGitHub(t, prs, comments)
+		explicit := newFakeGitHub(t, prs, comments, recordAuth(&mu, &auth))
+		code, out := runToolEnv(t, t.TempDir(), actionsEnv(detected.apiURL()),
+			"--owner", "o", "--repo", "r", "--api-url", explicit.apiURL(), "--token", "flag-token", "--count", "1", "--no-cache")
+		if code != 0 {
+			t.Fatalf("exited with %d:\n%s", code, out)
+		}
+		if strings.Contains(out, "Detected GitHub Actions") {
+			t.Errorf("explicit flags were reported as detected:\n%s", out)
+		}
+		if n := detected.requestCount(); n != 0 {
+			t.Errorf("%d requests went to $GITHUB_API_URL despite --api-url", n)
+		}
+		explicit.mu.Lock()
+		requests := append([]string(nil), explicit.requests...)
+		explicit.mu.Unlock()
+		if len(requests) == 0 {
+			t.Fatal("no requests reached --api-url")
+		}
+		for _, r := range requests {
+			if !strings.HasPrefix(r, "/repos/o/r") && !strings.HasPrefix(r, "/rate_limit") {
+				t.Errorf("request %s is not for the --owner/--repo repository", r)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(auth) == 0 {
+			t.Fatal("no Authorization headers were recorded")
+		}
+		for _, a := range auth {
+			if a != "token flag-token" {
+				t.Error("a request was not authenticated with --token")
+				break
+			}
+		}
+	})
+
+	t.Run("outside Actions", func(t *testing.T) {
+		env := actionsEnv("https://ghes.example.com/api/v3")[1:] // GITHUB_ACTIONS なし
+		code, out := runToolEnv(t, t.TempDir(), env, "--print-config")
+		if code == 0 || !strings.Contains(out, "a repository is required") || strings.Contains(out, "acme/widgets") {
+			t.Errorf("$GITHUB_REPOSITORY was used outside GitHub Actions (exit %d):\n%s", code, out)
+		}
+	})
+}
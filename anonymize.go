@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/hmac"     // 仮名の生成に使用
+	"crypto/sha256"   // HMACのハッシュ関数に使用
+	"encoding/binary" // ハッシュ値の数値への変換に使用
+	"encoding/json"   // 対応表の書き出しに使用
+	"fmt"             // 仮名の作成に使用
+	"os"              // 対応表の書き出しに使用
+	"strings"         // ユーザー名の正規化に使用
+	"sync"            // 並行して処理するリポジトリ間の排他制御に使用
+)
+
+// anonymizer はユーザー名を鍵付きのハッシュ（HMAC-SHA256）から作った仮名に置き換えます。
+// 同じ鍵を使えば実行をまたいで同じ仮名になり、鍵を知らなければ元のユーザー名には戻せません。
+type anonymizer struct {
+	key     []byte
+	byLogin map[string]string // 小文字のユーザー名 → 仮名
+	byAlias map[string]string // 仮名 → 小文字のユーザー名（仮名の衝突の検出に使用）
+	logins  map[string]string // 小文字のユーザー名 → 最初に出現した表記のユーザー名（対応表の出力に使用）
+	mu      sync.Mutex        // 複数のリポジトリを並行して処理する場合に、上のマップを保護する
+}
+
+// newAnonymizer は指定された鍵で仮名を作る anonymizer を作成します。
+func newAnonymizer(key string) *anonymizer {
+	return &anonymizer{
+		key:     []byte(key),
+		byLogin: make(map[string]string),
+		byAlias: make(map[string]string),
+		logins:  make(map[string]string),
+	}
+}
+
+// pseudonym はユーザー名に対応する "reviewer-0427" のような仮名を返します。
+// ユーザー名の大文字・小文字は区別しません。まれに別のユーザーと同じ仮名になる場合は、桁数を増やして区別します。
+//
+// パラメータ:
+//   - login: ユーザー名
+//
+// 戻り値:
+//   - string: 仮名
+func (a *anonymizer) pseudonym(login string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	lower := strings.ToLower(login)
+	if alias, ok := a.byLogin[lower]; ok {
+		return alias
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(lower))
+	n := binary.BigEndian.Uint64(mac.Sum(nil))
+	alias := ""
+	for digits, mod := 4, uint64(10000); ; digits, mod = digits+1, mod*10 {
+		alias = fmt.Sprintf("reviewer-%0*d", digits, n%mod)
+		if _, taken := a.byAlias[alias]; !taken {
+			break
+		}
+	}
+	a.byLogin[lower] = alias
+	a.byAlias[alias] = lower
+	a.logins[lower] = login
+	return alias
+}
+
+// anonymizeUser はユーザー名を仮名に置き換えます（削除されたユーザーはそのまま）。
+func (a *anonymizer) anonymizeUser(u *User) {
+	if u != nil && u.Login != "" {
+		u.Login = a.pseudonym(u.Login)
+	}
+}
+
+// applyPR はプルリクエストの作成者・レビュアー・担当者のユーザー名を仮名に置き換えます。
+//
+// パラメータ:
+//   - pr: 対象のプルリクエスト（直接更新されます）
+func (a *anonymizer) applyPR(pr *PullRequest) {
+	a.anonymizeUser(&pr.User)
+	// APIレスポンスのスライスを書き換えないよう、コピーしてから置き換える
+	pr.RequestedReviewers = append([]User(nil), pr.RequestedReviewers...)
+	for i := range pr.RequestedReviewers {
+		a.anonymizeUser(&pr.RequestedReviewers[i])
+	}
+	pr.Assignees = append([]User(nil), pr.Assignees...)
+	for i := range pr.Assignees {
+		a.anonymizeUser(&pr.Assignees[i])
+	}
+}
+
+// applyComments はコメントの投稿者と返信先の投稿者のユーザー名を仮名に置き換えます。
+// 本文中の @メンションは --strip-mentions の指定時に stripMentions で置き換えます。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func (a *anonymizer) applyComments(comments []Comment) {
+	for i := range comments {
+		if comments[i].User != nil {
+			u := *comments[i].User
+			a.anonymizeUser(&u)
+			comments[i].User = &u
+		}
+		if p := comments[i].ParentAuthor; p != "" && p != deletedUserLogin {
+			comments[i].ParentAuthor = a.pseudonym(p)
+		}
+	}
+}
+
+// saveMap はユーザー名と仮名の対応表をJSON形式で保存します。
+// 仮名を元に戻せる情報のため、所有者だけが読み書きできる権限で作成します。
+//
+// パラメータ:
+//   - path: 保存先のファイルパス
+//
+// 戻り値:
+//   - error: 保存に失敗した場合のエラー情報
+func (a *anonymizer) saveMap(path string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	mapping := make(map[string]string, len(a.byLogin))
+	for lower, alias := range a.byLogin {
+		mapping[a.logins[lower]] = alias
+	}
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	// 既存のファイルを上書きした場合も権限を制限する
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
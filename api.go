@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"     // URLの組み立てとエラーメッセージの作成に使用
+	"net/url" // --api-url の検証に使用
+	"strings" // URLの結合に使用
+)
+
+// defaultAPIURL はGitHub.comのREST APIのベースURLです。
+const defaultAPIURL = "https://api.github.com"
+
+// apiBaseURL はすべてのREST APIのリクエストに使うベースURLです（末尾のスラッシュは含みません）。
+// GitHub Enterprise Server の場合は --api-url で https://github.example.com/api/v3 のように変更します。
+var apiBaseURL = defaultAPIURL
+
+// setAPIBaseURL はREST APIのベースURLを検証して設定します。末尾のスラッシュは取り除きます。
+func setAPIBaseURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid --api-url %q (expected a URL such as https://github.example.com/api/v3)", raw)
+	}
+	apiBaseURL = strings.TrimRight(u.Scheme+"://"+u.Host+u.Path, "/")
+	return nil
+}
+
+// apiURL はREST APIのパスから完全なURLを作成します（fmt.Sprintf と同じ書式）。
+// 例: apiURL("/repos/%s/%s/pulls", owner, repo)
+func apiURL(format string, args ...interface{}) string {
+	return apiBaseURL + "/" + strings.TrimLeft(fmt.Sprintf(format, args...), "/")
+}
+
+// webHost はREST APIのベースURLに対応する、GitHubのWebのホスト名を返します。
+// api.github.com の場合は github.com を、GitHub Enterprise Server の場合はAPIと同じホスト名を返します。
+func webHost() string {
+	u, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return githubHost
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "api.")
+}
+
+// apiHost はREST APIのホスト名を返します（例: api.github.com、GitHub Enterprise Server の場合は github.example.com）。
+func apiHost() string {
+	u, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return "api." + githubHost
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// apiPath はREST APIのベースURLのパスを返します（GitHub.com の場合は空文字、GitHub Enterprise Server の場合は /api/v3）。
+func apiPath() string {
+	u, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// webURL はREST APIのベースURLに対応する、GitHubのWebのURLを返します（例: https://github.com）。
+func webURL() string {
+	u, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return "https://" + githubHost
+	}
+	host := u.Host
+	if rest, ok := strings.CutPrefix(host, "api."); ok {
+		host = rest
+	}
+	return u.Scheme + "://" + host
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"            // 保存したレスポンスの確認に使用
+	"path/filepath" // 保存先のパスの組み立てに使用
+	"strings"       // リクエストのパスの確認に使用
+	"testing"       // テストの実行に使用
+)
+
+// TestAPIBaseURL は --api-url の末尾のスラッシュを取り除いて、APIのURLとWebのホストを組み立てることを確認します。
+func TestAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		raw                      string
+		wantURL, wantHost, wantW string // apiURL の結果・webHost・webURL
+		wantPath                 string
+	}{
+		{"https://api.github.com", "https://api.github.com/repos/o/r/pulls", "github.com", "https://github.com", ""},
+		{"https://api.github.com/", "https://api.github.com/repos/o/r/pulls", "github.com", "https://github.com", ""},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/v3/repos/o/r/pulls", "github.example.com", "https://github.example.com", "/api/v3"},
+		{" https://github.example.com/api/v3// ", "https://github.example.com/api/v3/repos/o/r/pulls", "github.example.com", "https://github.example.com", "/api/v3"},
+		{"http://127.0.0.1:8080/api/v3/", "http://127.0.0.1:8080/api/v3/repos/o/r/pulls", "127.0.0.1", "http://127.0.0.1:8080", "/api/v3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			withAPIBaseURL(t, tt.raw)
+			if got := apiURL("/repos/%s/%s/pulls", "o", "r"); got != tt.wantURL {
+				t.Errorf("apiURL = %q, want %q", got, tt.wantURL)
+			}
+			if got := apiURL("repos/%s/%s/pulls", "o", "r"); got != tt.wantURL {
+				t.Errorf("apiURL without a leading slash = %q, want %q", got, tt.wantURL)
+			}
+			if got := webHost(); got != tt.wantHost {
+				t.Errorf("webHost = %q, want %q", got, tt.wantHost)
+			}
+			if got := webURL(); got != tt.wantW {
+				t.Errorf("webURL = %q, want %q", got, tt.wantW)
+			}
+			if got := apiPath(); got != tt.wantPath {
+				t.Errorf("apiPath = %q, want %q", got, tt.wantPath)
+			}
+		})
+	}
+	for _, raw := range []string{"", "github.example.com/api/v3", "ftp://github.example.com", "https://", "https://github.example.com/api/v3?x=1"} {
+		if err := setAPIBaseURL(raw); err == nil {
+			t.Errorf("setAPIBaseURL(%q) succeeded", raw)
+		}
+	}
+}
+
+// TestGHES は GitHub Enterprise Server の代わりに /api/v3 の下でAPIを提供するテスト用のサーバーに対して、
+// --api-url（末尾のスラッシュ付き）と GITHUB_API_URL の両方で、すべてのリクエストがそのパスに送られることを確認します。
+func TestGHES(t *testing.T) {
+	for _, viaEnv := range []bool{false, true} {
+		name := "--api-url"
+		if viaEnv {
+			name = "GITHUB_API_URL"
+		}
+		t.Run(name, func(t *testing.T) {
+			prs := []map[string]interface{}{fakePR(2, "2024-05-02T00:00:00Z"), fakePR(1, "2024-05-01T00:00:00Z")}
+			server := newFakeGitHub(t, prs, map[int][]map[string]interface{}{1: pagedComments(1, 5), 2: pagedComments(2, 3)}, func(f *fakeGitHub) {
+				f.prefix = "/api/v3"
+				f.maxPer = 2
+			})
+			dir := t.TempDir()
+			args := []string{"--owner", "o", "--repo", "r", "--count", "2", "--no-cache", "--token", "t", "--save-raw"}
+			var env []string
+			if viaEnv {
+				env = []string{"GITHUB_API_URL=" + server.apiURL() + "/"}
+			} else {
+				args = append(args, "--api-url", server.apiURL()+"/")
+			}
+			code, out := runToolEnv(t, dir, env, args...)
+			if code != 0 {
+				t.Fatalf("exited with %d:\n%s", code, out)
+			}
+			if !strings.Contains(out, "Saved 5 comments") || !strings.Contains(out, "Saved 3 comments") {
+				t.Errorf("comments were not fetched:\n%s", out)
+			}
+			server.mu.Lock()
+			requests := append([]string(nil), server.requests...)
+			server.mu.Unlock()
+			if len(requests) == 0 {
+				t.Fatal("no requests reached the server")
+			}
+			for _, r := range requests {
+				if !strings.HasPrefix(r, "/api/v3/") || strings.HasPrefix(r, "/api/v3//") {
+					t.Errorf("request %s is outside /api/v3/", r)
+				}
+			}
+			// 保存したレスポンスの名前には /api/v3 を含めない（GitHub.com で保存した場合と同じ名前になる）
+			if files, _ := filepath.Glob(filepath.Join(dir, rawDir, "repos", "o", "r", "pulls", "1", "comments", "page_0003_*.json")); len(files) != 1 {
+				t.Errorf("raw responses of the last comment page: %v", files)
+			}
+			if _, err := os.Stat(filepath.Join(dir, rawDir, "api")); !os.IsNotExist(err) {
+				t.Errorf("raw responses were saved under the API path prefix: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json" // エラーレスポンスのデコードに使用
+	"errors"        // エラーの種類の判定に使用
+	"fmt"           // エラーメッセージの作成に使用
+	"io"            // エラーレスポンスの読み込みに使用
+	"net"           // ネットワークのエラーの判定に使用
+	"net/http"      // HTTPレスポンスの処理に使用
+	"strconv"       // レート制限のヘッダーの変換に使用
+	"strings"       // メッセージの組み立てに使用
+	"time"          // レート制限が解除される時刻の表示に使用
+)
+
+// 失敗の種類ごとの終了コードです（--max-pages・--max-api-calls の上限に達した場合は exitLimitReached）。
+const (
+	exitAuthError   = 4 // トークンが無効な場合や、アクセスが拒否された場合（設定を直す必要がある）
+	exitServerError = 5 // GitHubのサーバーエラーや通信エラーの場合（時間をおいて再実行すれば成功しうる）
+)
+
+// apiErrorDetail はGitHub APIのエラーレスポンスの errors の1件分です（422 の入力エラーなど）。
+type apiErrorDetail struct {
+	Resource string `json:"resource"` // 対象のリソース（例: Issue）
+	Field    string `json:"field"`    // 問題のあるフィールド
+	Code     string `json:"code"`     // エラーの種類（例: missing_field、invalid）
+	Message  string `json:"message"`  // 説明（code が custom の場合など）
+}
+
+// apiError はGitHub APIが200番台以外のステータスを返したことを表すエラーです。
+// エラーレスポンスのJSON（message・documentation_url・errors）とレート制限のヘッダーから、ステータスごとに具体的なメッセージを作ります。
+type apiError struct {
+	Status           int              // HTTPステータスコード
+	Method           string           // リクエストのメソッド
+	Endpoint         string           // リクエストのパス（クエリを除く。例: /repos/acme/api/pulls）
+	Message          string           // エラーレスポンスの message
+	DocumentationURL string           // エラーレスポンスの documentation_url
+	Errors           []apiErrorDetail // エラーレスポンスの errors
+	RateLimited      bool             // レート制限の超過によるエラーか
+	Reset            time.Time        // レート制限が解除される時刻（わからない場合はゼロ値）
+}
+
+// newAPIError は200番台以外のレスポンスから apiError を作成します。レスポンスの本文を読み込みます（閉じるのは呼び出し元）。
+func newAPIError(resp *http.Response) *apiError {
+	e := &apiError{Status: resp.StatusCode}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Endpoint = strings.TrimPrefix(resp.Request.URL.Path, apiPath())
+	}
+	var body struct {
+		Message          string           `json:"message"`
+		DocumentationURL string           `json:"documentation_url"`
+		Errors           []apiErrorDetail `json:"errors"`
+	}
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err == nil {
+		json.Unmarshal(data, &body)
+	}
+	e.Message, e.DocumentationURL, e.Errors = body.Message, body.DocumentationURL, body.Errors
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		e.RateLimited = resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "" ||
+			strings.Contains(strings.ToLower(e.Message), "rate limit")
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		e.Reset = time.Unix(reset, 0)
+	}
+	return e
+}
+
+// subject はエンドポイントから、見つからなかったものの説明を返します（例: repository acme/api、PR #12 in acme/api）。
+func (e *apiError) subject() string {
+	parts := strings.Split(strings.Trim(e.Endpoint, "/"), "/")
+	if len(parts) >= 3 && parts[0] == "repos" {
+		repo := parts[1] + "/" + parts[2]
+		if len(parts) >= 5 && parts[3] == "pulls" {
+			return "PR #" + parts[4] + " in " + repo
+		}
+		return "repository " + repo
+	}
+	if len(parts) >= 4 && parts[0] == "orgs" && parts[2] == "teams" {
+		return "team " + parts[1] + "/" + parts[3]
+	}
+	return e.Endpoint
+}
+
+// Error は error インタフェースの実装です。
+func (e *apiError) Error() string {
+	var msg string
+	switch {
+	case e.RateLimited:
+		msg = "rate limit exceeded"
+		if !e.Reset.IsZero() {
+			msg += ", resets at " + e.Reset.Local().Format("15:04")
+		}
+	case e.Status == http.StatusUnauthorized:
+		msg = "bad credentials: the token is invalid, expired or revoked"
+	case e.Status == http.StatusForbidden:
+		msg = "access denied"
+		if e.Message != "" {
+			msg += ": " + e.Message
+		}
+	case e.Status == http.StatusNotFound:
+		msg = e.subject() + " not found or token has no access"
+	case e.Status == http.StatusUnprocessableEntity:
+		msg = "validation failed"
+		var fields []string
+		for _, d := range e.Errors {
+			switch {
+			case d.Field != "" && d.Code != "":
+				fields = append(fields, d.Field+" ("+d.Code+")")
+			case d.Message != "":
+				fields = append(fields, d.Message)
+			case d.Field != "":
+				fields = append(fields, d.Field)
+			}
+		}
+		if len(fields) > 0 {
+			msg += ": " + strings.Join(fields, ", ")
+		} else if e.Message != "" && e.Message != "Validation Failed" {
+			msg += ": " + e.Message
+		}
+	case e.Status >= 500:
+		msg = "GitHub server error, try again later"
+		if e.Message != "" {
+			msg += " (" + e.Message + ")"
+		}
+	default:
+		msg = e.Message
+		if msg == "" {
+			msg = http.StatusText(e.Status)
+		}
+	}
+	if e.Endpoint != "" {
+		msg += " [" + e.Method + " " + e.Endpoint + "]"
+	}
+	return fmt.Sprintf("%d: %s", e.Status, msg)
+}
+
+// authFailure は、設定を直さない限り再実行しても成功しない認証・認可のエラーかどうかを返します。
+func (e *apiError) authFailure() bool {
+	return !e.RateLimited && (e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden)
+}
+
+// accessError はトークンでリポジトリにアクセスできないことを表すエラーです（事前チェックで見つかった問題など）。
+type accessError struct {
+	msg string
+}
+
+// Error は error インタフェースの実装です。
+func (e *accessError) Error() string {
+	return e.msg
+}
+
+// accessDenied は対処方法を含む accessError を作成します（fmt.Sprintf と同じ書式）。
+func accessDenied(format string, args ...interface{}) error {
+	return &accessError{msg: fmt.Sprintf(format, args...)}
+}
+
+// exitCodeFor はエラーの種類に応じた終了コードを返します。
+// 認証・認可のエラーは exitAuthError、サーバーエラーと通信エラーは exitServerError、それ以外は1です。
+func exitCodeFor(err error) int {
+	var (
+		api    *apiError
+		access *accessError
+		limit  *limitError
+		netErr net.Error
+	)
+	switch {
+	case errors.As(err, &limit):
+		return limit.exitCode()
+	case errors.As(err, &access):
+		return exitAuthError
+	case errors.As(err, &api):
+		if api.authFailure() {
+			return exitAuthError
+		}
+		if api.Status >= 500 {
+			return exitServerError
+		}
+	case errors.As(err, &netErr):
+		return exitServerError
+	}
+	return 1
+}
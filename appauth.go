@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto"          // JWTの署名のハッシュ関数の指定に使用
+	"crypto/rand"     // JWTの署名に使用
+	"crypto/rsa"      // Appの秘密鍵の扱いに使用
+	"crypto/sha256"   // JWTの署名に使用
+	"crypto/x509"     // Appの秘密鍵の解析に使用
+	"encoding/base64" // JWTのエンコードに使用
+	"encoding/json"   // JWTとAPIのレスポンスのエンコード・デコードに使用
+	"encoding/pem"    // Appの秘密鍵の読み込みに使用
+	"fmt"             // エラーメッセージの作成に使用
+	"net/http"        // HTTPクライアントの実装を提供
+	"os"              // 秘密鍵のファイルの読み込みに使用
+	"strings"         // Authorization ヘッダーの解析に使用
+	"sync"            // トークンの更新の排他制御に使用
+	"time"            // トークンの有効期限の管理に使用
+)
+
+// appTokenRefreshMargin はインストールトークンの有効期限のどれだけ前に、新しいトークンに更新するかです。
+const appTokenRefreshMargin = 5 * time.Minute
+
+// appAuth は GitHub App のインストールとして認証するための情報と、取得したインストールトークンを保持します。
+// インストールトークンの有効期限は1時間のため、期限が近づくと新しいトークンを取得し直します。
+type appAuth struct {
+	appID          int64           // GitHub App のID
+	installationID int64           // インストールのID
+	key            *rsa.PrivateKey // GitHub App の秘密鍵
+	client         *http.Client    // インストールトークンの取得に使うクライアント
+
+	mu      sync.Mutex      // 以下のフィールドを保護する
+	token   string          // 現在のインストールトークン
+	expires time.Time       // 現在のインストールトークンの有効期限
+	issued  map[string]bool // これまでに取得したインストールトークン（古いトークンを使ったリクエストの判定に使用）
+}
+
+// newAppAuth は GitHub App の秘密鍵を読み込み、appAuth を作成します。
+//
+// パラメータ:
+//   - appID: GitHub App のID
+//   - installationID: インストールのID
+//   - keyPath: GitHub App の秘密鍵（PEM）のパス
+//   - transport: インストールトークンの取得に使うトランスポート
+//
+// 戻り値:
+//   - *appAuth: GitHub App の認証情報
+//   - error: 秘密鍵を読み込めない場合のエラー情報
+func newAppAuth(appID, installationID int64, keyPath string, transport http.RoundTripper) (*appAuth, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read --app-key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("bad --app-key %s: no PEM private key found", keyPath)
+	}
+	var key *rsa.PrivateKey
+	if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		parsed, err8 := x509.ParsePKCS8PrivateKey(block.Bytes)
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if err8 != nil || !ok {
+			return nil, fmt.Errorf("bad --app-key %s: not an RSA private key (download a new one from the app's settings)", keyPath)
+		}
+		key = rsaKey
+	}
+	return &appAuth{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		client:         &http.Client{Transport: transport},
+		issued:         make(map[string]bool),
+	}, nil
+}
+
+// jwt は GitHub App として認証するためのJWT（RS256、有効期限10分未満）を作成します。
+func (a *appAuth) jwt() (string, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	// 時計のずれを考慮して、発行時刻を少し前にする
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("cannot sign the app JWT: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Token は有効なインストールトークンを返します。有効期限が近い場合は新しいトークンを取得します。
+// エラーメッセージにはトークンの値を含めません。
+func (a *appAuth) Token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Until(a.expires) > appTokenRefreshMargin {
+		return a.token, nil
+	}
+	jwt, err := a.jwt()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", apiURL("/app/installations/%d/access_tokens", a.installationID), nil)
+	if err != nil {
+		return "", err
+	}
+	// JWTは Bearer、インストールトークンは token の形式で送る
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", mediaTypeJSON)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+	case http.StatusUnauthorized:
+		return "", fmt.Errorf("GitHub rejected the app JWT (status 401): check that --app-key belongs to --app-id %d", a.appID)
+	case http.StatusNotFound:
+		return "", fmt.Errorf("installation %d of GitHub App %d not found (status 404): check --installation-id and that the app is installed", a.installationID, a.appID)
+	default:
+		return "", fmt.Errorf("cannot create an installation token: %v", newAPIError(resp))
+	}
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("cannot decode the installation token response: %v", err)
+	}
+	a.token, a.expires = body.Token, body.ExpiresAt
+	a.issued[body.Token] = true
+	return a.token, nil
+}
+
+// isIssued は指定されたトークンが、これまでに取得したインストールトークンかどうかを返します。
+func (a *appAuth) isIssued(token string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.issued[token]
+}
+
+// appTransport はインストールトークンを使ったリクエストの Authorization ヘッダーを、
+// 送信時点で有効なインストールトークンに差し替える http.RoundTripper です。
+// 各関数は起動時に取得したトークンをヘッダーに設定するため、1時間を超える実行でも期限切れのトークンを送りません。
+type appTransport struct {
+	base http.RoundTripper // 実際にリクエストを送るトランスポート
+	auth *appAuth          // GitHub App の認証情報
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent, found := strings.CutPrefix(req.Header.Get("Authorization"), "token ")
+	if !found || !t.auth.isIssued(sent) {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.auth.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != sent {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "token "+token)
+	}
+	return t.base.RoundTrip(req)
+}
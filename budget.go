@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"     // リポジトリごとの呼び出し回数のカウンターの受け渡しに使用
+	"fmt"         // エラーメッセージの作成に使用
+	"net/http"    // HTTPクライアントの実装を提供
+	"strconv"     // ページ番号の変換に使用
+	"strings"     // APIのURLの判定に使用
+	"sync"        // 並行したリクエストの数え上げの排他制御に使用
+	"sync/atomic" // リポジトリごとの呼び出し回数の数え上げに使用
+)
+
+// exitLimitReached は --max-pages または --max-api-calls の上限に達して実行を打ち切った場合の終了コードです。
+const exitLimitReached = 3
+
+// limitError は --max-pages・--max-api-calls の上限、--max-duration・--deadline の期限に達したこと、または実行が中断されたことを表すエラーです。
+type limitError struct {
+	Flag        string // 上限を指定したフラグ
+	Limit       int    // 上限の値
+	Deadline    bool   // 実行全体の期限に達したか
+	Interrupted bool   // Ctrl-C（SIGINT）または SIGTERM で中断されたか
+}
+
+// Error は error インタフェースの実装です。
+func (e *limitError) Error() string {
+	if e.Interrupted {
+		return "run interrupted"
+	}
+	if e.Deadline {
+		return fmt.Sprintf("%s reached", e.Flag)
+	}
+	return fmt.Sprintf("%s limit of %d reached", e.Flag, e.Limit)
+}
+
+// hint は上限に達したことと、上限を引き上げる方法を説明するメッセージを返します。
+func (e *limitError) hint() string {
+	if e.Interrupted {
+		return "Interrupted: the results collected so far were saved (merged files and prs.json are marked as partial)"
+	}
+	if e.Deadline {
+		return fmt.Sprintf("Stopped: the %s was reached; the results collected so far were saved. Allow more time to fetch the rest", e.Flag)
+	}
+	return fmt.Sprintf("Stopped: the %s limit of %d was reached; raise it (e.g. %s %d) to fetch more", e.Flag, e.Limit, e.Flag, e.Limit*2)
+}
+
+// exitCode は上限に達した場合の終了コードを返します（期限の場合は exitDeadlineReached、中断の場合は exitInterrupted）。
+func (e *limitError) exitCode() int {
+	if e.Interrupted {
+		return exitInterrupted
+	}
+	if e.Deadline {
+		return exitDeadlineReached
+	}
+	return exitLimitReached
+}
+
+// budgetTransport はすべてのGitHub APIのリクエストを数え、ページの深さと呼び出し回数の上限を守らせる http.RoundTripper です。
+// 共有のトランスポート（sharedTransport）と置き換えることで、共有の http.Client（apiClient）を通して、すべてのエンドポイントのリクエストが対象になります。
+// 画像のダウンロードなど、GitHub API以外へのリクエストは数えません。
+type budgetTransport struct {
+	base     http.RoundTripper // 実際にリクエストを送るトランスポート
+	maxPages int               // 1つのエンドポイントでたどるページ数の上限（0の場合は上限なし）
+	maxCalls int               // 実行全体のAPI呼び出し回数の上限（0の場合は上限なし）
+
+	mu    sync.Mutex // Calls を保護する
+	Calls int        // これまでのAPI呼び出し回数
+}
+
+// callCounterKey は、API呼び出し回数を別に数えるカウンターをコンテキストに格納するためのキーです。
+type callCounterKey struct{}
+
+// withCallCounter は、このコンテキストで送ったAPI呼び出しを counter にも数えるコンテキストを返します。
+// 並行して処理するリポジトリごとに、PRの選択までに使った呼び出しの回数を数えるために使います（--dry-run の見積もり）。
+func withCallCounter(ctx context.Context, counter *atomic.Int64) context.Context {
+	return context.WithValue(ctx, callCounterKey{}, counter)
+}
+
+// callCount は withCallCounter で用意したカウンターの、これまでのAPI呼び出し回数を返します（カウンターがない場合は0）。
+func callCount(ctx context.Context) int {
+	if counter, ok := ctx.Value(callCounterKey{}).(*atomic.Int64); ok {
+		return int(counter.Load())
+	}
+	return 0
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。上限に達した場合はリクエストを送らずに limitError を返します。
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.String(), apiBaseURL+"/") {
+		return t.base.RoundTrip(req)
+	}
+	if t.maxPages > 0 {
+		if page, err := strconv.Atoi(req.URL.Query().Get("page")); err == nil && page > t.maxPages {
+			return nil, &limitError{Flag: "--max-pages", Limit: t.maxPages}
+		}
+	}
+	t.mu.Lock()
+	if t.maxCalls > 0 && t.Calls >= t.maxCalls {
+		t.mu.Unlock()
+		return nil, &limitError{Flag: "--max-api-calls", Limit: t.maxCalls}
+	}
+	t.Calls++
+	t.mu.Unlock()
+	if counter, ok := req.Context().Value(callCounterKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"         // キャッシュした本文の読み込みに使用
+	"crypto/sha256" // キャッシュのキーと本文の検査に使用
+	"encoding/hex"  // ハッシュの文字列化に使用
+	"encoding/json" // キャッシュの付随情報の保存に使用
+	"errors"        // キャッシュの破損の判定に使用
+	"fmt"           // エラーメッセージの作成に使用
+	"io"            // レスポンスの本文の読み込みに使用
+	"io/fs"         // キャッシュのディレクトリの走査に使用
+	"log"           // キャッシュの破損と書き込みの失敗の表示に使用
+	"net/http"      // HTTPクライアントの実装を提供
+	"os"            // キャッシュのファイル操作に使用
+	"path/filepath" // キャッシュのパスの組み立てに使用
+	"sort"          // 古いキャッシュからの削除に使用
+	"strings"       // GitHub APIへのリクエストの判定に使用
+	"sync/atomic"   // キャッシュを使った回数の集計に使用
+	"time"          // キャッシュを使った時刻の記録に使用
+)
+
+// cacheHeaders は 304 Not Modified のレスポンスに、キャッシュから補うレスポンスヘッダーです（ページの移動に Link が必要）。
+var cacheHeaders = []string{"Content-Type", "Link"}
+
+// defaultCacheDir はキャッシュの既定の保存先（OSごとのユーザーキャッシュディレクトリの下、Linuxでは ~/.cache/fetch_pr_comments）を返します。
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "fetch_pr_comments")
+}
+
+// cacheEntry はキャッシュした1つのレスポンスの付随情報（<キー>.json）を表す構造体です。本文は <キー>.body に保存します。
+type cacheEntry struct {
+	URL    string              `json:"url"`    // リクエストのURL
+	Accept string              `json:"accept"` // リクエストの Accept ヘッダー（メディアタイプによって本文が異なるため）
+	ETag   string              `json:"etag"`   // レスポンスの ETag
+	Header map[string][]string `json:"header"` // 304 のレスポンスに補うヘッダー
+	Size   int64               `json:"size"`   // 本文のバイト数
+	SHA256 string              `json:"sha256"` // 本文のハッシュ（破損の検出に使用）
+}
+
+// cacheTransport はGitHub APIの GET リクエストのレスポンスを ETag とともにディスクに保存し、
+// 次回からは If-None-Match を付けて送る http.RoundTripper です。
+// 304 Not Modified（レート制限を消費しない）が返った場合は、保存した本文を 200 のレスポンスとして返します。
+// 送り直し（retryTransport）の外側に置くため、送り直した後の最終的なレスポンスだけを扱います。
+type cacheTransport struct {
+	base http.RoundTripper // 実際にリクエストを送るトランスポート
+	dir  string            // キャッシュの保存先（--cache-dir）
+
+	Hits atomic.Int64 // 304 でキャッシュの本文を使った回数
+}
+
+// cacheKey はリクエストのURLと Accept ヘッダーからキャッシュのファイル名（拡張子なし）を作成します。
+// トークンはキーにもファイルにも含めません。
+func cacheKey(rawURL, accept string) string {
+	sum := sha256.Sum256([]byte(rawURL + "\n" + accept))
+	return hex.EncodeToString(sum[:])
+}
+
+// paths はキャッシュのキーから付随情報と本文のファイルパスを返します。
+func (t *cacheTransport) paths(key string) (meta, body string) {
+	base := filepath.Join(t.dir, key[:2], key)
+	return base + ".json", base + ".body"
+}
+
+// load はキャッシュした付随情報と本文を読み込みます。キャッシュがない場合は os.ErrNotExist を返し、
+// 付随情報が読めない場合や本文のサイズ・ハッシュが一致しない場合は破損としてエラーを返します。
+func (t *cacheTransport) load(key, rawURL, accept string) (cacheEntry, []byte, error) {
+	var entry cacheEntry
+	metaPath, bodyPath := t.paths(key)
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return entry, nil, err
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, nil, fmt.Errorf("corrupt cache entry %s: %v", metaPath, err)
+	}
+	if entry.URL != rawURL || entry.Accept != accept || entry.ETag == "" {
+		return entry, nil, fmt.Errorf("corrupt cache entry %s: does not match the request", metaPath)
+	}
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return entry, nil, fmt.Errorf("corrupt cache entry %s: %v", metaPath, err)
+	}
+	sum := sha256.Sum256(body)
+	if int64(len(body)) != entry.Size || hex.EncodeToString(sum[:]) != entry.SHA256 {
+		return entry, nil, fmt.Errorf("corrupt cache entry %s: body does not match its checksum", metaPath)
+	}
+	return entry, body, nil
+}
+
+// remove はキャッシュした付随情報と本文を削除します。
+func (t *cacheTransport) remove(key string) {
+	metaPath, bodyPath := t.paths(key)
+	os.Remove(metaPath)
+	os.Remove(bodyPath)
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !strings.HasPrefix(req.URL.String(), apiBaseURL+"/") || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+	rawURL, accept := req.URL.String(), req.Header.Get("Accept")
+	key := cacheKey(rawURL, accept)
+	entry, body, err := t.load(key, rawURL, accept)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// 壊れたキャッシュは削除し、通常のリクエストとして取得し直す
+		log.Printf("Warning: %v; fetching it again", err)
+		t.remove(key)
+	}
+	if body != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && body != nil:
+		// 変更がない場合は、保存した本文を 200 として返す（レート制限のヘッダーは 304 のものを使う）
+		resp.Body.Close()
+		for name, values := range entry.Header {
+			if resp.Header.Get(name) == "" {
+				resp.Header[name] = values
+			}
+		}
+		if resp.Header.Get("ETag") == "" {
+			resp.Header.Set("ETag", entry.ETag)
+		}
+		resp.StatusCode, resp.Status = http.StatusOK, "200 OK"
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		t.Hits.Add(1)
+		now := time.Now()
+		metaPath, _ := t.paths(key)
+		os.Chtimes(metaPath, now, now) // 最近使ったキャッシュとして削除の対象から外す
+		return resp, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		entry = cacheEntry{URL: rawURL, Accept: accept, ETag: resp.Header.Get("ETag"), Header: make(map[string][]string)}
+		for _, name := range cacheHeaders {
+			if values := resp.Header.Values(name); len(values) > 0 {
+				entry.Header[name] = values
+			}
+		}
+		metaPath, _ := t.paths(key)
+		resp.Body = newSpoolBody(resp.Body, filepath.Dir(metaPath), "cache", func(body spooledBody) error {
+			entry.Size, entry.SHA256 = body.Size, body.SHA256
+			if err := t.store(key, entry, body.Path); err != nil {
+				t.remove(key)
+				return err
+			}
+			return nil
+		})
+	}
+	return resp, nil
+}
+
+// store は書き込み済みの本文の一時ファイルと付随情報を、キャッシュのファイルに置き換えます。
+// 古い付随情報を先に削除し、本文を置き換えてから付随情報を書き込むため、途中で失敗しても食い違ったキャッシュは使われません。
+func (t *cacheTransport) store(key string, entry cacheEntry, tmpBody string) error {
+	metaPath, bodyPath := t.paths(key)
+	os.Remove(metaPath)
+	if err := os.Rename(tmpBody, bodyPath); err != nil {
+		os.Remove(tmpBody)
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp := metaPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, metaPath)
+}
+
+// pruneCache はキャッシュの合計サイズが maxBytes を超えている場合に、最近使われていないものから削除します。
+// 書き込み途中で残った古い一時ファイルも削除します。
+//
+// パラメータ:
+//   - dir: キャッシュの保存先
+//   - maxBytes: キャッシュの合計サイズの上限（バイト）
+//
+// 戻り値:
+//   - int: 削除したキャッシュの数
+//   - error: キャッシュのディレクトリを走査できなかった場合はエラー情報、成功時はnil
+func pruneCache(dir string, maxBytes int64) (int, error) {
+	type cached struct {
+		key   string    // キャッシュのファイルパス（拡張子なし）
+		size  int64     // 付随情報と本文の合計サイズ
+		mtime time.Time // 最後に使った時刻（付随情報の更新時刻）
+	}
+	byKey := make(map[string]*cached)
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".tmp") {
+			// 他の実行が書き込み中の場合があるため、1時間以上前のものだけ削除する
+			if info, err := d.Info(); err == nil && time.Since(info.ModTime()) > time.Hour {
+				os.Remove(path)
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".json" && ext != ".body" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		key := strings.TrimSuffix(path, ext)
+		c := byKey[key]
+		if c == nil {
+			c = &cached{key: key}
+			byKey[key] = c
+		}
+		c.size += info.Size()
+		if ext == ".json" {
+			c.mtime = info.ModTime()
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil || total <= maxBytes {
+		return 0, err
+	}
+	entries := make([]*cached, 0, len(byKey))
+	for _, c := range byKey {
+		entries = append(entries, c)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mtime.Before(entries[j].mtime) })
+	removed := 0
+	for _, c := range entries {
+		if total <= maxBytes {
+			break
+		}
+		os.Remove(c.key + ".json")
+		os.Remove(c.key + ".body")
+		total -= c.size
+		removed++
+	}
+	return removed, nil
+}
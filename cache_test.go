@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"         // 本文の比較に使用
+	"io"            // レスポンスの本文の読み込みに使用
+	"net/http"      // リクエストの作成に使用
+	"os"            // キャッシュのファイルの書き換えに使用
+	"path/filepath" // キャッシュのパスの組み立てに使用
+	"strings"       // エラーメッセージの確認に使用
+	"sync"          // リクエストの記録の排他制御に使用
+	"testing"       // テストの実行に使用
+)
+
+// cacheFixture はキャッシュのテストで使う、3ページに分かれたコメントを返すテスト用のサーバーと、その上に置いた cacheTransport を作成します。
+func cacheFixture(t *testing.T, configure ...func(*fakeGitHub)) (*fakeGitHub, *cacheTransport) {
+	t.Helper()
+	var comments []map[string]interface{}
+	for i := int64(1); i <= 5; i++ {
+		comments = append(comments, fakeComment(i, 1, "bob", "comment", "2024-05-01T10:00:00Z"))
+	}
+	server := newFakeGitHub(t, nil, map[int][]map[string]interface{}{1: comments}, append(configure, func(f *fakeGitHub) { f.maxPer = 2 })...)
+	withAPIBaseURL(t, server.apiURL())
+	return server, &cacheTransport{base: http.DefaultTransport, dir: t.TempDir()}
+}
+
+// cacheGet は t を通して GET リクエストを送り、レスポンスと最後まで読んだ本文を返します（本文を閉じるとキャッシュに保存される）。
+func cacheGet(t *testing.T, transport http.RoundTripper, url string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", mediaTypeJSON)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+// TestCacheRevalidate は 200 で保存したレスポンスを次回は If-None-Match で確認し、
+// 304 の場合は保存した本文と Link ヘッダー（304 には付かない）を補った 200 として返すことを確認します。
+func TestCacheRevalidate(t *testing.T) {
+	server, cache := cacheFixture(t)
+	url := apiURL("/repos/o/r/pulls/1/comments?page=1&per_page=100")
+
+	first, firstBody := cacheGet(t, cache, url)
+	if first.StatusCode != http.StatusOK || first.Header.Get("Link") == "" {
+		t.Fatalf("first response: status %d, Link %q", first.StatusCode, first.Header.Get("Link"))
+	}
+	if cache.Hits.Load() != 0 || server.notModified() != 0 {
+		t.Fatalf("first request was served from the cache")
+	}
+
+	second, secondBody := cacheGet(t, cache, url)
+	if n := server.notModified(); n != 1 {
+		t.Fatalf("server returned 304 %d times, want 1", n)
+	}
+	if cache.Hits.Load() != 1 {
+		t.Errorf("Hits = %d, want 1", cache.Hits.Load())
+	}
+	if second.StatusCode != http.StatusOK {
+		t.Errorf("revalidated status = %d, want 200", second.StatusCode)
+	}
+	if !bytes.Equal(secondBody, firstBody) {
+		t.Errorf("revalidated body = %q, want %q", secondBody, firstBody)
+	}
+	for _, name := range []string{"Link", "ETag", "Content-Type"} {
+		if got, want := second.Header.Get(name), first.Header.Get(name); got != want {
+			t.Errorf("revalidated %s = %q, want %q", name, got, want)
+		}
+	}
+	if second.ContentLength != int64(len(firstBody)) {
+		t.Errorf("revalidated ContentLength = %d, want %d", second.ContentLength, len(firstBody))
+	}
+}
+
+// TestCacheCorruption はサイズやハッシュがキャッシュの記録と一致しない本文を使わず、
+// キャッシュを削除して If-None-Match なしで取得し直し、新しいレスポンスを保存し直すことを確認します。
+func TestCacheCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(body []byte) []byte
+	}{
+		{"size", func(body []byte) []byte { return body[:len(body)-1] }},
+		{"sha256", func(body []byte) []byte {
+			// サイズを変えずに1バイトだけ書き換える
+			changed := append([]byte(nil), body...)
+			changed[len(changed)/2] ^= 0x20
+			return changed
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var conditional []bool
+			_, cache := cacheFixture(t, func(f *fakeGitHub) {
+				f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+					mu.Lock()
+					defer mu.Unlock()
+					conditional = append(conditional, r.Header.Get("If-None-Match") != "")
+					return false
+				}
+			})
+			url := apiURL("/repos/o/r/pulls/1/comments?page=2&per_page=100")
+			_, want := cacheGet(t, cache, url)
+
+			key := cacheKey(url, mediaTypeJSON)
+			metaPath, bodyPath := cache.paths(key)
+			data, err := os.ReadFile(bodyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(bodyPath, tt.corrupt(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := cache.load(key, url, mediaTypeJSON); err == nil || !strings.Contains(err.Error(), "does not match its checksum") {
+				t.Fatalf("load of a corrupt entry: %v", err)
+			}
+
+			resp, got := cacheGet(t, cache, url)
+			if resp.StatusCode != http.StatusOK || !bytes.Equal(got, want) {
+				t.Errorf("refetched response: status %d, body %q, want %q", resp.StatusCode, got, want)
+			}
+			if cache.Hits.Load() != 0 {
+				t.Errorf("corrupt entry was served (Hits = %d)", cache.Hits.Load())
+			}
+			mu.Lock()
+			if len(conditional) != 2 || conditional[1] {
+				t.Errorf("If-None-Match sent per request = %v, want the refetch to be unconditional", conditional)
+			}
+			mu.Unlock()
+			// 取得し直したレスポンスは保存し直され、次回は 304 で使われる
+			if _, _, err := cache.load(key, url, mediaTypeJSON); err != nil {
+				t.Fatalf("entry was not stored again: %v", err)
+			}
+			cacheGet(t, cache, url)
+			if cache.Hits.Load() != 1 {
+				t.Errorf("Hits after the refetch = %d, want 1", cache.Hits.Load())
+			}
+
+			cache.remove(key)
+			for _, path := range []string{metaPath, bodyPath} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s still exists after remove", filepath.Base(path))
+				}
+			}
+		})
+	}
+}
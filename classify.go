@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"  // 分類のための語句の検出に使用
+	"strings" // 文字列操作に使用
+)
+
+// コメントの分類の定数です。
+const (
+	categoryNit        = "nit"        // 細かい指摘
+	categoryQuestion   = "question"   // 質問
+	categorySuggestion = "suggestion" // 修正の提案（```suggestion ブロック）
+	categoryBlocker    = "blocker"    // マージ前に対応が必要な指摘
+	categoryOther      = "other"      // いずれにも当てはまらないもの
+)
+
+// heuristicCategories は組み込みの分類器が付ける分類の一覧です（--category の検証に使用）。
+var heuristicCategories = []string{categoryNit, categoryQuestion, categorySuggestion, categoryBlocker, categoryOther}
+
+var (
+	// nitPattern は先頭の "nit:" や "nitpick" にマッチします。
+	nitPattern = regexp.MustCompile(`(?i)^\s*(\*\*|_)?(nit|nitpick)\b`)
+	// suggestionPattern は ```suggestion ブロックにマッチします。
+	suggestionPattern = regexp.MustCompile("(?m)^\\s*(```|~~~)\\s*suggestion\\b")
+	// blockerPattern は対応が必須であることを示す語句にマッチします。
+	blockerPattern = regexp.MustCompile(`(?i)\b(must|blocker|blocking)\b|\bdo(?:n'?t| not) merge\b|必須|ブロッカー|マージしないで`)
+	// questionOpenerPattern は疑問文の書き出しにマッチします。
+	questionOpenerPattern = regexp.MustCompile(`(?i)^\s*(why|what|how|when|where|who|which|could|can|should|would|is|are|do|does|did|will|any reason)\b|^\s*(なぜ|どうして)`)
+)
+
+// classifyComment はコメント本文を簡単な手がかりから分類します。
+// 判定は nit → blocker → suggestion → question の順に行い、いずれにも当てはまらなければ other になります。
+//
+// パラメータ:
+//   - body: コメント本文
+//
+// 戻り値:
+//   - string: 分類（nit/question/suggestion/blocker/other）
+func classifyComment(body string) string {
+	switch {
+	case nitPattern.MatchString(body):
+		return categoryNit
+	case blockerPattern.MatchString(body):
+		return categoryBlocker
+	case suggestionPattern.MatchString(body):
+		return categorySuggestion
+	case isQuestion(body):
+		return categoryQuestion
+	}
+	return categoryOther
+}
+
+// isQuestion は本文が質問かどうかを判定します。末尾が "?"（全角を含む）の場合、または疑問文の書き出しの場合に質問とみなします。
+func isQuestion(body string) bool {
+	trimmed := strings.TrimSpace(body)
+	return strings.HasSuffix(trimmed, "?") || strings.HasSuffix(trimmed, "？") || questionOpenerPattern.MatchString(trimmed)
+}
+
+// annotateCategories は各コメントに分類を設定します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+//   - classify: 本文から分類を求める関数（組み込みの classifyComment、またはルールファイルの classify）
+func annotateCategories(comments []Comment, classify func(string) string) {
+	for i := range comments {
+		comments[i].Category = classify(comments[i].Body)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"           // フォーマット済み入出力に使用
+	"os"            // ファイル操作に使用
+	"path/filepath" // ファイルパス操作に使用
+	"sync"          // 並行して処理するリポジトリからの追加の排他制御に使用
+)
+
+// combinedDir は --merge-across-repos で、すべてのリポジトリのコメントをまとめたファイルを保存するディレクトリです。
+var combinedDir = filepath.Join("comments", "_combined")
+
+// combinedComments は --merge-across-repos で、すべてのリポジトリのコメントを1つのファイルにまとめるために集めます。
+// 複数のリポジトリを並行して処理する場合も、保存時にはリポジトリ一覧の順に並べます。
+type combinedComments struct {
+	mu       sync.Mutex
+	prs      map[string][]PullRequest // owner/repo → コメントを集めたPR（集めた順）
+	comments map[string][]PRComment   // owner/repo → コメント（PRの順）
+}
+
+// newCombinedComments はリポジトリをまたいでコメントを集める combinedComments を作成します。
+func newCombinedComments() *combinedComments {
+	return &combinedComments{
+		prs:      make(map[string][]PullRequest),
+		comments: make(map[string][]PRComment),
+	}
+}
+
+// add は1つのPRのコメントを、リポジトリ名と一緒に集めます。
+func (c *combinedComments) add(owner, repo string, pr PullRequest, comments []Comment) {
+	name := owner + "/" + repo
+	pr.Repository = name
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prs[name] = append(c.prs[name], pr)
+	for _, comment := range comments {
+		c.comments[name] = append(c.comments[name], PRComment{
+			Owner:    owner,
+			Repo:     repo,
+			PRNumber: pr.Number,
+			Labels:   pr.LabelNames(),
+			Comment:  comment,
+		})
+	}
+}
+
+// combinedCommentsFile はすべてのリポジトリのコメントをまとめて保存するファイルパスを返します。
+func combinedCommentsFile(format string) string {
+	return filepath.Join(combinedDir, "all_pr_comments"+formatExt(format))
+}
+
+// save は集めたコメントを comments/_combined/ に1つのファイルとして保存します。
+// リポジトリ一覧の順、同じリポジトリ内ではPRの順に並べ、時系列順の場合はリポジトリに関係なく作成日時順に並べます。
+// 各レコードと見出しには、コメントが属するリポジトリとPR番号を含めます。
+//
+// パラメータ:
+//   - repos: リポジトリ一覧（並び順に使用）
+//   - format: 出力形式（text/json/csv/html/markdown）
+//   - chrono: 作成日時順に並べるかどうか（--order chrono）
+//   - out: 警告の出力先
+//   - partial: 実行を途中で打ち切った場合はその理由（テキスト・HTML・Markdownの見出しに記録する）、すべて取得した場合は空文字
+//
+// 戻り値:
+//   - int: 保存したコメントの数
+//   - int: コメントを保存したPRの数
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func (c *combinedComments) save(repos []repoEntry, format string, chrono bool, partial string, out *console) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var allComments []PRComment
+	prByKey := make(map[string]PullRequest)
+	for _, r := range repos {
+		name := r.Name()
+		for _, pr := range c.prs[name] {
+			prByKey[combinedKey(name, pr.Number)] = pr
+		}
+		allComments = append(allComments, c.comments[name]...)
+	}
+	if chrono {
+		sortChronologically(allComments, out)
+	}
+
+	if err := os.MkdirAll(combinedDir, 0755); err != nil {
+		return 0, 0, fmt.Errorf("failed to create directory: %v", err)
+	}
+	f, err := os.Create(combinedCommentsFile(format))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	// PRが切り替わるごとにセクションを分ける
+	var sections []prSection
+	currentKey := ""
+	for _, pc := range allComments {
+		key := combinedKey(pc.Owner+"/"+pc.Repo, pc.PRNumber)
+		if key != currentKey {
+			sections = append(sections, prSection{PR: prByKey[key]})
+			currentKey = key
+		}
+		last := &sections[len(sections)-1]
+		last.Comments = append(last.Comments, pc.Comment)
+	}
+
+	title := partialTitle(fmt.Sprintf("Review comments from %d repositories", len(c.prs)), partial)
+	switch format {
+	case formatHTML:
+		err = writeHTMLReport(f, title, sections)
+	case formatMarkdown:
+		err = writeMarkdownReport(f, title, sections)
+	case formatText:
+		err = writePartialNotice(f, partial)
+		for _, s := range sections {
+			if err != nil {
+				break
+			}
+			if err = writePRHeader(f, s.PR); err != nil {
+				break
+			}
+			for _, comment := range s.Comments {
+				if err = writeTextComment(f, fmt.Sprintf("%s PR #%d ", s.PR.Repository, s.PR.Number), comment); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+	default:
+		records := make([]commentRecord, 0, len(allComments))
+		for _, pc := range allComments {
+			records = append(records, newCommentRecord(pc))
+		}
+		err = writeRecords(f, format, records)
+	}
+	return len(allComments), len(prByKey), err
+}
+
+// combinedKey はリポジトリをまたいでPRを区別するためのキーを返します。
+func combinedKey(repository string, number int) string {
+	return fmt.Sprintf("%s#%d", repository, number)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"fmt"      // フォーマット済み入出力に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"strings"  // PR番号の連結に使用
+)
+
+// fullSHALength は省略されていないコミットSHAの長さです。
+const fullSHALength = 40
+
+// commitInfo はコミットAPIのレスポンスのうち、SHAだけを格納する構造体です。
+type commitInfo struct {
+	SHA string `json:"sha"` // 省略されていないコミットSHA
+}
+
+// resolveCommitSHA は省略されたコミットSHAを、コミットAPIを使って省略されていないSHAに解決します。
+// 省略されていないSHAはそのまま返します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - sha: コミットSHA（省略形を含む）
+//   - token: GitHub APIアクセス用のトークン
+//   - client: リクエストに使用するHTTPクライアント
+//
+// 戻り値:
+//   - string: 省略されていないコミットSHA
+//   - error: コミットが見つからない場合や取得に失敗した場合のエラー情報
+func resolveCommitSHA(ctx context.Context, owner, repo, sha, token string, client *http.Client) (string, error) {
+	if len(sha) == fullSHALength {
+		return sha, nil
+	}
+	var commit commitInfo
+	status, err := getJSON(ctx, client, apiURL("/repos/%s/%s/commits/%s", owner, repo, sha), token, &commit)
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		return "", fmt.Errorf("commit %s not found in %s/%s (or the short SHA is ambiguous)", sha, owner, repo)
+	}
+	if err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+// fetchCommitPRNumbers はコミットを含むプルリクエストの番号を取得します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - sha: 省略されていないコミットSHA
+//   - token: GitHub APIアクセス用のトークン
+//   - client: リクエストに使用するHTTPクライアント
+//
+// 戻り値:
+//   - []int: コミットを含むPRの番号（どのPRにも含まれない場合は空）
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func fetchCommitPRNumbers(ctx context.Context, owner, repo, sha, token string, client *http.Client) ([]int, error) {
+	var prs []PullRequest
+	if _, err := getJSON(ctx, client, apiURL("/repos/%s/%s/commits/%s/pulls", owner, repo, sha), token, &prs); err != nil {
+		return nil, err
+	}
+	numbers := make([]int, 0, len(prs))
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	return numbers, nil
+}
+
+// joinPRNumbers はPR番号の配列を "#12, #15" の形式で連結します。
+func joinPRNumbers(numbers []int) string {
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = fmt.Sprintf("#%d", n)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"flag"          // 設定ファイルの値のフラグへの反映に使用
+	"fmt"           // エラーメッセージの作成と設定の出力に使用
+	"io"            // 解決した設定の出力先に使用
+	"os"            // 設定ファイルと環境変数の読み込みに使用
+	"path/filepath" // トークンファイルの相対パスの基準に使用
+	"strconv"       // PR番号の文字列への変換に使用
+	"strings"       // 設定の出力の組み立てに使用
+)
+
+// defaultConfigFile は --config を指定しない場合に、作業ディレクトリにあれば読み込む設定ファイルです。
+const defaultConfigFile = ".fetch_pr_comments.yaml"
+
+// secretFlags は --print-config で値を表示しないフラグです。
+var secretFlags = map[string]bool{"token": true, "anonymize-key": true}
+
+// commandLineOnlyFlags は設定ファイルには書けないフラグです。
+var commandLineOnlyFlags = map[string]bool{"config": true, "print-config": true}
+
+// repoSelectionKeys は対象のリポジトリを選ぶ設定のキーです。
+// コマンドラインか環境変数でリポジトリを指定した場合は、設定ファイルのこれらのキーをまとめて無視します。
+var repoSelectionKeys = map[string]bool{"owner": true, "repo": true, "repos-file": true, "repos": true}
+
+// configValue は設定ファイルに書かれた1つのフラグの値です。
+type configValue struct {
+	Name string    // フラグ名（先頭の -- を除いたもの）
+	Node *yamlNode // 値（繰り返し指定できるフラグの場合はシーケンスも可）
+	Line int       // キーが書かれている行番号
+}
+
+// fileConfig は設定ファイルから読み込んだ設定です。
+type fileConfig struct {
+	Path   string        // 設定ファイルのパス（設定ファイルを使わない場合は空）
+	Values []configValue // フラグの値（記述順）
+	Repos  []repoEntry   // repos: に書かれたリポジトリ（記述順）
+	Tokens tokenMap      // tokens: に書かれたリポジトリごとのトークンの読み込み元
+}
+
+// configFilePath は読み込む設定ファイルのパスを返します。
+// --config を指定した場合はその値を（空の場合は設定ファイルを使わない）、
+// 指定しない場合は作業ディレクトリに .fetch_pr_comments.yaml があればそのパスを返します。
+func configFilePath(path string, explicit bool) string {
+	if explicit {
+		return path
+	}
+	if _, err := os.Stat(defaultConfigFile); err == nil {
+		return defaultConfigFile
+	}
+	return ""
+}
+
+// isListFlag は繰り返し指定できるフラグかどうかを返します（設定ファイルでシーケンスを書けるフラグ）。
+func isListFlag(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case *stringList, *rawList, *prNumberList:
+		return true
+	}
+	return false
+}
+
+// loadConfig はYAML形式の設定ファイルを読み込みます。
+// トップレベルのキーは先頭の -- を除いたフラグ名で、繰り返し指定できるフラグには値のシーケンスも書けます。
+// repos: には、owner/repo（またはリポジトリのURL）か、repo・count・base を持つマッピングを並べます。
+// tokens: には、owner または owner/repo ごとに使うトークンの読み込み元（環境変数名またはファイルパス）を書きます。
+//
+//	count: 50
+//	author: [alice, bob]
+//	repos:
+//	  - acme/api
+//	  - repo: acme/web
+//	    base: main
+//	tokens:
+//	  acme: ACME_TOKEN
+//
+// パラメータ:
+//   - path: 設定ファイルのパス
+//
+// 戻り値:
+//   - *fileConfig: 読み込んだ設定
+//   - error: 読み込みや検証に失敗した場合は、ファイル名と行番号を含むエラー情報
+func loadConfig(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if root.Kind != yamlMapping {
+		return nil, fmt.Errorf("%s:%d: expected a mapping of flag names to values", path, root.Line)
+	}
+
+	cfg := &fileConfig{Path: path}
+	seen := make(map[string]int) // キー → 最初に書かれた行番号
+	for _, pair := range root.Pairs {
+		if first, ok := seen[pair.Key]; ok {
+			return nil, fmt.Errorf("%s:%d: %q is already set on line %d", path, pair.Line, pair.Key, first)
+		}
+		seen[pair.Key] = pair.Line
+		if pair.Key == "repos" {
+			if cfg.Repos, err = parseConfigRepos(pair.Value); err != nil {
+				return nil, fmt.Errorf("%s:%v", path, err)
+			}
+			continue
+		}
+		if pair.Key == "tokens" {
+			if cfg.Tokens, err = parseConfigTokens(pair.Value, filepath.Dir(path)); err != nil {
+				return nil, fmt.Errorf("%s:%v", path, err)
+			}
+			continue
+		}
+		if commandLineOnlyFlags[pair.Key] {
+			return nil, fmt.Errorf("%s:%d: %q can only be given on the command line", path, pair.Line, pair.Key)
+		}
+		f := flag.Lookup(pair.Key)
+		if f == nil {
+			return nil, fmt.Errorf("%s:%d: unknown key %q (expected a flag name without the leading --, repos or tokens)", path, pair.Line, pair.Key)
+		}
+		switch v := pair.Value; {
+		case v.Kind == yamlMapping:
+			return nil, fmt.Errorf("%s:%d: %s: expected a value, not a mapping", path, pair.Line, pair.Key)
+		case v.Kind == yamlSequence && !isListFlag(f):
+			return nil, fmt.Errorf("%s:%d: %s: expected a single value (only repeatable flags take a list)", path, pair.Line, pair.Key)
+		case v.Kind == yamlSequence:
+			for _, item := range v.Items {
+				if item.Kind != yamlScalar || item.Null {
+					return nil, fmt.Errorf("%s:%d: %s: expected a list of values", path, item.Line, pair.Key)
+				}
+			}
+		case v.Null:
+			return nil, fmt.Errorf("%s:%d: %s: missing value", path, pair.Line, pair.Key)
+		}
+		cfg.Values = append(cfg.Values, configValue{Name: pair.Key, Node: pair.Value, Line: pair.Line})
+	}
+	if len(cfg.Repos) > 0 {
+		for _, v := range cfg.Values {
+			if repoSelectionKeys[v.Name] {
+				return nil, fmt.Errorf("%s:%d: %q cannot be combined with repos", path, v.Line, v.Name)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// parseConfigRepos は設定ファイルの repos: を検証して repoEntry の一覧に変換します。
+func parseConfigRepos(node *yamlNode) ([]repoEntry, error) {
+	if node.Kind != yamlSequence || len(node.Items) == 0 {
+		return nil, fmt.Errorf("%d: repos must be a non-empty list", node.Line)
+	}
+	var entries []repoEntry
+	seen := make(map[string]int) // 小文字のリポジトリ名 → 最初に書かれた行番号
+	for _, item := range node.Items {
+		var entry repoEntry
+		switch item.Kind {
+		case yamlScalar:
+			e, err := parseRepoEntry(item.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", item.Line, err)
+			}
+			entry = e
+		case yamlMapping:
+			spec := item.Get("repo")
+			if spec == nil || spec.Kind != yamlScalar || spec.Null {
+				return nil, fmt.Errorf("%d: repos entry needs a repo key (owner/repo)", item.Line)
+			}
+			e, err := parseRepoEntry(spec.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", spec.Line, err)
+			}
+			entry = e
+			for _, pair := range item.Pairs {
+				switch pair.Key {
+				case "repo":
+				case "count", "base":
+					value, err := pair.Value.String()
+					if err != nil {
+						return nil, fmt.Errorf("%d: %s: %v", pair.Line, pair.Key, err)
+					}
+					if err := entry.override(pair.Key, value); err != nil {
+						return nil, fmt.Errorf("%d: %v", pair.Line, err)
+					}
+				default:
+					return nil, fmt.Errorf("%d: unknown key %q in repos entry (expected repo, count or base)", pair.Line, pair.Key)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%d: repos entry must be owner/repo or a mapping with repo, count and base", item.Line)
+		}
+		name := strings.ToLower(entry.Name())
+		if first, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%d: %s is already listed on line %d", item.Line, entry.Name(), first)
+		}
+		seen[name] = item.Line
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// commandLineFlags はコマンドラインで指定されたフラグ名の集合を返します（設定ファイルを反映する前に呼び出します）。
+func commandLineFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// apply は設定ファイルの値を、コマンドラインでも環境変数でも指定されていないフラグに反映します。
+// 優先順位はコマンドラインのフラグ > 環境変数 > 設定ファイル > デフォルト値です。
+//
+// パラメータ:
+//   - set: コマンドラインか環境変数で指定されたフラグ名の集合
+//   - explicitRepo: コマンドラインか環境変数で対象のリポジトリを指定したか（指定した場合は設定ファイルのリポジトリの指定を無視する）
+//
+// 戻り値:
+//   - error: 値が不正な場合は、ファイル名と行番号を含むエラー情報
+func (c *fileConfig) apply(set map[string]bool, explicitRepo bool) error {
+	for _, v := range c.Values {
+		if set[v.Name] || (explicitRepo && repoSelectionKeys[v.Name]) {
+			continue
+		}
+		values := []string{v.Node.Value}
+		if v.Node.Kind == yamlSequence {
+			values = values[:0]
+			for _, item := range v.Node.Items {
+				values = append(values, item.Value)
+			}
+		}
+		for _, value := range values {
+			if err := flag.Set(v.Name, value); err != nil {
+				return fmt.Errorf("%s:%d: invalid value %q for %s: %v", c.Path, v.Line, value, v.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// source はフラグの値の由来を返します（--print-config 用）。
+func (c *fileConfig) source(name string, cli map[string]bool, env map[string]string, explicitRepo bool) string {
+	if cli[name] {
+		return "flag"
+	}
+	if e, ok := env[name]; ok {
+		return "environment ($" + e + ")"
+	}
+	if !(explicitRepo && repoSelectionKeys[name]) {
+		for _, v := range c.Values {
+			if v.Name == name {
+				return fmt.Sprintf("config %s:%d", c.Path, v.Line)
+			}
+		}
+	}
+	return "default"
+}
+
+// printConfig は解決した設定を、設定ファイルと同じYAML形式で出力します（--print-config 用）。
+// 各値の由来（flag・environment・config・default）を行末のコメントとして付けるため、優先順位の確認に使えます。
+// トークンなどの秘密の値は表示しません。
+//
+// パラメータ:
+//   - w: 出力先
+//   - cfg: 読み込んだ設定ファイル
+//   - cli: コマンドラインで指定されたフラグ名の集合
+//   - env: 環境変数から値を反映したフラグ名 → 環境変数名
+//   - explicitRepo: コマンドラインか環境変数で対象のリポジトリを指定したか
+//   - repos: 処理するリポジトリ
+func printConfig(w io.Writer, cfg *fileConfig, cli map[string]bool, env map[string]string, explicitRepo bool, repos []repoEntry) {
+	if cfg.Path != "" {
+		fmt.Fprintf(w, "# Resolved configuration (config file: %s)\n", cfg.Path)
+	} else {
+		fmt.Fprintln(w, "# Resolved configuration (no config file)")
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		if commandLineOnlyFlags[f.Name] {
+			return
+		}
+		value := configValueString(f)
+		if secretFlags[f.Name] && f.Value.String() != "" {
+			value = "'<redacted>'"
+		}
+		fmt.Fprintf(w, "%s: %s # %s\n", f.Name, value, cfg.source(f.Name, cli, env, explicitRepo))
+	})
+	// トークンの読み込み元は、値ではなく環境変数名とファイルパスを出力する
+	if len(cfg.Tokens) > 0 {
+		fmt.Fprintln(w, "tokens:")
+		for _, t := range cfg.Tokens {
+			if t.Env != "" {
+				fmt.Fprintf(w, "  %s:\n    env: %s\n", quoteYAMLScalar(t.Pattern), t.Env)
+			} else {
+				fmt.Fprintf(w, "  %s:\n    file: %s\n", quoteYAMLScalar(t.Pattern), quoteYAMLScalar(t.File))
+			}
+		}
+	}
+	// 処理するリポジトリは、由来に関係なく解決した結果を repos: として出力する
+	fmt.Fprintln(w, "repos:")
+	for _, r := range repos {
+		fmt.Fprintf(w, "  - repo: %s\n", quoteYAMLScalar(r.Name()))
+		if r.Count > 0 {
+			fmt.Fprintf(w, "    count: %d\n", r.Count)
+		}
+		if r.Base != "" {
+			fmt.Fprintf(w, "    base: %s\n", quoteYAMLScalar(r.Base))
+		}
+	}
+}
+
+// configValueString はフラグの現在の値をYAMLの値として返します。繰り返し指定できるフラグはフロー形式のシーケンスにします。
+func configValueString(f *flag.Flag) string {
+	var items []string
+	switch v := f.Value.(type) {
+	case *stringList:
+		items = *v
+	case *rawList:
+		items = *v
+	case *prNumberList:
+		for _, n := range *v {
+			items = append(items, strconv.Itoa(n))
+		}
+	default:
+		return quoteYAMLScalar(f.Value.String())
+	}
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = quoteYAMLScalar(item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
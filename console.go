@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes" // 行ごとの接頭辞の付与に使用
+	"fmt"   // フォーマット済み入出力に使用
+	"log"   // エラーと警告の出力に使用
+	"os"    // 標準出力への書き込みに使用
+	"sync"  // 並行したリポジトリの出力の排他制御に使用
+)
+
+// stdoutMu は並行して処理するリポジトリの進捗が、標準出力で行の途中から混ざらないようにします。
+var stdoutMu sync.Mutex
+
+// console はリポジトリごとの進捗（標準出力）とエラー・警告（標準エラー出力）の出力先です。
+// 複数のリポジトリを処理する場合は、出力が混ざっても読めるよう各行の先頭に [owner/repo] を付けます。
+// io.Writer を実装しているため、表やJSONの書き込み先としても使えます。
+type console struct {
+	prefix  string      // 各行の先頭に付ける文字列（空の場合は付けない）
+	logger  *log.Logger // エラーと警告の出力先
+	midLine bool        // 直前の書き込みが行の途中で終わっているか（次の書き込みの先頭に接頭辞を付けない）
+}
+
+// newConsole は各行の先頭に prefix を付けて出力する console を作成します。
+// prefix が空の場合は、標準出力と標準のロガーにそのまま出力します。
+func newConsole(prefix string) *console {
+	return &console{prefix: prefix, logger: log.New(log.Writer(), prefix, log.Flags()|log.Lmsgprefix)}
+}
+
+// Write は io.Writer インタフェースの実装です。各行の先頭に接頭辞を付けて標準出力に書き込みます。
+func (c *console) Write(p []byte) (int, error) {
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	if c.prefix == "" {
+		return os.Stdout.Write(p)
+	}
+	var b bytes.Buffer
+	for rest := p; len(rest) > 0; {
+		if !c.midLine {
+			b.WriteString(c.prefix)
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			b.Write(rest)
+			c.midLine = true
+			break
+		}
+		b.Write(rest[:i+1])
+		rest = rest[i+1:]
+		c.midLine = false
+	}
+	if _, err := os.Stdout.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Printf は進捗を標準出力に表示します（fmt.Printf と同じ書式）。
+func (c *console) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(c, format, args...)
+}
+
+// Println は進捗を標準出力に表示します（fmt.Println と同じ書式）。
+func (c *console) Println(args ...interface{}) {
+	fmt.Fprintln(c, args...)
+}
+
+// Logf はエラーや警告を標準エラー出力に表示します（log.Printf と同じ書式）。
+func (c *console) Logf(format string, args ...interface{}) {
+	c.logger.Printf(format, args...)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"         // トークンファイルの1行目の読み込みに使用
+	"fmt"           // エラーメッセージの作成に使用
+	"io"            // トークンの読み込み元に使用
+	"log"           // 使えなかったトークンの読み込み元の表示に使用
+	"os"            // 環境変数とトークンファイルの読み込みに使用
+	"path/filepath" // トークンファイルのパスの解決に使用
+	"regexp"        // 環境変数名の判定に使用
+	"runtime"       // ファイルの権限を確認するOSの判定に使用
+	"strings"       // 文字列操作に使用
+)
+
+// defaultCredential は tokens: のどれにも一致しないリポジトリで使う、--token（または環境変数・設定ファイル）のトークンの表示名です。
+const defaultCredential = "default (--token)"
+
+// envNamePattern は tokens: の値を環境変数名とみなす形式です（それ以外はファイルパスとして扱います）。
+var envNamePattern = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// tokenSource は設定ファイルの tokens: の1件分で、リポジトリに使うトークンの読み込み元です。
+// トークンの値そのものは保持せず、リポジトリを処理するときに読み込みます。
+type tokenSource struct {
+	Pattern string // owner または owner/repo（小文字）
+	Env     string // トークンを読み込む環境変数名
+	File    string // トークンを読み込むファイルのパス
+}
+
+// Name はトークンの読み込み元を、値を含まない表示用の名前で返します。
+func (s tokenSource) Name() string {
+	if s.Env != "" {
+		return "$" + s.Env
+	}
+	return "file " + s.File
+}
+
+// token はトークンを読み込みます。エラーメッセージにはトークンの値を含めません。
+func (s tokenSource) token() (string, error) {
+	if s.Env != "" {
+		v := strings.TrimSpace(os.Getenv(s.Env))
+		if v == "" {
+			return "", fmt.Errorf("environment variable %s for %s is not set", s.Env, s.Pattern)
+		}
+		return v, nil
+	}
+	data, err := os.ReadFile(s.File)
+	if err != nil {
+		return "", fmt.Errorf("cannot read token file for %s: %v", s.Pattern, err)
+	}
+	v := strings.TrimSpace(string(data))
+	if v == "" {
+		return "", fmt.Errorf("token file %s for %s is empty", s.File, s.Pattern)
+	}
+	return v, nil
+}
+
+// tokenMap は設定ファイルの tokens: から読み込んだ、リポジトリごとのトークンの読み込み元です。
+type tokenMap []tokenSource
+
+// parseConfigTokens は設定ファイルの tokens: を検証して tokenMap に変換します。
+// キーは owner または owner/repo で、値は環境変数名（大文字・数字・_ のみ）かファイルパス、
+// または env・file のどちらか1つを持つマッピングです。相対パスは設定ファイルのディレクトリを基準にします。
+//
+//	tokens:
+//	  acme: ACME_TOKEN
+//	  acme/secret:
+//	    file: ~/.config/tokens/secret
+//
+// パラメータ:
+//   - node: tokens: の値
+//   - dir: 設定ファイルのディレクトリ
+//
+// 戻り値:
+//   - tokenMap: リポジトリごとのトークンの読み込み元
+//   - error: 不正な指定の場合は行番号を含むエラー情報
+func parseConfigTokens(node *yamlNode, dir string) (tokenMap, error) {
+	if node.Kind != yamlMapping || len(node.Pairs) == 0 {
+		return nil, fmt.Errorf("%d: tokens must be a mapping of owner or owner/repo to an environment variable or file", node.Line)
+	}
+	var tokens tokenMap
+	for _, pair := range node.Pairs {
+		pattern := strings.ToLower(pair.Key)
+		if parts := strings.Split(pattern, "/"); len(parts) > 2 || parts[0] == "" || len(parts) == 2 && parts[1] == "" {
+			return nil, fmt.Errorf("%d: invalid tokens key %q (expected owner or owner/repo)", pair.Line, pair.Key)
+		}
+		source := tokenSource{Pattern: pattern}
+		switch v := pair.Value; v.Kind {
+		case yamlScalar:
+			if v.Null || v.Value == "" {
+				return nil, fmt.Errorf("%d: tokens: %s: missing environment variable or file", pair.Line, pair.Key)
+			}
+			if envNamePattern.MatchString(v.Value) {
+				source.Env = v.Value
+			} else {
+				source.File = v.Value
+			}
+		case yamlMapping:
+			for _, p := range v.Pairs {
+				value, err := p.Value.String()
+				if err != nil || value == "" {
+					return nil, fmt.Errorf("%d: tokens: %s: %s must be a non-empty string", p.Line, pair.Key, p.Key)
+				}
+				switch p.Key {
+				case "env":
+					source.Env = value
+				case "file":
+					source.File = value
+				default:
+					return nil, fmt.Errorf("%d: tokens: %s: unknown key %q (expected env or file)", p.Line, pair.Key, p.Key)
+				}
+			}
+			if (source.Env == "") == (source.File == "") {
+				return nil, fmt.Errorf("%d: tokens: %s: give exactly one of env or file", pair.Line, pair.Key)
+			}
+		default:
+			return nil, fmt.Errorf("%d: tokens: %s: expected an environment variable, a file or a mapping with env or file", pair.Line, pair.Key)
+		}
+		if source.File != "" {
+			source.File = resolveTokenFile(source.File, dir)
+		}
+		for _, t := range tokens {
+			if t.Pattern == pattern {
+				return nil, fmt.Errorf("%d: tokens: %s is already set", pair.Line, pair.Key)
+			}
+		}
+		tokens = append(tokens, source)
+	}
+	return tokens, nil
+}
+
+// resolveTokenFile はトークンファイルのパスを解決します。~/ はホームディレクトリに、相対パスは設定ファイルのディレクトリを基準にします。
+func resolveTokenFile(path, dir string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolve はリポジトリに使うトークンと、その読み込み元の表示名を返します。
+// owner/repo の指定を owner の指定より優先し、どちらにも一致しない場合は defaultToken を使います。
+//
+// パラメータ:
+//   - owner: オーナー名
+//   - repo: リポジトリ名
+//   - defaultToken: tokens: に一致しない場合に使うトークン（--token など）
+//
+// 戻り値:
+//   - string: リポジトリに使うトークン
+//   - string: トークンの読み込み元の表示名（トークンの値は含まない）
+//   - error: トークンを読み込めない場合のエラー情報（トークンの値は含まない）
+func (m tokenMap) resolve(owner, repo, defaultToken string) (string, string, error) {
+	name := strings.ToLower(owner + "/" + repo)
+	var match *tokenSource
+	for i, t := range m {
+		if t.Pattern == name {
+			match = &m[i]
+			break
+		}
+		if t.Pattern == strings.ToLower(owner) {
+			match = &m[i]
+		}
+	}
+	if match == nil {
+		if defaultToken == "" {
+			return "", "", fmt.Errorf("no token for %s/%s: add it to tokens: in the config file or pass --token", owner, repo)
+		}
+		return defaultToken, defaultCredential, nil
+	}
+	token, err := match.token()
+	return token, match.Name(), err
+}
+
+// readTokenFile は --token-file からトークンを読み込みます。ファイルの1行目の前後の空白を除いたものをトークンとし、
+// "-" の場合は標準入力の1行目を読み込みます（シークレットマネージャーからパイプで渡す場合）。
+// 他のユーザーも読めるファイルは、allowReadable が指定されない限り読み込みません（Windows では確認しません）。
+// エラーメッセージにはトークンの値を含めません。
+//
+// パラメータ:
+//   - path: トークンファイルのパス、または標準入力を表す "-"
+//   - allowReadable: 他のユーザーも読めるファイルを許可するか
+//
+// 戻り値:
+//   - string: トークン
+//   - error: ファイルを読み込めない場合や、空・他のユーザーも読めるファイルの場合のエラー情報
+func readTokenFile(path string, allowReadable bool) (string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read --token-file: %v", err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0044 != 0 && !allowReadable {
+			return "", fmt.Errorf("--token-file %s is readable by other users (mode %v); run chmod 600 %s or pass --allow-readable-token-file", path, info.Mode().Perm(), path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read --token-file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("cannot read --token-file %s: %v", path, err)
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return "", fmt.Errorf("--token-file %s is empty", path)
+	}
+	return token, nil
+}
+
+// tokenOptions はトークンを探す場所と順序に関わるフラグと設定です。
+type tokenOptions struct {
+	Token             string // --token・環境変数・設定ファイルの token: から決まった最初のトークン（ない場合は空）
+	FromCLI           bool   // Token をコマンドラインの --token で指定したか
+	Env               string // Token を読み込んだ環境変数名（環境変数から読み込んでいない場合は空）
+	ConfigPath        string // 設定ファイルのパス（Token を設定ファイルから読み込んだ場合の表示に使用）
+	File              string // --token-file のパス（--token を指定した場合は使わない）
+	AllowReadableFile bool   // ほかのユーザーが読めるトークンファイルを許可するか（--allow-readable-token-file）
+	StdinBusy         bool   // 標準入力をほかの用途（--pr-stdin）に使うか
+	Auth              string // トークンの取得方法（--auth）
+	App               bool   // GitHub App のインストールとして認証するか
+	Offline           bool   // --offline で保存したレスポンスを使うか
+	RepoTokens        bool   // 設定ファイルの tokens: にリポジトリごとのトークンがあるか
+}
+
+// tokenLookups はOSのキーリング・--login で保存したトークン・.netrc・gh CLI からトークンを読み込む関数です（テストで差し替えます）。
+type tokenLookups struct {
+	Keyring func(host string) (string, error)         // OSのキーリング（keyringGet）
+	Stored  func(host string) string                  // --login で保存したトークン（storedToken）
+	Netrc   func(host string) (string, string, error) // .netrc の machine エントリ（netrcToken）
+	GH      func(host string) (string, error)         // gh CLI（ghToken）
+}
+
+// systemTokenLookups は実際のキーリング・ファイル・gh CLI からトークンを読み込む tokenLookups です。
+var systemTokenLookups = tokenLookups{Keyring: keyringGet, Stored: storedToken, Netrc: netrcToken, GH: ghToken}
+
+// resolveToken は、--token、--token-file、環境変数（FETCH_PR_TOKEN・GITHUB_TOKEN_PR・GITHUB_TOKEN）、設定ファイル、
+// OSのキーリング、--login で保存したトークン、.netrc、gh CLI の順にトークンを探します。
+// --token・環境変数・設定ファイルの間の順序はフラグと環境変数の反映（applyEnv）で決まり、opts.Token に入っています。
+// --auth keyring・--auth gh の場合はそれぞれキーリング・gh CLI だけを使います。
+//
+// パラメータ:
+//   - opts: トークンを探す場所と順序に関わるフラグと設定
+//   - lookups: キーリング・保存したトークン・.netrc・gh CLI からトークンを読み込む関数
+//
+// 戻り値:
+//   - string: 見つかったトークン（見つからない場合は空）
+//   - string: トークンの読み込み元の表示名（トークンの値は含まない）
+//   - error: トークンファイルやキーリングを読み込めない場合など、続けられない場合のエラー情報、それ以外はnil
+func resolveToken(opts tokenOptions, lookups tokenLookups) (string, string, error) {
+	token, from := opts.Token, "--token"
+	if opts.Env != "" {
+		from = "$" + opts.Env
+	} else if !opts.FromCLI {
+		from = "config file " + opts.ConfigPath
+	}
+	if opts.File != "" && !opts.FromCLI {
+		if opts.File == "-" && opts.StdinBusy {
+			return "", "", fmt.Errorf("--token-file - and --pr-stdin cannot both read standard input")
+		}
+		t, err := readTokenFile(opts.File, opts.AllowReadableFile)
+		if err != nil {
+			return "", "", err
+		}
+		token, from = t, "--token-file "+opts.File
+	}
+	// --offline ではリクエストを送らないため、トークンが指定されていなければ探さずに代わりの値を使う
+	if opts.Offline && token == "" && !opts.RepoTokens {
+		token = offlineToken
+	}
+	// 見つからない場合に次の場所を探すか（--auth auto で、GitHub App として認証せず、tokens: もない場合）
+	fallback := func() bool {
+		return opts.Auth == authAuto && token == "" && !opts.RepoTokens && !opts.App
+	}
+	// --auth keyring の場合はOSのキーリングのトークンだけを使い、--auth auto の場合は環境変数と設定ファイルの次に探す
+	if opts.Auth == authKeyring || fallback() {
+		if t, err := lookups.Keyring(apiHost()); err == nil {
+			token, from = t, "the OS keyring ("+apiHost()+")"
+		} else if opts.Auth == authKeyring {
+			if err == errKeyringNotFound {
+				return "", "", fmt.Errorf("%v for %s; store one with: fetch_pr_comments auth set", err, apiHost())
+			}
+			return "", "", err
+		}
+	}
+	// --login で保存したトークン
+	if token == "" && opts.Auth != authGH && opts.Auth != authKeyring && !opts.App {
+		if t := lookups.Stored(webHost()); t != "" {
+			token, from = t, "--login ("+webHost()+")"
+		}
+	}
+	// .netrc の REST API のホストの machine エントリ
+	if token == "" && opts.Auth != authGH && opts.Auth != authKeyring && !opts.App && !opts.RepoTokens {
+		if t, path, err := lookups.Netrc(apiHost()); err != nil {
+			log.Printf("Not using .netrc: %v", err)
+		} else if t != "" {
+			token, from = t, path+" (machine "+apiHost()+")"
+		}
+	}
+	// --auth gh の場合、または --auth auto でトークンが見つからない場合は、gh CLI に保存されたトークンを使う
+	if opts.Auth == authGH || fallback() {
+		if t, err := lookups.GH(webHost()); err != nil {
+			log.Printf("Could not get a token from the gh CLI: %v", err)
+			token = ""
+		} else {
+			token, from = t, "the gh CLI ("+webHost()+")"
+		}
+	}
+	return token, from, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"        // テスト用の読み込み元のエラーの作成に使用
+	"os"            // トークンファイルの書き込みに使用
+	"path/filepath" // トークンファイルのパスの組み立てに使用
+	"strings"       // エラーメッセージの確認に使用
+	"testing"       // テストの実行に使用
+)
+
+// TestResolveToken は --token、--token-file、GITHUB_TOKEN_PR、GITHUB_TOKEN、設定ファイル、OSのキーリング、
+// --login で保存したトークン、.netrc、gh CLI の順にトークンを探し、--auth で探す場所を絞れることを確認します。
+func TestResolveToken(t *testing.T) {
+	withAPIBaseURL(t, "https://api.github.com")
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// sources はテストケースで用意するトークンの読み込み元です（空の場合はその場所にトークンがない）。
+	type sources struct {
+		flag, file, envPR, envGH, config, keyring, login, netrc, gh string
+	}
+	all := sources{"flag-token", tokenFile, "pr-token", "gh-env-token", "config-token", "keyring-token", "login-token", "netrc-token", "gh-token"}
+	tests := []struct {
+		name      string
+		src       sources
+		auth      string
+		app       bool
+		offline   bool
+		repoToken bool
+		stdin     bool
+		want      string // 期待するトークン
+		from      string // 期待する読み込み元の表示名
+		err       string // 空でなければ、エラーメッセージに含まれるべき文字列
+	}{
+		{name: "flag first", src: all, want: "flag-token", from: "--token"},
+		{name: "token file", src: sources{file: tokenFile, envPR: "pr-token", envGH: "gh-env-token", config: "config-token", keyring: "k", login: "l", netrc: "n", gh: "g"}, want: "file-token", from: "--token-file " + tokenFile},
+		{name: "GITHUB_TOKEN_PR", src: sources{envPR: "pr-token", envGH: "gh-env-token", config: "config-token", keyring: "k", login: "l", netrc: "n", gh: "g"}, want: "pr-token", from: "$GITHUB_TOKEN_PR"},
+		{name: "GITHUB_TOKEN", src: sources{envGH: "gh-env-token", config: "config-token", keyring: "k", login: "l", netrc: "n", gh: "g"}, want: "gh-env-token", from: "$GITHUB_TOKEN"},
+		{name: "config file", src: sources{config: "config-token", keyring: "k", login: "l", netrc: "n", gh: "g"}, want: "config-token", from: "config file /etc/fpc.yaml"},
+		{name: "keyring", src: sources{keyring: "keyring-token", login: "l", netrc: "n", gh: "g"}, want: "keyring-token", from: "the OS keyring (api.github.com)"},
+		{name: "login", src: sources{login: "login-token", netrc: "n", gh: "g"}, want: "login-token", from: "--login (github.com)"},
+		{name: "netrc", src: sources{netrc: "netrc-token", gh: "g"}, want: "netrc-token", from: "/home/u/.netrc (machine api.github.com)"},
+		{name: "gh", src: sources{gh: "gh-token"}, want: "gh-token", from: "the gh CLI (github.com)"},
+		{name: "nothing", want: ""},
+
+		// --auth で探す場所を絞る
+		{name: "auth token skips keyring and gh", src: sources{keyring: "k", gh: "g"}, auth: authToken, want: ""},
+		{name: "auth token still reads login and netrc", src: sources{login: "login-token", gh: "g"}, auth: authToken, want: "login-token", from: "--login (github.com)"},
+		{name: "auth keyring overrides flag", src: all, auth: authKeyring, want: "keyring-token", from: "the OS keyring (api.github.com)"},
+		{name: "auth keyring without entry", src: sources{flag: "flag-token", login: "l"}, auth: authKeyring, err: "fetch_pr_comments auth set"},
+		{name: "auth gh overrides flag", src: all, auth: authGH, want: "gh-token", from: "the gh CLI (github.com)"},
+		{name: "auth gh without gh", src: sources{flag: "flag-token"}, auth: authGH, want: ""},
+
+		// 探さない場合
+		{name: "GitHub App", src: sources{keyring: "k", login: "l", netrc: "n", gh: "g"}, app: true, want: ""},
+		{name: "per-repository tokens", src: sources{keyring: "k", netrc: "n", gh: "g", login: "login-token"}, repoToken: true, want: "login-token", from: "--login (github.com)"},
+		{name: "offline", src: sources{keyring: "k", gh: "g"}, offline: true, want: offlineToken},
+		{name: "token file on stdin with --pr-stdin", src: sources{file: "-"}, stdin: true, err: "cannot both read standard input"},
+		{name: "missing token file", src: sources{file: filepath.Join(dir, "missing")}, err: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FETCH_PR_TOKEN", "")
+			t.Setenv("GITHUB_TOKEN_PR", tt.src.envPR)
+			t.Setenv("GITHUB_TOKEN", tt.src.envGH)
+			auth := tt.auth
+			if auth == "" {
+				auth = authAuto
+			}
+			opts := tokenOptions{ConfigPath: "/etc/fpc.yaml", File: tt.src.file, Auth: auth, App: tt.app, Offline: tt.offline, RepoTokens: tt.repoToken, StdinBusy: tt.stdin}
+			// --token・環境変数・設定ファイルの順は、main と同じくフラグと環境変数の反映で決まる
+			if value, env, ok := lookupFlagEnv("token"); tt.src.flag == "" && ok {
+				opts.Token, opts.Env = value, env
+			}
+			switch {
+			case tt.src.flag != "":
+				opts.Token, opts.FromCLI = tt.src.flag, true
+			case opts.Token == "":
+				opts.Token = tt.src.config
+			}
+			lookups := tokenLookups{
+				Keyring: func(string) (string, error) {
+					if tt.src.keyring == "" {
+						return "", errKeyringNotFound
+					}
+					return tt.src.keyring, nil
+				},
+				Stored: func(string) string { return tt.src.login },
+				Netrc:  func(string) (string, string, error) { return tt.src.netrc, "/home/u/.netrc", nil },
+				GH: func(string) (string, error) {
+					if tt.src.gh == "" {
+						return "", errors.New("gh not installed")
+					}
+					return tt.src.gh, nil
+				},
+			}
+
+			token, from, err := resolveToken(opts, lookups)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("resolveToken = %q, %v; want an error containing %q", from, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != tt.want {
+				t.Errorf("resolveToken chose the token from %q, want %q from %q", from, tt.want, tt.from)
+			}
+			if tt.want != "" && tt.want != offlineToken && from != tt.from {
+				t.Errorf("resolveToken reported the source %q, want %q", from, tt.from)
+			}
+			if strings.Contains(from, token) && token != "" {
+				t.Errorf("the source name %q contains the token value", from)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"  // リクエストごとのタイムアウトと実行全体の期限の判定に使用
+	"errors"   // 期限切れのエラーの判定に使用
+	"fmt"      // エラーメッセージの作成に使用
+	"io"       // レスポンスの本文を閉じたときのタイムアウトの解除に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"time"     // タイムアウトの時間に使用
+)
+
+// exitDeadlineReached は --max-duration・--deadline の期限に達して実行を打ち切った場合の終了コードです。
+const exitDeadlineReached = 6
+
+// httpTimeoutError は1つのリクエストが --http-timeout 以内に完了しなかったことを表すエラーです。
+// net.Error を実装するため、一時的な失敗として送り直しの対象になります。
+type httpTimeoutError struct {
+	Limit time.Duration // タイムアウトの時間
+}
+
+// Error は error インタフェースの実装です。
+func (e *httpTimeoutError) Error() string {
+	return fmt.Sprintf("request timed out after %s (--http-timeout)", e.Limit)
+}
+
+// Timeout は net.Error インタフェースの実装です。
+func (e *httpTimeoutError) Timeout() bool { return true }
+
+// Temporary は net.Error インタフェースの実装です。
+func (e *httpTimeoutError) Temporary() bool { return true }
+
+// timeoutTransport は1回のリクエスト（接続・レスポンスの本文の読み込みまで）に --http-timeout の時間制限を設ける http.RoundTripper です。
+// http.Client.Timeout と異なり、レート制限の解除を待つ時間や送り直しの待機時間は含めません。
+type timeoutTransport struct {
+	base    http.RoundTripper // 実際にリクエストを送るトランスポート
+	timeout time.Duration     // 1回のリクエストの時間制限
+}
+
+// cancelOnClose はレスポンスの本文を閉じたときに、リクエストのタイムアウトを解除します。
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close は io.Closer インタフェースの実装です。
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		// 実行全体の期限や中断ではなく、このリクエストの時間制限による場合だけ変換する
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, &httpTimeoutError{Limit: t.timeout}
+		}
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// stopTransport は実行全体のコンテキストが期限（--max-duration・--deadline）や中断で終わった後のリクエストのエラーを、
+// コンテキストに記録した limitError に変換する http.RoundTripper です。
+// --max-api-calls の上限と同じように、それまでに取得した結果を保存してから終了させます。
+type stopTransport struct {
+	base http.RoundTripper // 実際にリクエストを送るトランスポート
+}
+
+// stopCause はコンテキストが終わった理由が limitError の場合にそれを返します。それ以外の場合はnilを返します。
+func stopCause(ctx context.Context) *limitError {
+	var limit *limitError
+	if errors.As(context.Cause(ctx), &limit) {
+		return limit
+	}
+	return nil
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *stopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if limit := stopCause(req.Context()); limit != nil {
+		return nil, limit
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		if limit := stopCause(req.Context()); limit != nil {
+			return nil, limit
+		}
+	}
+	return resp, err
+}
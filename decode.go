@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"         // エラーに含める本文の先頭の保持に使用
+	"encoding/json" // レスポンスの逐次デコードに使用
+	"fmt"           // エラーメッセージの作成に使用
+	"io"            // 本文の読み込みの制限に使用
+	"net/http"      // HTTPレスポンスの型を提供
+	"strings"       // エンドポイントのパスの整形に使用
+)
+
+// maxResponseBytes はAPIのレスポンスの本文として受け付ける最大のバイト数です。
+// 通常のページは数MB程度のため、これを超える本文はプロキシの誤動作などとみなして読み込みを打ち切ります。
+const maxResponseBytes = 64 << 20
+
+// decodeSnippetBytes はデコードのエラーに含める、本文の先頭のバイト数です。
+const decodeSnippetBytes = 200
+
+// decodeError はAPIのレスポンスの本文をJSONとしてデコードできなかったことを表すエラーです。
+// 調査できるよう、エンドポイント・ページ番号・本文の先頭を含めます。
+type decodeError struct {
+	Method   string // リクエストのメソッド
+	Endpoint string // リクエストのパス（クエリを除く）
+	Page     string // リクエストのページ番号（ページ送りしない場合は空文字）
+	Snippet  string // 本文の先頭（最大 decodeSnippetBytes バイト）
+	Err      error  // デコードのエラー
+}
+
+// Error は error インタフェースの実装です。
+func (e *decodeError) Error() string {
+	page := ""
+	if e.Page != "" {
+		page = ", page " + e.Page
+	}
+	return fmt.Sprintf("cannot decode response of %s %s%s: %v (body starts with %q)", e.Method, e.Endpoint, page, e.Err, e.Snippet)
+}
+
+// Unwrap は errors.Is・errors.As のためにデコードのエラーを返します。
+func (e *decodeError) Unwrap() error {
+	return e.Err
+}
+
+// headBuffer は書き込まれたデータのうち、先頭の limit バイトだけを保持する io.Writer です。
+type headBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// Write は io.Writer インタフェースの実装です。保持しきれない分は捨てます。
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if rest := h.limit - h.Len(); rest > 0 {
+		if len(p) > rest {
+			h.Buffer.Write(p[:rest])
+		} else {
+			h.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// decodeJSON はレスポンスの本文を、全体をメモリに読み込まずに json.Decoder で逐次デコードします。
+// 本文が maxResponseBytes を超える場合や、JSONとして不正な場合は、エンドポイントと本文の先頭を含むエラーを返します（本文を閉じるのは呼び出し元）。
+//
+// パラメータ:
+//   - resp: ステータスが200のレスポンス
+//   - v: デコード先
+//
+// 戻り値:
+//   - error: デコードに失敗した場合はエラー情報、成功時はnil
+func decodeJSON(resp *http.Response, v interface{}) error {
+	head := &headBuffer{limit: decodeSnippetBytes}
+	limited := &io.LimitedReader{R: resp.Body, N: maxResponseBytes + 1}
+	err := json.NewDecoder(io.TeeReader(limited, head)).Decode(v)
+	if err == nil {
+		return nil
+	}
+	e := &decodeError{Snippet: head.String(), Err: err}
+	if limited.N <= 0 {
+		e.Err = fmt.Errorf("response body exceeds %d MB", maxResponseBytes>>20)
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Endpoint = strings.TrimPrefix(resp.Request.URL.Path, apiPath())
+		e.Page = resp.Request.URL.Query().Get("page")
+	}
+	return e
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"     // リクエストのコンテキストに使用
+	"errors"      // エラーの種類の判定に使用
+	"io"          // 大きな本文の生成に使用
+	"net/http"    // テスト用のサーバーの実装に使用
+	"strings"     // 大きな本文の作成とエラーメッセージの確認に使用
+	"sync/atomic" // 本文の大きさの記録に使用
+	"testing"     // テストの実行に使用
+)
+
+// TestDecodeLargePage は数MBのページ（長い本文のコメント100件）を逐次デコードで正しく読み込めることを確認します。
+func TestDecodeLargePage(t *testing.T) {
+	long := strings.Repeat("長い本文 with \"quotes\" and \\ backslashes. ", 1500)
+	comments := make([]map[string]interface{}, 0, commentsPerPage)
+	for i := 1; i <= commentsPerPage; i++ {
+		comments = append(comments, fakeComment(int64(i), 1, "bob", long, "2024-05-01T10:00:00Z"))
+	}
+	var size atomic.Int64
+	server := newFakeGitHub(t, nil, map[int][]map[string]interface{}{1: comments}, func(f *fakeGitHub) {
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			// 本文の大きさを記録するため、応答の書き込みを数える
+			counter := &countingWriter{ResponseWriter: w}
+			f.paged(counter, r, f.comments[1])
+			size.Store(counter.n)
+			return true
+		}
+	})
+	withAPIBaseURL(t, server.apiURL())
+	withTransport(t, http.DefaultTransport)
+
+	got, _, err := fetchCommentPage(context.Background(), "o", "r", 1, "t", mediaTypeJSON, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := size.Load(); n < 4<<20 {
+		t.Fatalf("test page is only %d bytes", n)
+	}
+	if len(got) != commentsPerPage {
+		t.Fatalf("decoded %d comments, want %d", len(got), commentsPerPage)
+	}
+	for i, c := range got {
+		if c.ID != int64(i+1) || c.Body != long {
+			t.Fatalf("comment %d decoded as ID %d with a %d-byte body", i, c.ID, len(c.Body))
+		}
+	}
+}
+
+// countingWriter は書き込んだバイト数を数える http.ResponseWriter です。
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+// Write は io.Writer インタフェースの実装です。
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// TestDecodeErrorContext はJSONとして不正な本文のエラーに、エンドポイント・ページ番号・本文の先頭が含まれることを確認します。
+func TestDecodeErrorContext(t *testing.T) {
+	server := newFakeGitHub(t, nil, nil, func(f *fakeGitHub) {
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html><body>Bad gateway from a proxy"+strings.Repeat(".", 1000)+"</body></html>")
+			return true
+		}
+	})
+	withAPIBaseURL(t, server.apiURL())
+	withTransport(t, http.DefaultTransport)
+
+	_, _, err := fetchCommentPage(context.Background(), "o", "r", 7, "t", mediaTypeJSON, 3)
+	var decodeErr *decodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("error is %T (%v), want *decodeError", err, err)
+	}
+	if decodeErr.Endpoint != "/repos/o/r/pulls/7/comments" || decodeErr.Page != "3" || decodeErr.Method != http.MethodGet {
+		t.Errorf("decode error context = %s %s page %s", decodeErr.Method, decodeErr.Endpoint, decodeErr.Page)
+	}
+	if !strings.HasPrefix(decodeErr.Snippet, "<html><body>Bad gateway") || len(decodeErr.Snippet) > decodeSnippetBytes {
+		t.Errorf("snippet = %q (%d bytes)", decodeErr.Snippet, len(decodeErr.Snippet))
+	}
+}
+
+// endlessArray は "[" の後に空白を size バイト続ける（閉じない）本文です。
+type endlessArray struct {
+	size int64
+	sent bool
+}
+
+// Read は io.Reader インタフェースの実装です。
+func (r *endlessArray) Read(p []byte) (int, error) {
+	if !r.sent {
+		r.sent = true
+		p[0] = '['
+		return 1, nil
+	}
+	if r.size <= 0 {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if int64(n) > r.size {
+		n = int(r.size)
+	}
+	for i := range p[:n] {
+		p[i] = ' '
+	}
+	r.size -= int64(n)
+	return n, nil
+}
+
+// TestDecodeTooLarge は maxResponseBytes を超える本文を最後まで読まずにエラーにすることを確認します。
+func TestDecodeTooLarge(t *testing.T) {
+	body := &endlessArray{size: maxResponseBytes + 1<<20}
+	resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(body)}
+	var v []Comment
+	err := decodeJSON(resp, &v)
+	if err == nil || !strings.Contains(err.Error(), "exceeds 64 MB") {
+		t.Fatalf("decode of an oversized body: %v", err)
+	}
+	if body.size <= 0 {
+		t.Error("the whole oversized body was read")
+	}
+}
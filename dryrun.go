@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"       // 実行全体の期限の伝達に使用
+	"encoding/json" // 前回の実行のPR一覧の読み込みに使用
+	"fmt"           // フォーマット済み入出力に使用
+	"io"            // 出力先のインタフェースに使用
+	"os"            // ファイルの読み込みに使用
+	"path/filepath" // ファイルパス操作に使用
+	"time"          // レート制限の解除時刻の表示に使用
+)
+
+// commentsPerPage はコメントの一覧を取得する際の1ページあたりの件数です。
+const commentsPerPage = 100
+
+// dryRunPlan は --dry-run で表示する、実行した場合の作業の見積もりです。
+type dryRunPlan struct {
+	Requests  map[int]int  // PR番号 → コメントの取得に必要なリクエスト数の見積もり
+	Details   map[int]bool // PR番号 → PRの詳細（/pulls/N）の取得が必要か（選択のために取得済みの場合はfalse）
+	Known     map[int]int  // PR番号 → 前回の実行で取得したコメント数（前回の記録がある場合のみ）
+	Selection int          // PRの選択（と事前の確認）のために既に行ったAPI呼び出しの回数
+	Comments  int          // コメントの取得に必要なリクエスト数の合計
+	Detail    int          // PRの詳細の取得に必要なリクエスト数の合計
+	Total     int          // 選択・コメント・PRの詳細を合わせたAPI呼び出しの回数
+	Files     []string     // 作成されるファイル
+}
+
+// loadPreviousCounts は前回の実行で保存したPR一覧（prs.json）から、PRごとのコメント数を読み込みます。
+// ファイルがない場合や読み込めない場合は空のマップを返します。
+func loadPreviousCounts(owner, repo string) map[int]int {
+	counts := make(map[int]int)
+	data, err := os.ReadFile(filepath.Join(commentsDir(owner, repo), "prs.json"))
+	if err != nil {
+		return counts
+	}
+	var entries []prIndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return counts
+	}
+	for _, e := range entries {
+		// 打ち切った場合は、わかっている総数の方を使う
+		if e.Truncated && e.AvailableComments > 0 {
+			counts[e.Number] = e.AvailableComments
+			continue
+		}
+		counts[e.Number] = e.CommentCount
+	}
+	return counts
+}
+
+// estimateCommentRequests は1つのPRのコメントを取得するのに必要なリクエスト数を見積もります。
+// コメントの一覧はLinkヘッダーに rel="next" がなくなるまでたどるため、コメントのあるページの数だけリクエストします。
+// コメントがない場合やコメント数がわからない場合でも、最初のページの1回は必要です。
+//
+// パラメータ:
+//   - count: 前回の実行で取得したコメント数
+//   - known: 前回の記録があるかどうか（ない場合は最低の1回とする）
+//   - maxComments: --max-comments-per-pr の上限（0の場合は上限なし）
+//
+// 戻り値:
+//   - int: リクエスト数の見積もり
+func estimateCommentRequests(count int, known bool, maxComments int) int {
+	if !known {
+		return 1
+	}
+	pages := (count + commentsPerPage - 1) / commentsPerPage
+	if pages < 1 {
+		pages = 1
+	}
+	// 上限で打ち切る場合は、上限までのページと、それより後にある場合は総数を求めるための最後のページを取得する
+	if maxComments > 0 && count > maxComments {
+		if need := (maxComments + commentsPerPage - 1) / commentsPerPage; need < pages {
+			return need + 1
+		}
+	}
+	return pages
+}
+
+// planDryRun は選択されたPRについて、実行した場合のリクエスト数と作成されるファイルを見積もります。
+// リクエスト数には、PRの選択で既に行った呼び出しと、PRの規模を求めるためのPRごとの詳細の取得（取得済みのPRを除く）を含めます。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - prs: 選択されたプルリクエスト
+//   - selection: PRの選択のために既に行ったAPI呼び出しの回数
+//   - haveDetails: PRの詳細を取得済みかどうかを返す関数（-merged-by などで取得済みのPRは再取得しない）
+//   - previous: 前回の実行で取得したPRごとのコメント数
+//   - maxComments: --max-comments-per-pr の上限（0の場合は上限なし）
+//   - format: 出力形式
+//   - merge: すべてのコメントを1つのファイルにまとめるかどうか
+//   - stats: 統計レポートを作成するかどうか
+//
+// 戻り値:
+//   - dryRunPlan: 作業の見積もり
+func planDryRun(owner, repo string, prs []PullRequest, selection int, haveDetails func(number int) bool, previous map[int]int, maxComments int, format string, merge, stats bool) dryRunPlan {
+	plan := dryRunPlan{Requests: make(map[int]int), Details: make(map[int]bool), Known: make(map[int]int), Selection: selection}
+	for _, pr := range prs {
+		count, known := previous[pr.Number]
+		if known {
+			plan.Known[pr.Number] = count
+		}
+		n := estimateCommentRequests(count, known, maxComments)
+		plan.Requests[pr.Number] = n
+		plan.Comments += n
+		if !haveDetails(pr.Number) {
+			plan.Details[pr.Number] = true
+			plan.Detail++
+		}
+		// コメントのないPRのファイルは作成しないため、前回コメントがなかったPRは除く
+		if !merge && (!known || count > 0) {
+			plan.Files = append(plan.Files, prCommentsFile(owner, repo, pr.Number, format))
+		}
+	}
+	if merge {
+		plan.Files = append(plan.Files, mergedCommentsFile(owner, repo, format))
+	}
+	plan.Files = append(plan.Files, filepath.Join(commentsDir(owner, repo), "prs.json"))
+	if stats {
+		plan.Files = append(plan.Files, filepath.Join(commentsDir(owner, repo), "stats.txt"))
+	}
+	plan.Total = plan.Selection + plan.Comments + plan.Detail
+	return plan
+}
+
+// rateLimit はレート制限APIのレスポンスのうち、通常のAPIの制限を格納する構造体です。
+type rateLimit struct {
+	Resources struct {
+		Core struct {
+			Limit     int   `json:"limit"`     // 1時間あたりのリクエスト数の上限
+			Remaining int   `json:"remaining"` // 残りのリクエスト数
+			Reset     int64 `json:"reset"`     // 制限が解除される時刻（UNIX時間）
+		} `json:"core"`
+	} `json:"resources"`
+}
+
+// fetchRateLimit は現在のレート制限の状況を取得します（この呼び出し自体は制限に数えられません）。
+func fetchRateLimit(ctx context.Context, token string) (rateLimit, error) {
+	var limit rateLimit
+	_, err := getJSON(ctx, apiClient, apiURL("/rate_limit"), token, &limit)
+	return limit, err
+}
+
+// printDryRun は作業の見積もりとレート制限の状況を表示します。
+// レート制限の残りは、PRの選択で既に使った分を除いたこれからの呼び出し回数と比べます。
+//
+// パラメータ:
+//   - w: 書き込み先（通常は標準出力）
+//   - prs: 選択されたプルリクエスト
+//   - plan: 作業の見積もり
+//   - limit: レート制限の状況（取得できなかった場合はnil）
+func printDryRun(w io.Writer, prs []PullRequest, plan dryRunPlan, limit *rateLimit) {
+	fmt.Fprintf(w, "Dry run: %d PRs selected\n", len(prs))
+	for _, pr := range prs {
+		known := "comment count unknown"
+		if count, ok := plan.Known[pr.Number]; ok {
+			known = fmt.Sprintf("%d comments last run", count)
+		}
+		detail := ""
+		if plan.Details[pr.Number] {
+			detail = " + 1 detail request"
+		}
+		fmt.Fprintf(w, "  PR #%d: ~%d comment requests%s (%s)\n", pr.Number, plan.Requests[pr.Number], detail, known)
+	}
+	fmt.Fprintf(w, "Estimated API calls: %d (%d already made to select the PRs, %d for comments, %d for PR details)\n", plan.Total, plan.Selection, plan.Comments, plan.Detail)
+	fmt.Fprintln(w, "Files that would be written:")
+	for _, f := range plan.Files {
+		fmt.Fprintf(w, "  %s\n", f)
+	}
+	if limit != nil {
+		core := limit.Resources.Core
+		fmt.Fprintf(w, "Rate limit: %d of %d requests remaining (resets at %s)\n", core.Remaining, core.Limit, time.Unix(core.Reset, 0).Format(time.RFC3339))
+		if core.Remaining < plan.Total-plan.Selection {
+			fmt.Fprintln(w, "Warning: the estimated API calls exceed the remaining rate limit")
+		}
+	}
+}
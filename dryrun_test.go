@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"     // 見積もりの読み取りに使用
+	"strings" // 出力の行の確認に使用
+	"testing" // テストの実行に使用
+)
+
+// TestEstimateCommentRequests は rel="next" がなくなるまでたどる場合の、PRごとのコメントの取得に必要なリクエスト数の見積もりを確認します。
+func TestEstimateCommentRequests(t *testing.T) {
+	tests := []struct {
+		count       int
+		known       bool
+		maxComments int
+		want        int
+	}{
+		{0, false, 0, 1}, // 前回の記録がない
+		{0, true, 0, 1},  // コメントがなくても最初のページは取得する
+		{1, true, 0, 1},
+		{100, true, 0, 1}, // ちょうど1ページ（空のページは取得しない）
+		{101, true, 0, 2},
+		{250, true, 0, 3},
+		{250, true, 100, 2}, // 上限までの1ページと、総数を求めるための最後のページ
+		{250, true, 300, 3}, // 上限に届かない
+		{150, true, 120, 2}, // 上限までのページが最後のページ
+		{150, true, 150, 2}, // 上限とコメント数が同じ
+	}
+	for _, tt := range tests {
+		if got := estimateCommentRequests(tt.count, tt.known, tt.maxComments); got != tt.want {
+			t.Errorf("estimateCommentRequests(%d, %v, %d) = %d, want %d", tt.count, tt.known, tt.maxComments, got, tt.want)
+		}
+	}
+}
+
+// TestPlanDryRun は見積もりの合計に、PRの選択で既に行った呼び出しと、取得済みでないPRの詳細の取得が含まれることを確認します。
+func TestPlanDryRun(t *testing.T) {
+	prs := []PullRequest{{Number: 3}, {Number: 2}, {Number: 1}}
+	tests := []struct {
+		name                    string
+		selection               int
+		fetched                 map[int]bool // 詳細を取得済みのPR
+		previous                map[int]int
+		maxComments             int
+		comments, detail, total int
+	}{
+		{"nothing known", 1, nil, nil, 0, 3, 3, 7},
+		{"previous counts", 2, nil, map[int]int{3: 250, 2: 0, 1: 100}, 0, 5, 3, 10},
+		{"capped", 2, nil, map[int]int{3: 250}, 100, 4, 3, 9},
+		{"details fetched for selection", 4, map[int]bool{3: true, 1: true}, nil, 0, 3, 1, 8},
+		{"all details fetched", 0, map[int]bool{3: true, 2: true, 1: true}, map[int]int{3: 1}, 0, 3, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have := func(n int) bool { return tt.fetched[n] }
+			plan := planDryRun("o", "r", prs, tt.selection, have, tt.previous, tt.maxComments, formatText, false, false)
+			if plan.Selection != tt.selection || plan.Comments != tt.comments || plan.Detail != tt.detail || plan.Total != tt.total {
+				t.Errorf("selection=%d comments=%d detail=%d total=%d; want %d, %d, %d, %d",
+					plan.Selection, plan.Comments, plan.Detail, plan.Total, tt.selection, tt.comments, tt.detail, tt.total)
+			}
+			for _, pr := range prs {
+				if plan.Details[pr.Number] == tt.fetched[pr.Number] {
+					t.Errorf("PR #%d: detail request planned = %v, want %v", pr.Number, plan.Details[pr.Number], !tt.fetched[pr.Number])
+				}
+			}
+		})
+	}
+}
+
+// TestDryRunMatchesRun は --dry-run の見積もりの合計が、実際に実行した場合のAPI呼び出しの回数と一致することを確認します。
+func TestDryRunMatchesRun(t *testing.T) {
+	prs := []map[string]interface{}{fakePR(3, "2024-05-03T00:00:00Z"), fakePR(2, "2024-05-02T00:00:00Z"), fakePR(1, "2024-05-01T00:00:00Z")}
+	server := newFakeGitHub(t, prs, map[int][]map[string]interface{}{1: pagedComments(1, 2), 2: pagedComments(2, 1)}, func(f *fakeGitHub) {
+		f.maxPer = 2 // PRの一覧を2ページに分ける（コメントはどのPRも1ページ）
+	})
+	dir := t.TempDir()
+	args := []string{"--owner", "o", "--repo", "r", "--count", "3", "--no-cache", "--token", "t", "--api-url", server.apiURL(), "--skip-preflight"}
+
+	code, out := runTool(t, dir, append(args, "--dry-run")...)
+	if code != 0 {
+		t.Fatalf("--dry-run exited with %d:\n%s", code, out)
+	}
+	var estimate, selection int
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Estimated API calls:") {
+			if _, err := fmt.Sscanf(line, "Estimated API calls: %d (%d already made", &estimate, &selection); err != nil {
+				t.Fatalf("cannot parse %q: %v", line, err)
+			}
+		}
+	}
+	if estimate == 0 || selection == 0 {
+		t.Fatalf("no estimate in the output:\n%s", out)
+	}
+
+	before := server.requestCount()
+	if code, out := runTool(t, dir, args...); code != 0 {
+		t.Fatalf("exited with %d:\n%s", code, out)
+	}
+	if made := server.requestCount() - before; made != estimate {
+		t.Errorf("the run made %d API calls, --dry-run estimated %d", made, estimate)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"    // 環境変数の値のフラグへの反映に使用
+	"fmt"     // エラーメッセージの作成に使用
+	"os"      // 環境変数の読み込みに使用
+	"strconv" // 真偽値の変換に使用
+	"strings" // 環境変数名の組み立てに使用
+)
+
+// envPrefix はフラグに対応する環境変数名の接頭辞です。
+const envPrefix = "FETCH_PR_"
+
+// flagEnvAliases は FETCH_PR_* 以外の、フラグに対応する環境変数です（他のGitHubのツールと共通のものを含む）。
+// FETCH_PR_* の環境変数が設定されていない場合に、記述順に使用します。
+var flagEnvAliases = map[string][]string{
+	"token":   {"GITHUB_TOKEN_PR", "GITHUB_TOKEN"},
+	"api-url": {"GITHUB_API_URL"},
+}
+
+// flagEnvVar はフラグに対応する環境変数名を返します（例: count → FETCH_PR_COUNT、skip-bots → FETCH_PR_SKIP_BOTS）。
+// すべてのフラグに自動的に対応するため、フラグを追加するときに環境変数を個別に用意する必要はありません。
+func flagEnvVar(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// lookupFlagEnv はフラグに対応する環境変数の値と、値を読み込んだ環境変数名を返します。
+// 未設定または空の場合は ok に false を返します。
+func lookupFlagEnv(name string) (value, env string, ok bool) {
+	for _, env := range append([]string{flagEnvVar(name)}, flagEnvAliases[name]...) {
+		if v := os.Getenv(env); v != "" {
+			return v, env, true
+		}
+	}
+	return "", "", false
+}
+
+// parseEnvBool は環境変数の真偽値を解釈します。true/false・1/0・yes/no・on/off を大文字小文字を区別せずに受け付けます。
+func parseEnvBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes", "on":
+		return true, nil
+	case "false", "0", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q (expected true/false, 1/0, yes/no or on/off)", value)
+}
+
+// isBoolFlag は値を省略できる真偽値のフラグかどうかを返します。
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// applyEnv は、コマンドラインで指定されていないフラグに、対応する FETCH_PR_* 環境変数の値を反映します。
+// 真偽値のフラグは yes/no なども受け付け、繰り返し指定できるフラグはフラグと同じくカンマ区切りの値を受け付けます
+// （正規表現のフラグは、フラグと同じく値全体を1つの指定として扱います）。
+//
+// パラメータ:
+//   - cli: コマンドラインで指定されたフラグ名の集合
+//
+// 戻り値:
+//   - map[string]string: 環境変数から値を反映したフラグ名 → 環境変数名
+//   - error: 値が不正な場合は、環境変数名を含むエラー情報
+func applyEnv(cli map[string]bool) (map[string]string, error) {
+	applied := make(map[string]string)
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if err != nil || cli[f.Name] {
+			return
+		}
+		value, env, ok := lookupFlagEnv(f.Name)
+		if !ok {
+			return
+		}
+		if isBoolFlag(f) {
+			b, perr := parseEnvBool(value)
+			if perr != nil {
+				err = fmt.Errorf("$%s: %v", env, perr)
+				return
+			}
+			value = strconv.FormatBool(b)
+		}
+		if serr := flag.Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("$%s: invalid value %q for --%s: %v", env, value, f.Name, serr)
+			return
+		}
+		applied[f.Name] = env
+	})
+	return applied, err
+}
+
+// documentEnvVars は各フラグのヘルプの末尾に、対応する環境変数名を追記します（flag.Parse の前に呼び出します）。
+func documentEnvVars() {
+	flag.VisitAll(func(f *flag.Flag) {
+		f.Usage += " [$" + flagEnvVar(f.Name) + "]"
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"    // テスト用のフラグの定義に使用
+	"reflect" // スライスの比較に使用
+	"strings" // エラーメッセージの確認に使用
+	"testing" // テストの実行に使用
+)
+
+// TestParseEnvBool は true/1/yes/on と false/0/no/off を大文字小文字や前後の空白を問わず受け付け、それ以外をエラーにすることを確認します。
+func TestParseEnvBool(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"true", true}, {"TRUE", true}, {"True", true}, {"1", true}, {"yes", true}, {"Yes", true}, {"on", true}, {"ON", true}, {" true ", true},
+		{"false", false}, {"FALSE", false}, {"0", false}, {"no", false}, {"NO", false}, {"off", false}, {"Off", false}, {"\tfalse\n", false},
+	}
+	for _, tt := range tests {
+		got, err := parseEnvBool(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseEnvBool(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"", "2", "-1", "y", "n", "t", "f", "enabled", "truee", "yes please"} {
+		if _, err := parseEnvBool(value); err == nil {
+			t.Errorf("parseEnvBool(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+// withFlagSet は flag.CommandLine をテスト用のフラグセットに差し替え、テストの終了時に元に戻します。
+func withFlagSet(t *testing.T) *flag.FlagSet {
+	t.Helper()
+	saved := flag.CommandLine
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flag.CommandLine = fs
+	t.Cleanup(func() { flag.CommandLine = saved })
+	return fs
+}
+
+// TestApplyEnv は FETCH_PR_* の値が真偽値・数値・リストのフラグに反映され、
+// リストはカンマで分割し、正規表現のフラグは分割せず、コマンドラインの指定が優先されることを確認します。
+func TestApplyEnv(t *testing.T) {
+	fs := withFlagSet(t)
+	merge := fs.Bool("merge", false, "")
+	skipBots := fs.Bool("skip-bots", true, "")
+	count := fs.Int("count", 10, "")
+	owner := fs.String("owner", "", "")
+	var authors stringList
+	fs.Var(&authors, "author", "")
+	var match rawList
+	fs.Var(&match, "match", "")
+	unset := fs.String("output-dir", "out", "")
+
+	t.Setenv("FETCH_PR_MERGE", "yes")
+	t.Setenv("FETCH_PR_SKIP_BOTS", "off")
+	t.Setenv("FETCH_PR_COUNT", "25")
+	t.Setenv("FETCH_PR_OWNER", "from-env")
+	t.Setenv("FETCH_PR_AUTHOR", "alice, bob,,carol")
+	t.Setenv("FETCH_PR_MATCH", "nit(pick)?,typo")
+	t.Setenv("FETCH_PR_OUTPUT_DIR", "")
+	if err := fs.Parse([]string{"--owner", "from-cli"}); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := applyEnv(map[string]bool{"owner": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*merge || *skipBots || *count != 25 {
+		t.Errorf("merge=%v skip-bots=%v count=%d; want true, false, 25", *merge, *skipBots, *count)
+	}
+	if *owner != "from-cli" {
+		t.Errorf("owner = %q, want the command-line value", *owner)
+	}
+	if want := (stringList{"alice", "bob", "carol"}); !reflect.DeepEqual(authors, want) {
+		t.Errorf("author = %q, want %q", authors, want)
+	}
+	if want := (rawList{"nit(pick)?,typo"}); !reflect.DeepEqual(match, want) {
+		t.Errorf("match = %q, want the whole value as one pattern %q", match, want)
+	}
+	if *unset != "out" {
+		t.Errorf("output-dir = %q, want the default for an empty variable", *unset)
+	}
+	want := map[string]string{
+		"merge": "FETCH_PR_MERGE", "skip-bots": "FETCH_PR_SKIP_BOTS", "count": "FETCH_PR_COUNT",
+		"author": "FETCH_PR_AUTHOR", "match": "FETCH_PR_MATCH",
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+}
+
+// TestApplyEnvInvalid は不正な値がエラーになり、エラーメッセージに環境変数名が含まれることを確認します。
+func TestApplyEnvInvalid(t *testing.T) {
+	tests := []struct {
+		env, value string
+		want       string
+	}{
+		{"FETCH_PR_MERGE", "maybe", "$FETCH_PR_MERGE: invalid boolean"},
+		{"FETCH_PR_COUNT", "ten", "$FETCH_PR_COUNT: invalid value \"ten\" for --count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			fs := withFlagSet(t)
+			fs.Bool("merge", false, "")
+			fs.Int("count", 10, "")
+			t.Setenv("FETCH_PR_MERGE", "")
+			t.Setenv("FETCH_PR_COUNT", "")
+			t.Setenv(tt.env, tt.value)
+			_, err := applyEnv(map[string]bool{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyEnv with %s=%q: %v; want an error containing %q", tt.env, tt.value, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestFlagEnvVar はフラグ名から環境変数名への変換と、GITHUB_TOKEN などの別名の優先順位を確認します。
+func TestFlagEnvVar(t *testing.T) {
+	for name, want := range map[string]string{"count": "FETCH_PR_COUNT", "skip-bots": "FETCH_PR_SKIP_BOTS", "api-url": "FETCH_PR_API_URL"} {
+		if got := flagEnvVar(name); got != want {
+			t.Errorf("flagEnvVar(%q) = %q, want %q", name, got, want)
+		}
+	}
+	t.Setenv("FETCH_PR_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN_PR", "")
+	t.Setenv("GITHUB_TOKEN", "gh")
+	if _, env, _ := lookupFlagEnv("token"); env != "GITHUB_TOKEN" {
+		t.Errorf("lookupFlagEnv(token) read %s, want GITHUB_TOKEN", env)
+	}
+	t.Setenv("GITHUB_TOKEN_PR", "pr")
+	if _, env, _ := lookupFlagEnv("token"); env != "GITHUB_TOKEN_PR" {
+		t.Errorf("lookupFlagEnv(token) read %s, want GITHUB_TOKEN_PR", env)
+	}
+	t.Setenv("FETCH_PR_TOKEN", "fp")
+	if _, env, _ := lookupFlagEnv("token"); env != "FETCH_PR_TOKEN" {
+		t.Errorf("lookupFlagEnv(token) read %s, want FETCH_PR_TOKEN", env)
+	}
+}
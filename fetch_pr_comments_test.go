@@ -0,0 +1,696 @@
+package main
+
+import (
+	"context"           // リクエストのコンテキストに使用
+	"crypto/sha1"       // テスト用のサーバーの ETag の作成に使用
+	"encoding/hex"      // ETag の文字列化に使用
+	"encoding/json"     // テスト用のサーバーのレスポンスの作成に使用
+	"fmt"               // URLとデータの作成に使用
+	"net/http"          // テスト用のサーバーの実装に使用
+	"net/http/httptest" // テスト用のサーバーの起動に使用
+	"os"                // 実行ファイルの起動に使用
+	"os/exec"           // ツールを別のプロセスとして実行するために使用
+	"path/filepath"     // 出力先のパスの組み立てに使用
+	"reflect"           // prs.json の重複の対応の比較に使用
+	"strconv"           // ページ番号の変換に使用
+	"strings"           // パスの分割に使用
+	"sync"              // リクエストの記録の排他制御に使用
+	"testing"           // テストの実行に使用
+	"time"              // 応答の遅延と送り直しの待機時間に使用
+)
+
+// runMainEnv はテストの実行ファイルを、テストではなくツールとして起動するための環境変数です（runTool で使用）。
+const runMainEnv = "FPC_TEST_RUN_MAIN"
+
+// TestMain は runMainEnv が設定されている場合に、テストの代わりにツールの main を実行します。
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTool はツールを dir を作業ディレクトリにした別のプロセスとして実行し、終了コードと出力（標準出力と標準エラー出力）を返します。
+// 設定ファイルやトークンの保存先には dir の下を使うため、実行する環境の設定には影響されません。
+func runTool(t *testing.T, dir string, args ...string) (int, string) {
+	t.Helper()
+	return runToolEnv(t, dir, nil, args...)
+}
+
+// runToolEnv は runTool と同じですが、env の環境変数（"NAME=value" の形）も設定して実行します。
+func runToolEnv(t *testing.T, dir string, env []string, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		runMainEnv + "=1",
+		"HOME=" + dir,
+		"XDG_CONFIG_HOME=" + filepath.Join(dir, "config"),
+		"XDG_CACHE_HOME=" + filepath.Join(dir, "cache"),
+		"APPDATA=" + filepath.Join(dir, "config"),
+		"LOCALAPPDATA=" + filepath.Join(dir, "cache"),
+		"PATH=" + os.Getenv("PATH"),
+	}
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	if exit, ok := err.(*exec.ExitError); ok {
+		return exit.ExitCode(), string(out)
+	}
+	if err != nil {
+		t.Fatalf("failed to run the tool: %v", err)
+	}
+	return 0, string(out)
+}
+
+// withAPIBaseURL はテストの間だけREST APIのベースURL（apiBaseURL）を url に変更します。
+func withAPIBaseURL(t testing.TB, url string) {
+	t.Helper()
+	saved := apiBaseURL
+	if err := setAPIBaseURL(url); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { apiBaseURL = saved })
+}
+
+// chdirTemp はテストの間だけ作業ディレクトリを一時ディレクトリに変更し、そのパスを返します（出力先の comments/ を一時ディレクトリに作るため）。
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// withTransport はテストの間だけ、apiClient が使うトランスポート（sharedTransport）を rt に変更します。
+func withTransport(t testing.TB, rt http.RoundTripper) {
+	saved := sharedTransport
+	sharedTransport = rt
+	t.Cleanup(func() { sharedTransport = saved })
+}
+
+// fakePR はテスト用のサーバーが返すマージ済みのPRを作成します。
+func fakePR(number int, merged string) map[string]interface{} {
+	return map[string]interface{}{
+		"number":              number,
+		"title":               fmt.Sprintf("PR %d", number),
+		"user":                map[string]interface{}{"login": "alice", "type": "User"},
+		"state":               "closed",
+		"created_at":          merged,
+		"updated_at":          merged,
+		"closed_at":           merged,
+		"merged_at":           merged,
+		"html_url":            fmt.Sprintf("https://github.com/o/r/pull/%d", number),
+		"body":                "",
+		"labels":              []interface{}{},
+		"assignees":           []interface{}{},
+		"requested_reviewers": []interface{}{},
+	}
+}
+
+// fakeComment はテスト用のサーバーが返すレビューコメントを作成します。user が空の場合は削除されたユーザーのコメントにします。
+func fakeComment(id int64, prNumber int, user, body, created string) map[string]interface{} {
+	c := map[string]interface{}{
+		"id":             id,
+		"in_reply_to_id": nil,
+		"user":           nil,
+		"body":           body,
+		"created_at":     created,
+		"updated_at":     created,
+		"path":           "src/a.go",
+		"line":           10,
+		"diff_hunk":      "@@ -1 +1 @@\n-a\n+b",
+		"html_url":       fmt.Sprintf("https://github.com/o/r/pull/%d#discussion_r%d", prNumber, id),
+		"reactions":      map[string]interface{}{"total_count": 0},
+	}
+	if user != "" {
+		c["user"] = map[string]interface{}{"login": user, "type": "User"}
+	}
+	return c
+}
+
+// fakeGitHub はツールが使うGitHub APIの一部（リポジトリ o/r のPRとレビューコメント）を真似るテスト用のサーバーです。
+// 一覧は per_page と page に従って分割し、Link ヘッダーと ETag（If-None-Match が一致すれば 304）を返します。
+type fakeGitHub struct {
+	*httptest.Server
+	prefix   string                           // APIのパスの接頭辞（GitHub Enterprise Server の場合は "/api/v3"）
+	maxPer   int                              // 1ページの件数の上限（0なら per_page のとおり、複数ページのテストに使用）
+	prs      []map[string]interface{}         // PRの一覧（新しい順）
+	comments map[int][]map[string]interface{} // PR番号 → レビューコメント
+
+	// intercept が設定されている場合は、通常の処理の前に呼び、true を返したリクエストにはそれ以上応答しません。
+	intercept func(w http.ResponseWriter, r *http.Request) bool
+
+	mu       sync.Mutex
+	requests []string // 受け取ったリクエストのパスとクエリ
+	notMod   int      // 304 を返した回数
+}
+
+// newFakeGitHub はテスト用のサーバーを起動します。テストの終了時に停止します。
+// configure は起動する前に呼ぶため、接頭辞などの設定を排他制御なしで変更できます。
+func newFakeGitHub(t testing.TB, prs []map[string]interface{}, comments map[int][]map[string]interface{}, configure ...func(*fakeGitHub)) *fakeGitHub {
+	f := &fakeGitHub{prs: prs, comments: comments}
+	for _, c := range configure {
+		c(f)
+	}
+	f.Server = httptest.NewServer(f)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// apiURL は --api-url に指定するURLを返します。
+func (f *fakeGitHub) apiURL() string {
+	return f.URL + f.prefix
+}
+
+// notModified は 304 を返した回数を返します。
+func (f *fakeGitHub) notModified() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.notMod
+}
+
+// requestCount は受け取ったリクエストの数を返します。
+func (f *fakeGitHub) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// ServeHTTP は http.Handler インタフェースの実装です。
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.mu.Unlock()
+	if f.intercept != nil && f.intercept(w, r) {
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, f.prefix+"/") {
+		f.send(w, r, http.StatusNotFound, map[string]string{"message": "Not Found"}, "")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, f.prefix), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "rate_limit":
+		core := map[string]int64{"limit": 5000, "remaining": 4999, "reset": 9999999999}
+		f.send(w, r, http.StatusOK, map[string]interface{}{"resources": map[string]interface{}{"core": core, "search": core}}, "")
+	case len(parts) == 3 && parts[0] == "repos":
+		f.send(w, r, http.StatusOK, map[string]interface{}{"full_name": parts[1] + "/" + parts[2], "private": false}, "")
+	case len(parts) == 4 && parts[3] == "pulls":
+		f.paged(w, r, f.prs)
+	case len(parts) == 5 && parts[3] == "pulls":
+		n, _ := strconv.Atoi(parts[4])
+		for _, pr := range f.prs {
+			if pr["number"] == n {
+				detail := make(map[string]interface{})
+				for k, v := range pr {
+					detail[k] = v
+				}
+				detail["additions"], detail["deletions"], detail["changed_files"] = 10, 2, 3
+				f.send(w, r, http.StatusOK, detail, "")
+				return
+			}
+		}
+		f.send(w, r, http.StatusNotFound, map[string]string{"message": "Not Found"}, "")
+	case len(parts) == 6 && parts[3] == "pulls" && parts[5] == "comments":
+		n, _ := strconv.Atoi(parts[4])
+		f.paged(w, r, f.comments[n])
+	default:
+		f.send(w, r, http.StatusNotFound, map[string]string{"message": "Not Found"}, "")
+	}
+}
+
+// paged は items を per_page と page に従って分割して返します。
+func (f *fakeGitHub) paged(w http.ResponseWriter, r *http.Request, items []map[string]interface{}) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage < 1 {
+		perPage = 30
+	}
+	if f.maxPer > 0 && perPage > f.maxPer {
+		perPage = f.maxPer
+	}
+	last := (len(items) + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+	chunk := []map[string]interface{}{}
+	if start := (page - 1) * perPage; start < len(items) {
+		end := start + perPage
+		if end > len(items) {
+			end = len(items)
+		}
+		chunk = items[start:end]
+	}
+	base := fmt.Sprintf("http://%s%s", r.Host, r.URL.Path)
+	var links []string
+	if page < last {
+		links = append(links, fmt.Sprintf(`<%s?per_page=%d&page=%d>; rel="next"`, base, perPage, page+1))
+	}
+	links = append(links, fmt.Sprintf(`<%s?per_page=%d&page=%d>; rel="last"`, base, perPage, last))
+	f.send(w, r, http.StatusOK, chunk, strings.Join(links, ", "))
+}
+
+// send は v をJSONにして返します。200 の場合は本文のハッシュを ETag にし、If-None-Match が一致すれば 304 を返します（304 には Link を付けません）。
+func (f *fakeGitHub) send(w http.ResponseWriter, r *http.Request, status int, v interface{}, link string) {
+	body, _ := json.Marshal(v)
+	w.Header().Set("X-RateLimit-Remaining", "4999")
+	w.Header().Set("X-RateLimit-Reset", "9999999999")
+	w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+	if status == http.StatusOK {
+		sum := sha1.Sum(body)
+		etag := `"` + hex.EncodeToString(sum[:]) + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			f.mu.Lock()
+			f.notMod++
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if link != "" {
+			w.Header().Set("Link", link)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// readOutputs は dir/comments の下のファイル（rawDir を除く）を、comments からの相対パス → 内容 の形で読み込みます。
+func readOutputs(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	root := filepath.Join(dir, "comments")
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if info.IsDir() {
+			if rel == "raw" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// pagedComments は1つのPRに、ID が 1 から n まで順に並んだ n 件のコメントを作成します。
+func pagedComments(prNumber, n int) []map[string]interface{} {
+	comments := make([]map[string]interface{}, 0, n)
+	for i := 1; i <= n; i++ {
+		comments = append(comments, fakeComment(int64(i), prNumber, "bob", fmt.Sprintf("comment %d", i), "2024-05-01T10:00:00Z"))
+	}
+	return comments
+}
+
+// commentIDs はコメントのIDを順に並べます。
+func commentIDs(comments []Comment) []int64 {
+	ids := make([]int64, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// TestFetchReviewCommentsParallel は残りのページを並行して取得した結果が、1ページずつ取得した場合と同じ順序・内容になり、
+// 途中のページが一時的に失敗しても送り直して同じ結果になることを確認します。
+func TestFetchReviewCommentsParallel(t *testing.T) {
+	var mu sync.Mutex
+	failed := false
+	server := newFakeGitHub(t, nil, map[int][]map[string]interface{}{1: pagedComments(1, 10*commentsPerPage)}, func(f *fakeGitHub) {
+		// 4ページ目は並行して取得した場合にだけ、1回だけ 502 を返す
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if r.URL.Query().Get("page") == "4" && r.Header.Get("X-Test-Parallel") != "" && !failed {
+				failed = true
+				w.WriteHeader(http.StatusBadGateway)
+				return true
+			}
+			return false
+		}
+	})
+	withAPIBaseURL(t, server.apiURL())
+
+	var want []int64
+	for _, workers := range []int{1, 5} {
+		var base http.RoundTripper = http.DefaultTransport
+		if workers > 1 {
+			base = headerTransport{base: base, name: "X-Test-Parallel", value: "1"}
+		}
+		withTransport(t, &retryTransport{base: base, retries: 2, maxWait: 10 * time.Millisecond})
+		comments, available, err := fetchReviewComments(context.Background(), "o", "r", 1, "t", mediaTypeJSON, 0, workers, newConsole(""))
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		ids := commentIDs(comments)
+		if available != len(ids) || len(ids) != 10*commentsPerPage {
+			t.Fatalf("workers=%d: got %d comments (available %d), want %d", workers, len(ids), available, 10*commentsPerPage)
+		}
+		if workers == 1 {
+			want = ids
+			continue
+		}
+		for i := range ids {
+			if ids[i] != want[i] {
+				t.Fatalf("workers=%d: comment %d is %d, want %d", workers, i, ids[i], want[i])
+			}
+		}
+	}
+	if !failed {
+		t.Error("the failing page was never requested in parallel")
+	}
+}
+
+// headerTransport はすべてのリクエストにヘッダーを1つ付ける http.RoundTripper です（テスト用のサーバーで経路を見分けるために使用）。
+type headerTransport struct {
+	base        http.RoundTripper
+	name, value string
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.name, t.value)
+	return t.base.RoundTrip(req)
+}
+
+// BenchmarkFetchCommentPages は1リクエストに 20ms かかるサーバーから10ページのコメントを取得する時間を、並行数ごとに計測します。
+// 並行数が5の場合は、1の場合のおよそ5分の1の時間になります。
+func BenchmarkFetchCommentPages(b *testing.B) {
+	server := newFakeGitHub(b, nil, map[int][]map[string]interface{}{1: pagedComments(1, 10*commentsPerPage)}, func(f *fakeGitHub) {
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			time.Sleep(20 * time.Millisecond)
+			return false
+		}
+	})
+	withAPIBaseURL(b, server.apiURL())
+	withTransport(b, http.DefaultTransport)
+	for _, workers := range []int{1, 5} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				results := fetchCommentPages(context.Background(), "o", "r", 1, "t", mediaTypeJSON, 1, 10, 10, workers)
+				for page := 1; page <= 10; page++ {
+					r := results[page]
+					if r.Err != nil || len(r.Comments) != commentsPerPage || r.Comments[0].ID != int64((page-1)*commentsPerPage+1) {
+						b.Fatalf("page %d: %d comments, err %v", page, len(r.Comments), r.Err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestPaginationShift は取得の途中で項目が先頭に追加されて後ろのページにずれた場合（更新日時順の一覧でよく起きる）、
+// 前のページと重複した PR・コメントを二重に数えないことを確認します。
+func TestPaginationShift(t *testing.T) {
+	var prs, comments []map[string]interface{}
+	for n := 9; n >= 1; n-- {
+		prs = append(prs, fakePR(n, fmt.Sprintf("2024-05-%02dT00:00:00Z", n)))
+	}
+	comments = pagedComments(1, 9)
+	var mu sync.Mutex
+	shifted := map[string]bool{}
+	server := newFakeGitHub(t, nil, nil, func(f *fakeGitHub) {
+		f.maxPer = 3
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			var items *[]map[string]interface{}
+			var added map[string]interface{}
+			switch r.URL.Path {
+			case "/repos/o/r/pulls":
+				// 1ページ目を返した後に、マージされていないPRが更新されて一覧の先頭に来る
+				items = &prs
+				added = fakePR(100, "")
+				added["merged_at"] = nil
+			case "/repos/o/r/pulls/1/comments":
+				// 1ページ目を返した後に、古いコメントより前に並ぶコメントが追加される
+				items = &comments
+				added = fakeComment(0, 1, "carol", "inserted", "2024-05-01T09:00:00Z")
+			default:
+				return false
+			}
+			f.paged(w, r, *items)
+			if r.URL.Query().Get("page") == "1" && !shifted[r.URL.Path] {
+				shifted[r.URL.Path] = true
+				*items = append([]map[string]interface{}{added}, *items...)
+			}
+			return true
+		}
+	})
+	withAPIBaseURL(t, server.apiURL())
+	withTransport(t, http.DefaultTransport)
+	ctx := context.Background()
+
+	got, err := fetchMergedPRs(ctx, "o", "r", "t", prQuery{SortBy: prSortUpdated, State: stateMerged}, &prFilter{}, newConsole(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []int
+	for _, pr := range got {
+		numbers = append(numbers, pr.Number)
+	}
+	if fmt.Sprint(numbers) != "[9 8 7 6 5 4 3 2 1]" {
+		t.Errorf("listed PRs %v, want each of 9..1 once", numbers)
+	}
+
+	fetched, available, err := fetchReviewComments(ctx, "o", "r", 1, "t", mediaTypeJSON, 0, 1, newConsole(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := commentIDs(fetched); fmt.Sprint(ids) != "[1 2 3 4 5 6 7 8 9]" || available != 9 {
+		t.Errorf("fetched comments %v (available %d), want each of 1..9 once", ids, available)
+	}
+}
+
+// TestDeletedUserFixture は user が null のコメント（アカウントが削除されたユーザー）を含むAPIのレスポンスを読み込み、
+// "[deleted]" として出力・集計し、投稿者による絞り込みで一貫して扱うことを確認します。
+func TestDeletedUserFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "null_user_comments.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var comments []Comment
+	if err := json.Unmarshal(data, &comments); err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 3 {
+		t.Fatalf("decoded %d comments, want 3", len(comments))
+	}
+	deleted := comments[1]
+	if !deleted.IsDeletedUser() || deleted.AuthorLogin() != deletedUserLogin {
+		t.Fatalf("comment with a null user decoded as user %v", deleted.User)
+	}
+	if comments[0].IsDeletedUser() || comments[0].AuthorLogin() != "alice" {
+		t.Errorf("comment with a user decoded as %q", comments[0].AuthorLogin())
+	}
+
+	var text strings.Builder
+	if err := writeTextComment(&text, "", deleted); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "] [deleted]:\n") || strings.Contains(text.String(), "] :") {
+		t.Errorf("text output of a deleted user's comment:\n%s", text.String())
+	}
+	if record := newCommentRecord(PRComment{PRNumber: 3, Comment: deleted}); record.User != deletedUserLogin {
+		t.Errorf("record user = %q, want %q", record.User, deletedUserLogin)
+	}
+
+	var summary runSummary
+	summary.addComments(comments)
+	if summary.DeletedUserComments != 1 {
+		t.Errorf("DeletedUserComments = %d, want 1", summary.DeletedUserComments)
+	}
+
+	tests := []struct {
+		name   string
+		filter *commentFilter
+		want   []int64
+	}{
+		{"--author keeps only that author", &commentFilter{authors: lowerSet([]string{"alice"})}, []int64{1001}},
+		{"--exclude-author keeps deleted users", &commentFilter{excludeAuthors: lowerSet([]string{"alice"})}, []int64{1002, 1003}},
+		{"--skip-bots keeps deleted users", &commentFilter{skipBots: true}, []int64{1001, 1002}},
+		{"--exclude-self keeps deleted users", &commentFilter{excludeSelf: true}, []int64{1001, 1002, 1003}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, _ := tt.filter.apply(append([]Comment(nil), comments...))
+			if got := commentIDs(kept); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMergedWindowBoundaries は --since・--until で選ぶPRについて、期間の開始ちょうど（0 時）にマージされたPRを含め、
+// 終了ちょうどにマージされたPRを含めないこと、期間の開始より前に更新されたPRに到達したら次のページをたどらないことを確認します。
+func TestMergedWindowBoundaries(t *testing.T) {
+	// 一覧は更新日時の新しい順（#2 はマージ後に更新されたため、#3 より前に並ぶ）
+	pr2 := fakePR(2, "2024-04-30T23:59:59Z")
+	pr2["updated_at"] = "2024-05-02T00:00:00Z"
+	prs := []map[string]interface{}{
+		fakePR(6, "2024-06-01T00:00:00Z"),
+		fakePR(5, "2024-05-31T23:59:59Z"),
+		fakePR(4, "2024-05-15T12:00:00Z"),
+		pr2,
+		fakePR(3, "2024-05-01T00:00:00Z"),
+		fakePR(1, "2024-04-30T23:59:59Z"),
+		fakePR(7, "2024-04-20T00:00:00Z"),
+	}
+	for _, sortBy := range []string{prSortMerged, prSortUpdated} {
+		t.Run(sortBy, func(t *testing.T) {
+			server := newFakeGitHub(t, prs, nil, func(f *fakeGitHub) { f.maxPer = 2 })
+			withAPIBaseURL(t, server.apiURL())
+			withTransport(t, http.DefaultTransport)
+			var since, until timeFlag
+			if err := since.Set("2024-05-01"); err != nil {
+				t.Fatal(err)
+			}
+			if err := until.Set("2024-06-01"); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := fetchMergedPRs(context.Background(), "o", "r", "t", prQuery{Since: since, Until: until, SortBy: sortBy, State: stateMerged}, &prFilter{}, newConsole(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var numbers []int
+			for _, pr := range got {
+				numbers = append(numbers, pr.Number)
+			}
+			if fmt.Sprint(numbers) != "[5 4 3]" {
+				t.Errorf("selected PRs %v, want [5 4 3]", numbers)
+			}
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			for _, uri := range server.requests {
+				if strings.Contains(uri, "page=4") {
+					t.Errorf("requested %s after reaching PRs updated before --since", uri)
+				}
+			}
+		})
+	}
+}
+
+// TestSortPRsByMerged は更新日時とマージ日時の順序が入れ替わった一覧（古いPRに最近コメントが付いた場合など）から、
+// --sort-prs merged がマージ日時の新しい順に上位N件を選び、上位N件がそろったと判断できるまでだけページをたどることを確認します。
+func TestSortPRsByMerged(t *testing.T) {
+	pr := func(number int, merged, updated string) map[string]interface{} {
+		p := fakePR(number, merged)
+		p["updated_at"] = updated
+		if merged == "" {
+			p["merged_at"] = nil
+		}
+		return p
+	}
+	// 一覧は更新日時の新しい順
+	prs := []map[string]interface{}{
+		pr(10, "2022-01-01T00:00:00Z", "2024-06-10T00:00:00Z"), // 2年前にマージされ、昨日コメントが付いた
+		pr(9, "2024-06-09T00:00:00Z", "2024-06-09T00:00:00Z"),
+		pr(8, "2024-06-08T00:00:00Z", "2024-06-08T00:00:00Z"),
+		pr(7, "2024-05-01T00:00:00Z", "2024-06-07T00:00:00Z"),
+		pr(6, "2024-06-06T00:00:00Z", "2024-06-06T00:00:00Z"),
+		pr(5, "2024-06-05T00:00:00Z", "2024-06-05T00:00:00Z"),
+		pr(4, "2024-06-04T00:00:00Z", "2024-06-04T00:00:00Z"),
+		pr(3, "", "2024-06-03T00:00:00Z"),
+		pr(2, "2024-06-02T00:00:00Z", "2024-06-02T00:00:00Z"),
+	}
+	tests := []struct {
+		sortBy string
+		count  int
+		want   string
+		pages  int // たどるページ数（1ページ2件）
+	}{
+		// 2ページ目の時点では3番目のマージ日時（#7）より後に更新されたPRが残っている可能性があるため、3ページ目までたどる
+		{prSortMerged, 3, "[9 8 6]", 3},
+		// 1ページ目の最後（#9）はマージ日時が最も新しいPRと同時に更新されているため、2ページ目までたどる
+		{prSortMerged, 1, "[9]", 2},
+		{prSortMerged, 0, "[9 8 6 5 4 2 7 10]", 5},
+		{prSortUpdated, 3, "[10 9 8]", 2},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s-%d", tt.sortBy, tt.count), func(t *testing.T) {
+			server := newFakeGitHub(t, prs, nil, func(f *fakeGitHub) { f.maxPer = 2 })
+			withAPIBaseURL(t, server.apiURL())
+			withTransport(t, http.DefaultTransport)
+
+			got, err := fetchMergedPRs(context.Background(), "o", "r", "t", prQuery{Count: tt.count, SortBy: tt.sortBy, State: stateMerged}, &prFilter{}, newConsole(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var numbers []int
+			for _, pr := range got {
+				numbers = append(numbers, pr.Number)
+			}
+			if fmt.Sprint(numbers) != tt.want {
+				t.Errorf("selected PRs %v, want %s", numbers, tt.want)
+			}
+			if n := server.requestCount(); n != tt.pages {
+				t.Errorf("fetched %d pages of PRs, want %d", n, tt.pages)
+			}
+		})
+	}
+}
+
+// TestDedupeBodiesOutput は --dedupe-bodies がどの出力形式でも重複したコメントを出力せず、prs.json に最初に出現したコメントとの対応を記録することを確認します。
+func TestDedupeBodiesOutput(t *testing.T) {
+	prs := []map[string]interface{}{fakePR(2, "2024-05-02T00:00:00Z"), fakePR(1, "2024-05-01T00:00:00Z")}
+	server := newFakeGitHub(t, prs, map[int][]map[string]interface{}{
+		1: {fakeComment(11, 1, "bob", "Please add a changelog entry.", "2024-05-01T10:00:00Z"), fakeComment(12, 1, "bob", "unique one", "2024-05-01T10:01:00Z")},
+		2: {fakeComment(21, 2, "bob", "Please  add a changelog entry.\n", "2024-05-02T10:00:00Z")},
+	})
+	for _, format := range []string{formatText, formatJSON, formatCSV} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			code, out := runTool(t, dir, "--owner", "o", "--repo", "r", "--count", "2", "--no-cache", "--token", "t", "--api-url", server.apiURL(),
+				"--dedupe-bodies", "--format", format)
+			if code != 0 {
+				t.Fatalf("exited with %d:\n%s", code, out)
+			}
+			files := readOutputs(t, dir)
+			for name, content := range files {
+				if strings.HasSuffix(name, "prs.json") {
+					continue
+				}
+				if n := strings.Count(content, "changelog"); strings.Contains(name, "pr_1_") && n != 0 || strings.Contains(name, "pr_2_") && n != 1 {
+					t.Errorf("%s has %d copies of the duplicated body:\n%s", name, n, content)
+				}
+			}
+			var index []prIndexEntry
+			if err := json.Unmarshal([]byte(files[filepath.Join("o_r", "prs.json")]), &index); err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range index {
+				var want []duplicateRef
+				if e.Number == 1 { // 新しいPRから処理するため、PR #2 のコメントが最初に出現したコメントになる
+					want = []duplicateRef{{ID: 11, DuplicateOf: 21}}
+				}
+				if !reflect.DeepEqual(e.Duplicates, want) {
+					t.Errorf("prs.json duplicates of PR #%d = %v, want %v", e.Number, e.Duplicates, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"     // エラーメッセージの作成に使用
+	"regexp"  // 本文の正規表現による絞り込みに使用
+	"strings" // ユーザー名の比較に使用
+	"sync"    // 並行して処理するリポジトリ間の排他制御に使用
+	"time"    // 作成日時による絞り込みに使用
+)
+
+// commentFilter はコメント単位の絞り込み条件をまとめた構造体です。
+// コメントの取得後に適用するため、ページネーションには影響しません。
+type commentFilter struct {
+	authors            map[string]bool // 残すコメントの投稿者（小文字、空なら全員）
+	excludeAuthors     map[string]bool // 除外するコメントの投稿者（小文字、authors と重なる場合は除外が優先）
+	teamMembers        map[string]bool // 残すコメントの投稿者のチームメンバー（小文字、nilなら絞り込まない）
+	excludeTeamMembers map[string]bool // 除外するコメントの投稿者のチームメンバー（小文字）
+	skipBots           bool            // botのコメントを除外するか
+	botAccounts        map[string]bool // botとして扱う追加のアカウント（小文字）
+	excludeSelf        bool            // セルフレビューを除外するか
+	selfOnly           bool            // セルフレビューだけを残すか
+	unansweredOnly     bool            // 返信のないルートコメントだけを残すか
+	rootsOnly          bool            // ルートコメント（返信でないコメント）だけを残すか
+	repliesOnly        bool            // 返信だけを残すか
+	repliesTo          string          // 返信先のコメントの投稿者（小文字、空なら絞り込まない）
+	includeSelfReplies bool            // repliesTo のユーザーが自分のコメントに返信したものも残すか
+	minLength          int             // 本文の最小文字数（空白と絵文字を除く、0なら無制限）
+	skipTrivial        bool            // 「LGTM」などの定型的なコメントを除外するか
+	pathGlobs          []string        // 残すコメントのファイルパスのglob（いずれかに一致すればよい）
+	excludePathGlobs   []string        // 除外するコメントのファイルパスのglob
+	includePathless    bool            // パスの絞り込み時に、パスのないコメントを残すか
+	since              timeFlag        // この日時以降に作成されたコメントだけを残す（この日時を含む）
+	until              timeFlag        // この日時より前に作成されたコメントだけを残す（この日時を含まない）
+	match              []patternGroup  // 本文が一致しなければならない正規表現（グループ間はAND）
+	excludeMatch       []patternGroup  // 本文がすべて一致した場合に除外する正規表現（グループ間はAND）
+	matchPlain         bool            // 正規表現をプレーンテキストに変換した本文（--plain の出力と同じ）に対して判定するか
+	category           string          // 残すコメントの分類（空なら絞り込まない）
+	lang               string          // 残すコメントの言語（空なら絞り込まない）
+	langStrict         bool            // 言語を判定できないコメントも除外するか
+	skipMediaOnly      bool            // 画像やURLだけのコメントを除外するか
+	markMediaOnly      bool            // 画像やURLだけのコメントを除外せず MediaOnly を設定して残すか（JSON出力で使用）
+
+	dedupeBodies bool             // 同じ本文のコメントを最初の1件だけ残すか
+	dedupeScope  string           // 重複を判定する範囲（dedupeScopePR または dedupeScopeRun）
+	seenBodies   map[string]int64 // 正規化した本文 → 最初に出現したコメントのID
+	mu           sync.Mutex       // 複数のリポジトリを並行して処理する場合に、seenBodies を保護する
+}
+
+// 重複を判定する範囲の定数です。
+const (
+	dedupeScopePR  = "pr"  // PRごとに重複を判定する
+	dedupeScopeRun = "run" // 実行全体で重複を判定する（デフォルト）
+)
+
+// normalizeBody は重複判定のために本文を正規化します（前後の空白を除き、連続する空白を1つにまとめます）。
+func normalizeBody(body string) string {
+	return strings.Join(strings.Fields(body), " ")
+}
+
+// patternGroup は1つの --match / --exclude-match で指定された正規表現のグループです。
+// グループ内のカンマ区切りの候補はいずれかに一致すればよい（OR）ものとして扱います。
+type patternGroup struct {
+	source   string           // 指定された文字列（集計の表示に使用）
+	patterns []*regexp.Regexp // カンマで分割してコンパイルした正規表現
+}
+
+// matches は本文がグループ内のいずれかの正規表現に一致するかを返します。
+func (g patternGroup) matches(body string) bool {
+	for _, re := range g.patterns {
+		if re.MatchString(body) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePatternGroups は --match / --exclude-match の値を正規表現のグループにコンパイルします。
+// 各値はカンマで候補に分割されます（正規表現の中でカンマそのものを使う場合は \x2c と書きます）。
+//
+// パラメータ:
+//   - flagName: エラーメッセージに表示するフラグ名
+//   - values: フラグで指定された値
+//
+// 戻り値:
+//   - []patternGroup: コンパイルしたグループ
+//   - error: 不正な正規表現が含まれていた場合はエラー情報
+func compilePatternGroups(flagName string, values []string) ([]patternGroup, error) {
+	var groups []patternGroup
+	for _, v := range values {
+		g := patternGroup{source: v}
+		for _, alt := range strings.Split(v, ",") {
+			if alt = strings.TrimSpace(alt); alt == "" {
+				continue
+			}
+			re, err := regexp.Compile(alt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s pattern %q: %v", flagName, alt, err)
+			}
+			g.patterns = append(g.patterns, re)
+		}
+		if len(g.patterns) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+// filterCounts はPRごとの絞り込み結果の件数です。
+type filterCounts struct {
+	Dropped    map[string]int // 除外された理由ごとのコメント数
+	Matched    map[string]int // 条件（"--match パターン" など）ごとの一致したコメント数
+	Duplicates []duplicateRef // --dedupe-bodies で除外したコメントと、同じ本文で最初に出現したコメントの対応
+}
+
+// duplicateRef は --dedupe-bodies で除外したコメントが、どのコメントの重複かを表します（prs.json に記録します）。
+// 最初に出現したコメントは既に保存したファイルにあることがあるため、対応はコメントではなくPRの一覧に記録します。
+type duplicateRef struct {
+	ID          int64 `json:"id"`           // 除外したコメントのID
+	DuplicateOf int64 `json:"duplicate_of"` // 同じ本文で最初に出現したコメントのID
+}
+
+// newFilterCounts は空の集計を作成します。
+func newFilterCounts() filterCounts {
+	return filterCounts{Dropped: make(map[string]int), Matched: make(map[string]int)}
+}
+
+// 絞り込みでコメントが除外された理由です。実行の最後に理由ごとの件数を表示します。
+const (
+	dropAuthor        = "--author"
+	dropExcludeAuthor = "--exclude-author"
+	dropTeam          = "--team"
+	dropExcludeTeam   = "--exclude-team"
+	dropBot           = "--skip-bots"
+	dropSelf          = "--exclude-self/--self-only"
+	dropUnanswered    = "--unanswered-only"
+	dropThreadPos     = "--roots-only/--replies-only"
+	dropRepliesTo     = "--replies-to"
+	dropPathGlob      = "--path-glob"
+	dropExcludePath   = "--exclude-path-glob"
+	dropPathless      = "pathless (see --include-pathless)"
+	dropCreatedRange  = "--comments-since/--comments-until"
+	dropMinLength     = "--min-length"
+	dropTrivial       = "--skip-trivial"
+	dropMatch         = "--match"
+	dropExcludeMatch  = "--exclude-match"
+	dropDuplicate     = "--dedupe-bodies"
+	dropCategory      = "--category"
+	dropMediaOnly     = "--skip-media-only"
+	dropLang          = "--lang"
+)
+
+// apply はPRのコメントに絞り込み条件を適用します。
+// コメントには事前に annotateReplies と annotateSelfReview で集計・判定済みの情報が設定されている必要があります。
+//
+// パラメータ:
+//   - comments: 対象のコメント
+//
+// 戻り値:
+//   - []Comment: 条件に一致したコメントの配列
+//   - filterCounts: 除外された理由ごと・条件ごとの件数
+func (f *commentFilter) apply(comments []Comment) ([]Comment, filterCounts) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var kept []Comment
+	counts := newFilterCounts()
+	// PRごとに重複を判定する場合は、PRが変わるたびに出現済みの本文をリセットする
+	if f.dedupeBodies && (f.seenBodies == nil || f.dedupeScope == dedupeScopePR) {
+		f.seenBodies = make(map[string]int64)
+	}
+	for _, c := range comments {
+		if reason := f.dropReason(c, counts); reason != "" {
+			counts.Dropped[reason]++
+			continue
+		}
+		// 画像やURLだけのコメントの判定（引用の除去などの本文の加工後に判定する）
+		if (f.skipMediaOnly || f.markMediaOnly) && isMediaOnly(c.Body) {
+			if !f.markMediaOnly {
+				counts.Dropped[dropMediaOnly]++
+				continue
+			}
+			c.MediaOnly = true
+			counts.Matched["--mark-media-only"]++
+		}
+		// 他の条件をすべて満たしたコメントの中で重複を判定する
+		if f.dedupeBodies {
+			key := normalizeBody(c.Body)
+			if first, ok := f.seenBodies[key]; ok {
+				counts.Dropped[dropDuplicate]++
+				counts.Duplicates = append(counts.Duplicates, duplicateRef{ID: c.ID, DuplicateOf: first})
+				continue
+			}
+			f.seenBodies[key] = c.ID
+		}
+		kept = append(kept, c)
+	}
+	return kept, counts
+}
+
+// dropReason は1件のコメントを除外する理由を返します。残す場合は空文字を返します。
+// 正規表現などの条件に一致した件数は counts に記録します。
+func (f *commentFilter) dropReason(c Comment, counts filterCounts) string {
+	// 除外する投稿者の判定（--author と重なる場合も除外を優先するため先に判定する）
+	if len(f.excludeAuthors) > 0 && !c.IsDeletedUser() && f.excludeAuthors[strings.ToLower(c.User.Login)] {
+		return dropExcludeAuthor
+	}
+	// チームメンバーによる絞り込み
+	if f.excludeTeamMembers != nil && !c.IsDeletedUser() && f.excludeTeamMembers[strings.ToLower(c.User.Login)] {
+		return dropExcludeTeam
+	}
+	if f.teamMembers != nil && (c.IsDeletedUser() || !f.teamMembers[strings.ToLower(c.User.Login)]) {
+		return dropTeam
+	}
+	// botの除外
+	if f.skipBots && isBot(c.User, f.botAccounts) {
+		return dropBot
+	}
+	// 投稿者の絞り込み（削除されたユーザーはどの投稿者にも一致しない）
+	if len(f.authors) > 0 && (c.IsDeletedUser() || !f.authors[strings.ToLower(c.User.Login)]) {
+		return dropAuthor
+	}
+	// セルフレビューの絞り込み
+	if (f.excludeSelf && c.IsSelfReview) || (f.selfOnly && !c.IsSelfReview) {
+		return dropSelf
+	}
+	// ルートコメント・返信の絞り込み
+	if (f.rootsOnly && c.InReplyToID != nil) || (f.repliesOnly && c.InReplyToID == nil) {
+		return dropThreadPos
+	}
+	// 返信先のコメントの投稿者による絞り込み（annotateParents で設定した返信先の情報を使う）
+	if f.repliesTo != "" {
+		if c.InReplyToID == nil || !strings.EqualFold(c.ParentAuthor, f.repliesTo) {
+			return dropRepliesTo
+		}
+		if !f.includeSelfReplies && strings.EqualFold(c.AuthorLogin(), f.repliesTo) {
+			return dropRepliesTo
+		}
+	}
+	// 返信のないルートコメントの絞り込み
+	if f.unansweredOnly && (c.InReplyToID != nil || c.ReplyCount > 0) {
+		return dropUnanswered
+	}
+	// ファイルパスによる絞り込み
+	if len(f.pathGlobs) > 0 || len(f.excludePathGlobs) > 0 {
+		if c.Path == "" {
+			if !f.includePathless {
+				return dropPathless
+			}
+		} else {
+			for _, g := range f.excludePathGlobs {
+				if matchGlob(g, c.Path) {
+					counts.Matched["--exclude-path-glob "+g]++
+					return dropExcludePath
+				}
+			}
+			if len(f.pathGlobs) > 0 {
+				matched := false
+				for _, g := range f.pathGlobs {
+					if matchGlob(g, c.Path) {
+						counts.Matched["--path-glob "+g]++
+						matched = true
+					}
+				}
+				if !matched {
+					return dropPathGlob
+				}
+			}
+		}
+	}
+	// 作成日時による絞り込み（開始を含み、終了を含まない）
+	if f.since.IsSet || f.until.IsSet {
+		created, err := time.Parse(time.RFC3339, c.CreatedAt)
+		if err != nil || (f.since.IsSet && created.Before(f.since.Time)) || (f.until.IsSet && !created.Before(f.until.Time)) {
+			return dropCreatedRange
+		}
+	}
+	// 短いコメント・定型的なコメントの除外
+	if f.skipTrivial && isTrivialComment(c.Body) {
+		return dropTrivial
+	}
+	if f.minLength > 0 && meaningfulLength(c.Body) < f.minLength {
+		return dropMinLength
+	}
+	// 分類による絞り込み
+	if f.category != "" && c.Category != f.category {
+		return dropCategory
+	}
+	// 言語による絞り込み（判定できないコメントは --lang-strict の場合のみ除外する）
+	if f.lang != "" && c.Lang != f.lang && (c.Lang != langUnknown || f.langStrict) {
+		return dropLang
+	}
+	// 本文の正規表現による絞り込み（すべてのグループに一致する必要がある）
+	// --plain の場合は、出力される本文と同じくMarkdownの記号を取り除いた本文で判定する
+	matchBody := c.Body
+	if f.matchPlain && (len(f.match) > 0 || len(f.excludeMatch) > 0) {
+		matchBody = markdownToPlain(c.Body)
+	}
+	for _, g := range f.match {
+		if !g.matches(matchBody) {
+			return dropMatch
+		}
+		counts.Matched["--match "+g.source]++
+	}
+	// 本文の正規表現による除外（すべてのグループに一致した場合のみ除外する）
+	if len(f.excludeMatch) > 0 {
+		excluded := true
+		for _, g := range f.excludeMatch {
+			if !g.matches(matchBody) {
+				excluded = false
+				break
+			}
+		}
+		if excluded {
+			return dropExcludeMatch
+		}
+	}
+	return ""
+}
+
+// isBot はユーザーがbotかどうかを判定します。
+// アカウントの種類が "Bot" の場合、ユーザー名が "[bot]" で終わる場合（GitHub Apps）、
+// または追加で指定されたアカウントに含まれる場合にbotとみなします。
+//
+// パラメータ:
+//   - u: 判定するユーザー（削除されたユーザーの場合はnil）
+//   - extra: botとして扱う追加のアカウント（小文字）
+//
+// 戻り値:
+//   - bool: botの場合はtrue
+func isBot(u *User, extra map[string]bool) bool {
+	if u == nil {
+		return false
+	}
+	login := strings.ToLower(u.Login)
+	return u.Type == "Bot" || strings.HasSuffix(login, "[bot]") || extra[login]
+}
+
+// annotateSelfReview はPR作成者自身が投稿したコメントに IsSelfReview を設定します。
+// PRごとに作成者を判定するため、同じ人が複数のPRで作成者とレビュアーの両方になっていても正しく区別されます。
+//
+// パラメータ:
+//   - pr: コメントが属するプルリクエスト
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func annotateSelfReview(pr PullRequest, comments []Comment) {
+	for i, c := range comments {
+		comments[i].IsSelfReview = !c.IsDeletedUser() && pr.User.Login != "" && strings.EqualFold(c.User.Login, pr.User.Login)
+	}
+}
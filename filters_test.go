@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect" // 重複の対応の比較に使用
+	"testing" // テストの実行に使用
+)
+
+// TestMatchPlain は --plain の場合に、--match / --exclude-match をMarkdownの記号を取り除いた本文で判定することを確認します。
+func TestMatchPlain(t *testing.T) {
+	match, err := compilePatternGroups("--match", []string{`must be fixed`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exclude, err := compilePatternGroups("--exclude-match", []string{`see docs \(https`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := []Comment{
+		{ID: 1, User: &User{Login: "alice"}, Body: "This **must** be fixed"},
+		{ID: 2, User: &User{Login: "alice"}, Body: "This must be fixed, [see docs](https://example.com)"},
+		{ID: 3, User: &User{Login: "alice"}, Body: "Looks good"},
+	}
+	tests := []struct {
+		name  string
+		plain bool
+		want  []int64
+	}{
+		{"markdown", false, []int64{2}},
+		{"plain", true, []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &commentFilter{match: match, excludeMatch: exclude, matchPlain: tt.plain}
+			kept, _ := f.apply(append([]Comment(nil), comments...))
+			var ids []int64
+			for _, c := range kept {
+				ids = append(ids, c.ID)
+			}
+			if len(ids) != len(tt.want) || (len(ids) > 0 && ids[0] != tt.want[0]) {
+				t.Errorf("kept %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// TestDedupeBodies は --dedupe-bodies が空白を正規化した同じ本文の2件目以降を除外し、最初に出現したコメントとの対応を記録すること、
+// 範囲が run の場合はPRをまたいで、pr の場合はPRごとに判定することを確認します。
+func TestDedupeBodies(t *testing.T) {
+	pr1 := []Comment{{ID: 1, Body: "Please add a changelog entry."}, {ID: 2, Body: "  Please add a\nchangelog   entry. "}, {ID: 3, Body: "other"}}
+	pr2 := []Comment{{ID: 4, Body: "Please add a changelog entry."}, {ID: 5, Body: "new"}}
+	tests := []struct {
+		scope          string
+		kept1, kept2   []int64
+		dupes1, dupes2 []duplicateRef
+	}{
+		{dedupeScopeRun, []int64{1, 3}, []int64{5}, []duplicateRef{{ID: 2, DuplicateOf: 1}}, []duplicateRef{{ID: 4, DuplicateOf: 1}}},
+		{dedupeScopePR, []int64{1, 3}, []int64{4, 5}, []duplicateRef{{ID: 2, DuplicateOf: 1}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			f := &commentFilter{dedupeBodies: true, dedupeScope: tt.scope}
+			for _, step := range []struct {
+				comments []Comment
+				kept     []int64
+				dupes    []duplicateRef
+			}{{pr1, tt.kept1, tt.dupes1}, {pr2, tt.kept2, tt.dupes2}} {
+				kept, counts := f.apply(step.comments)
+				if got := commentIDs(kept); !reflect.DeepEqual(got, step.kept) {
+					t.Errorf("kept %v, want %v", got, step.kept)
+				}
+				if !reflect.DeepEqual(counts.Duplicates, step.dupes) {
+					t.Errorf("duplicates %v, want %v", counts.Duplicates, step.dupes)
+				}
+				if counts.Dropped[dropDuplicate] != len(step.dupes) {
+					t.Errorf("dropped %d duplicates, want %d", counts.Dropped[dropDuplicate], len(step.dupes))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"   // 標準入力からの行単位の読み込みに使用
+	"flag"    // 指定されたフラグの確認に使用
+	"fmt"     // エラーメッセージの作成に使用
+	"io"      // PR番号の読み込み元に使用
+	"log"     // 不正なPR番号の警告に使用
+	"strconv" // 真偽値の解析に使用
+	"strings" // フラグ値の分割に使用
+	"time"    // 日時フラグの解析に使用
+)
+
+// stringList は複数回指定できる文字列フラグです。
+// --author alice --author bob のような繰り返し指定と、--author alice,bob のようなカンマ区切りの両方を受け付けます。
+type stringList []string
+
+// String は flag.Value インタフェースの実装です。
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set は flag.Value インタフェースの実装です。カンマ区切りの値を分割して追加します。
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// rawList は複数回指定できる文字列フラグです。stringList と異なり、値をカンマで分割せずそのまま保持します。
+// 正規表現のように値の中にカンマを含みうるフラグに使用します。
+type rawList []string
+
+// String は flag.Value インタフェースの実装です。
+func (l *rawList) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Set は flag.Value インタフェースの実装です。
+func (l *rawList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// containsString は文字列の配列に指定された値が含まれているかを返します。
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerSet は文字列の配列を、大文字小文字を区別せずに照合するための集合に変換します。
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// dateLayout は日付だけを指定する場合の形式です。
+const dateLayout = "2006-01-02"
+
+// parseDateTime は RFC3339 または YYYY-MM-DD 形式の文字列を日時に変換します。
+// YYYY-MM-DD の場合はUTCのその日の0時とみなします。
+func parseDateTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected RFC3339 or YYYY-MM-DD)", value)
+}
+
+// timeFlag は RFC3339 または YYYY-MM-DD 形式で指定する日時フラグです。
+// 不正な値はフラグの解析時にエラーになります。
+type timeFlag struct {
+	Time  time.Time // 指定された日時
+	IsSet bool      // フラグが指定されたかどうか
+}
+
+// String は flag.Value インタフェースの実装です。
+func (f *timeFlag) String() string {
+	if f == nil || !f.IsSet {
+		return ""
+	}
+	return f.Time.Format(time.RFC3339)
+}
+
+// Set は flag.Value インタフェースの実装です。
+func (f *timeFlag) Set(value string) error {
+	t, err := parseDateTime(value)
+	if err != nil {
+		return err
+	}
+	f.Time, f.IsSet = t, true
+	return nil
+}
+
+// maxPRRange は --pr で1つの範囲として指定できるPRの最大数です（1-100000 のような誤入力を防ぐため）。
+const maxPRRange = 1000
+
+// prNumberList は複数回指定できるPR番号のフラグです。
+// --pr 1423 --pr 1501 のような繰り返し指定、--pr 1423,1501 のようなカンマ区切り、--pr 1400-1410 のような範囲を受け付けます。
+// 同じ番号が複数回指定された場合は最初の1つだけを残します。
+type prNumberList []int
+
+// String は flag.Value インタフェースの実装です。
+func (l *prNumberList) String() string {
+	values := make([]string, len(*l))
+	for i, n := range *l {
+		values[i] = strconv.Itoa(n)
+	}
+	return strings.Join(values, ",")
+}
+
+// Set は flag.Value インタフェースの実装です。
+func (l *prNumberList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(v, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || first <= 0 {
+			return fmt.Errorf("invalid PR number %q", v)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || last < first {
+				return fmt.Errorf("invalid PR range %q (expected FROM-TO with FROM <= TO)", v)
+			}
+			if last-first+1 > maxPRRange {
+				return fmt.Errorf("PR range %q is too large (at most %d PRs per range)", v, maxPRRange)
+			}
+		}
+		for n := first; n <= last; n++ {
+			if !containsInt(*l, n) {
+				*l = append(*l, n)
+			}
+		}
+	}
+	return nil
+}
+
+// readFrom は空白・改行区切りのPR番号を読み込み、リストに追加します（--pr-stdin 用）。
+// 空行と "#" で始まる行は無視し、数値として読めない語は行番号とともに警告して読み飛ばします。
+// 入力の順序を保ち、すでに含まれている番号は追加しません。
+//
+// パラメータ:
+//   - r: 読み込み元（通常は標準入力）
+//
+// 戻り値:
+//   - error: 読み込みに失敗した場合のエラー情報
+func (l *prNumberList) readFrom(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		for _, field := range strings.Fields(text) {
+			n, err := strconv.Atoi(field)
+			if err != nil || n <= 0 {
+				log.Printf("Warning: stdin line %d: invalid PR number %q; skipped", line, field)
+				continue
+			}
+			if !containsInt(*l, n) {
+				*l = append(*l, n)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// containsInt は整数の配列に指定された値が含まれているかを返します。
+func containsInt(values []int, v int) bool {
+	for _, n := range values {
+		if n == v {
+			return true
+		}
+	}
+	return false
+}
+
+// isFlagSet はコマンドラインでフラグが明示的に指定されたかを返します（flag.Parse の後に呼び出します）。
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// parseArgs は flag.Parse の後に残った引数から位置引数を取り出し、その後ろのフラグも続けて解析します。
+// 標準の flag パッケージは最初の位置引数で解析をやめるため、`acme/api --count 5` のように
+// 位置引数の後にフラグを書いた場合にも、フラグとして扱えるようにします。
+//
+// 戻り値:
+//   - []string: 位置引数（"--" より後の引数はすべて位置引数として扱います）
+func parseArgs() []string {
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		// 解析でエラーになった場合は flag.ExitOnError により終了する
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	return args
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing" // テストの実行に使用
+	"time"    // 期待する日時の作成に使用
+)
+
+// TestParseDateTime は --since・--until などの日時の解析で、YYYY-MM-DD が UTC の 0 時になり、
+// RFC3339 のオフセットが保たれることを確認します。
+func TestParseDateTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-05-01T00:00:00Z", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-05-01T09:00:00+09:00", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-04-30T23:59:59Z", time.Date(2024, 4, 30, 23, 59, 59, 0, time.UTC)},
+		{"2024-04-30T20:00:00-04:00", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseDateTime(tt.value)
+		if err != nil {
+			t.Errorf("parseDateTime(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDateTime(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"", "2024-5-1", "2024-05-01 00:00:00", "2024-05-01T00:00:00", "2024-02-30"} {
+		if _, err := parseDateTime(value); err == nil {
+			t.Errorf("parseDateTime(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+// TestMergedWithinBoundaries は期間の開始ちょうどにマージされたPRを含め、終了ちょうどにマージされたPRを含めないことを確認します。
+func TestMergedWithinBoundaries(t *testing.T) {
+	var since, until timeFlag
+	if err := since.Set("2024-05-01"); err != nil {
+		t.Fatal(err)
+	}
+	if err := until.Set("2024-06-01"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		merged string
+		want   bool
+	}{
+		{"2024-04-30T23:59:59Z", false},
+		{"2024-05-01T00:00:00Z", true},
+		{"2024-05-01T09:00:00+09:00", true},  // 開始と同じ時刻（別のオフセット）
+		{"2024-05-01T08:59:59+09:00", false}, // 開始の1秒前
+		{"2024-05-31T23:59:59Z", true},
+		{"2024-06-01T00:00:00Z", false},
+		{"2024-06-01T08:59:59+09:00", true}, // 終了の1秒前
+		{"not a date", false},
+	}
+	for _, tt := range tests {
+		if got := mergedWithin(tt.merged, since, until); got != tt.want {
+			t.Errorf("mergedWithin(%q) = %v, want %v", tt.merged, got, tt.want)
+		}
+	}
+	if !mergedWithin("not a date", timeFlag{}, timeFlag{}) {
+		t.Error("mergedWithin without a window rejected a PR")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"           // エラーメッセージの作成に使用
+	"os"            // 設定ディレクトリの取得とファイルの読み込みに使用
+	"os/exec"       // gh コマンドの実行に使用
+	"path/filepath" // hosts.yml のパスの組み立てに使用
+	"runtime"       // Windows の設定ディレクトリの判定に使用
+	"strings"       // 出力の整形に使用
+)
+
+// トークンの取得方法（--auth）の定数です。
+const (
+	authAuto    = "auto"    // --token・環境変数・設定ファイルのトークンを使い、どれもなければ gh CLI のトークンを使う（デフォルト）
+	authToken   = "token"   // --token・環境変数・設定ファイルのトークンだけを使う
+	authGH      = "gh"      // gh CLI に保存されたトークンを使う（gh auth login 済みの場合）
+	authKeyring = "keyring" // fetch_pr_comments auth set でOSのキーリングに保存したトークンを使う
+)
+
+// ghToken は gh CLI に保存された、指定されたホストのトークンを取得します。
+// まず `gh auth token --hostname HOST` を実行し、gh がインストールされていない場合などは gh の hosts.yml を読みます。
+// エラーメッセージにはトークンの値を含めません。
+//
+// パラメータ:
+//   - host: GitHubのWebのホスト名（github.com または GitHub Enterprise Server のホスト名）
+//
+// 戻り値:
+//   - string: トークン
+//   - error: どちらの方法でもトークンを取得できない場合のエラー情報
+func ghToken(host string) (string, error) {
+	out, cmdErr := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if token := strings.TrimSpace(string(out)); cmdErr == nil && token != "" {
+		return token, nil
+	}
+	token, fileErr := ghHostsToken(host)
+	if fileErr == nil {
+		return token, nil
+	}
+	if ee, ok := cmdErr.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		cmdErr = fmt.Errorf("%s", strings.TrimSpace(string(ee.Stderr)))
+	}
+	return "", fmt.Errorf("gh auth token: %v; %v", cmdErr, fileErr)
+}
+
+// ghConfigDir は gh CLI の設定ディレクトリを返します（GH_CONFIG_DIR・XDG_CONFIG_HOME・AppData・~/.config の順）。
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	if dir := os.Getenv("AppData"); runtime.GOOS == "windows" && dir != "" {
+		return filepath.Join(dir, "GitHub CLI")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
+
+// ghHostsToken は gh CLI の hosts.yml から、指定されたホストの oauth_token を読み込みます。
+// gh がトークンをOSのキーチェーンに保存している場合は hosts.yml にトークンがないため、エラーを返します。
+func ghHostsToken(host string) (string, error) {
+	path := filepath.Join(ghConfigDir(), "hosts.yml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %v", path, err)
+	}
+	root, err := parseYAML(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	entry := root.Get(host)
+	if root.Kind != yamlMapping || entry == nil || entry.Kind != yamlMapping {
+		return "", fmt.Errorf("%s has no entry for %s (run gh auth login --hostname %s)", path, host, host)
+	}
+	if n := entry.Get("oauth_token"); n != nil && n.Kind == yamlScalar && n.Value != "" {
+		return n.Value, nil
+	}
+	return "", fmt.Errorf("%s has no oauth_token for %s", path, host)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path"    // パスのセグメント単位の照合に使用
+	"strings" // パスの分割に使用
+)
+
+// matchGlob はdoublestar形式のglobパターンとファイルパスを照合します。
+// "**" は0個以上のディレクトリに一致し、"*"・"?"・"[...]" は1つのセグメントの中で path.Match と同じように扱います。
+// "/" を含まないパターン（例: "*.swift"）はファイル名（最後のセグメント）と照合するため、どのディレクトリにあっても一致します。
+//
+// パラメータ:
+//   - pattern: globパターン（例: "internal/network/**"）
+//   - name: 照合するファイルパス（"/" 区切り）
+//
+// 戻り値:
+//   - bool: 一致した場合はtrue
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	name = strings.TrimPrefix(name, "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments はパターンとパスをセグメント単位で照合します。"**" は0個以上のセグメントに一致します。
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// 連続する "**" はまとめて扱う
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			// 残りのパターンがどこかの位置から一致するかを試す
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"html/template" // HTMLレポートの生成に使用（自動エスケープ付き）
+	"io"            // 入出力の基本インタフェースを提供
+)
+
+// htmlReportTemplate はHTMLレポートのテンプレートです。
+// GitHubでレンダリング済みのHTML本文がある場合はそれを使い、ない場合はMarkdownをそのまま整形済みテキストとして表示します。
+// --merge でPRごとに追記できるよう、先頭（head）・PRのセクション（section）・末尾（foot）を別のテンプレートとして定義します。
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"logins":     func(users []User) string { return joinOrNone(userLogins(users)) },
+	"suffix":     authorSuffix,
+	"labels":     labelSuffix,
+	"state":      stateSuffix,
+	"repository": repositoryPrefix,
+	"size":       sizeSuffix,
+	"truncation": truncationSuffix,
+	"category":   categoryPrefix,
+	"parent":     parentLine,
+	"created":    displayCreatedAt,
+	"renderBody": renderHTMLBody,
+}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 960px; margin: 2em auto; }
+.comment { border: 1px solid #d0d7de; border-radius: 6px; margin: 1em 0; padding: 0 1em; }
+.meta { color: #57606a; font-size: 0.9em; }
+.raw { white-space: pre-wrap; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #d0d7de; padding: 4px 8px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{end}}{{define "section"}}
+<section>
+<h2>{{repository .PR}}PR #{{.PR.Number}} by {{.PR.User.Login}}{{labels .PR}}{{size .PR}}{{state .PR}}{{truncation .PR}}</h2>
+<p class="meta">Requested reviewers: {{logins .PR.RequestedReviewers}} / Assignees: {{logins .PR.Assignees}}</p>
+{{range .Comments}}
+<div class="comment">
+<p class="meta">{{category .}}[{{created .}}] {{.AuthorLogin}}{{suffix .}}</p>
+{{with parent .}}<blockquote class="meta">{{.}}</blockquote>{{end}}
+{{renderBody .}}
+</div>
+{{end}}
+</section>
+{{end}}{{define "foot"}}
+</body>
+</html>
+{{end}}{{template "head" .}}{{range .Sections}}{{template "section" .}}{{end}}{{template "foot" .}}`))
+
+// renderHTMLBody はコメント本文をHTMLレポート用に変換します。
+// --render-html で取得したGitHubのレンダリング結果があればそれを使用し、
+// なければ生のMarkdownをエスケープして整形済みテキストとして表示します。
+func renderHTMLBody(c Comment) template.HTML {
+	if c.BodyHTML != "" {
+		// body_html はGitHub側でサニタイズ済みのため、そのまま埋め込む
+		return template.HTML(c.BodyHTML)
+	}
+	return template.HTML(`<div class="raw">` + template.HTMLEscapeString(c.Body) + `</div>`)
+}
+
+// writeHTMLReport はPRごとのセクションをHTMLレポートとして書き込みます。
+//
+// パラメータ:
+//   - w: 書き込み先
+//   - title: レポートのタイトル
+//   - sections: 表示するPRとコメントのセクション
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func writeHTMLReport(w io.Writer, title string, sections []prSection) error {
+	return htmlReportTemplate.Execute(w, struct {
+		Title    string
+		Sections []prSection
+	}{title, sections})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"       // 実行全体の期限の伝達に使用
+	"crypto/sha256" // 画像URLのハッシュ化（重複排除）に使用
+	"encoding/hex"  // ハッシュ値の文字列化に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"io"            // 入出力の基本インタフェースを提供
+	"net/http"      // 画像のダウンロードに使用
+	"net/url"       // URLの解析に使用
+	"os"            // ファイル操作に使用
+	"path"          // URLパスから拡張子を取り出すために使用
+	"path/filepath" // ファイルパス操作に使用
+	"regexp"        // 本文中の画像参照の抽出に使用
+	"strings"       // 文字列操作に使用
+	"sync"          // 並行ダウンロードの排他制御に使用
+)
+
+// imageAssetsDir は画像を保存するサブディレクトリ名です（出力ディレクトリからの相対パス）。
+const imageAssetsDir = "assets"
+
+var (
+	// markdownImagePattern は ![alt](URL "title") 形式の画像参照にマッチします。
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?(https?://[^\s)>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	// htmlImagePattern は <img src="URL"> 形式の画像参照にマッチします。
+	htmlImagePattern = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["'](https?://[^"']+)["']`)
+)
+
+// extractImageURLs はコメント本文から画像URLを重複なく抽出します。
+func extractImageURLs(body string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, pattern := range []*regexp.Regexp{markdownImagePattern, htmlImagePattern} {
+		for _, m := range pattern.FindAllStringSubmatch(body, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				urls = append(urls, m[1])
+			}
+		}
+	}
+	return urls
+}
+
+// imageDownloader はコメント本文に含まれる画像をダウンロードし、ローカルに保存します。
+// 同じURLの画像は一度だけダウンロードされ、同時ダウンロード数と合計サイズには上限があります。
+type imageDownloader struct {
+	ctx      context.Context // 実行全体の期限を伝えるコンテキスト
+	dir      string          // 画像の保存先ディレクトリ
+	token    string          // GitHubのホストに送るトークン
+	client   *http.Client    // ダウンロード用のHTTPクライアント
+	sem      chan struct{}   // 同時ダウンロード数を制限するセマフォ
+	maxBytes int64           // 保存する画像の合計サイズの上限（以前の実行で保存済みの画像を含む。0以下なら無制限）
+	out      *console        // ダウンロードに失敗した場合のエラーの出力先
+
+	mu         sync.Mutex        // 以下のフィールドを保護する
+	totalBytes int64             // これまでに使った合計サイズ（ダウンロード中の画像の読み込み済みの分と、再利用した保存済みの画像を含む）
+	local      map[string]string // 画像URL → 出力ディレクトリからの相対パス（失敗した場合は空文字）
+}
+
+// newImageDownloader は画像ダウンローダーを作成します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - saveDir: 出力ディレクトリ（この下の assets/ に画像を保存します）
+//   - token: GitHub APIアクセス用のトークン
+//   - concurrency: 同時ダウンロード数の上限
+//   - maxBytes: ダウンロードする合計サイズの上限（0以下なら無制限）
+//   - out: ダウンロードに失敗した場合のエラーの出力先
+//
+// 戻り値:
+//   - *imageDownloader: 作成したダウンローダー
+func newImageDownloader(ctx context.Context, saveDir, token string, concurrency int, maxBytes int64, out *console) *imageDownloader {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &imageDownloader{
+		ctx:      ctx,
+		dir:      filepath.Join(saveDir, imageAssetsDir),
+		token:    token,
+		client:   apiClient,
+		sem:      make(chan struct{}, concurrency),
+		maxBytes: maxBytes,
+		out:      out,
+		local:    make(map[string]string),
+	}
+}
+
+// localize はコメントに含まれる画像をすべてダウンロードし、本文中のURLをローカルの相対パスに書き換えます。
+// ダウンロードに失敗した画像は元のURLのまま残します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+//   - rewrite: 本文を書き換えるかどうか（Markdown/HTML出力の場合のみtrue）
+func (d *imageDownloader) localize(comments []Comment, rewrite bool) {
+	// 未処理の画像URLを集める
+	var pending []string
+	d.mu.Lock()
+	for _, c := range comments {
+		for _, u := range extractImageURLs(c.Body + "\n" + c.BodyHTML) {
+			if _, ok := d.local[u]; !ok {
+				d.local[u] = ""
+				pending = append(pending, u)
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	// 同時ダウンロード数を制限しながら並行してダウンロード
+	var wg sync.WaitGroup
+	for _, u := range pending {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			d.sem <- struct{}{}
+			defer func() { <-d.sem }()
+
+			rel, err := d.download(u)
+			if err != nil {
+				d.out.Logf("Error downloading image %s: %v", u, err)
+				return
+			}
+			d.mu.Lock()
+			d.local[u] = rel
+			d.mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+
+	if !rewrite {
+		return
+	}
+	// 本文中のURLをローカルの相対パスに書き換え
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range comments {
+		for _, u := range extractImageURLs(comments[i].Body + "\n" + comments[i].BodyHTML) {
+			if rel := d.local[u]; rel != "" {
+				comments[i].Body = strings.ReplaceAll(comments[i].Body, u, rel)
+				comments[i].BodyHTML = strings.ReplaceAll(comments[i].BodyHTML, u, rel)
+			}
+		}
+	}
+}
+
+// download は1つの画像をダウンロードして保存し、出力ディレクトリからの相対パスを返します。
+// ファイル名はURLのハッシュから作るため、同じURLは同じファイルになります。
+func (d *imageDownloader) download(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	name := hex.EncodeToString(sum[:])[:16] + strings.ToLower(path.Ext(parsed.Path))
+	rel := path.Join(imageAssetsDir, name)
+	dest := filepath.Join(d.dir, name)
+
+	// 以前の実行で保存済みならダウンロードしない（出力が参照する画像として、合計サイズには数える）
+	if info, err := os.Stat(dest); err == nil {
+		if !d.reserve(info.Size()) {
+			return "", d.limitError()
+		}
+		return rel, nil
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, "GET", rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// トークンはGitHubのホスト（GitHub Enterprise Server の場合はそのホスト）にのみ送る
+	host := strings.ToLower(parsed.Hostname())
+	if host == webHost() || host == "github.com" || strings.HasSuffix(host, ".github.com") || strings.HasSuffix(host, ".githubusercontent.com") {
+		req.Header.Set("Authorization", "token "+d.token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	// 読み込んだ分だけ合計サイズの上限から確保しながら読み込む（並行してダウンロードする他の画像と合わせて上限を超えないようにする）
+	body := &reservingReader{r: resp.Body, d: d}
+	data, err := io.ReadAll(body)
+	if err == nil {
+		if err = os.MkdirAll(d.dir, 0755); err != nil {
+			err = fmt.Errorf("failed to create directory: %v", err)
+		} else {
+			err = os.WriteFile(dest, data, 0644)
+		}
+	}
+	if err != nil {
+		// 保存しなかった画像の分は、他の画像のために解放する
+		d.release(body.reserved)
+		return "", err
+	}
+	return rel, nil
+}
+
+// reserve は合計サイズの上限の中から n バイトを確保します。上限を超える場合は確保せずに false を返します。
+func (d *imageDownloader) reserve(n int64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.maxBytes > 0 && d.totalBytes+n > d.maxBytes {
+		return false
+	}
+	d.totalBytes += n
+	return true
+}
+
+// release は reserve で確保したサイズを解放します。
+func (d *imageDownloader) release(n int64) {
+	d.mu.Lock()
+	d.totalBytes -= n
+	d.mu.Unlock()
+}
+
+// limitError は合計サイズの上限に達したことを表すエラーを返します。
+func (d *imageDownloader) limitError() error {
+	return fmt.Errorf("total image size limit (%d bytes) reached", d.maxBytes)
+}
+
+// reservingReader は読み込んだバイト数を、読み込むたびに合計サイズの上限から確保する io.Reader です。
+// 上限を超える場合は、それ以上読み込まずにエラーを返します。
+type reservingReader struct {
+	r        io.Reader
+	d        *imageDownloader
+	reserved int64 // これまでに確保したバイト数
+}
+
+// Read は io.Reader インタフェースの実装です。
+func (r *reservingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if !r.d.reserve(int64(n)) {
+			return 0, r.d.limitError()
+		}
+		r.reserved += int64(n)
+	}
+	return n, err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"           // ダウンローダーのコンテキストに使用
+	"crypto/sha256"     // 保存済みの画像のファイル名の作成に使用
+	"encoding/hex"      // ファイル名の作成に使用
+	"fmt"               // コメント本文の作成に使用
+	"net/http"          // テスト用の画像サーバーの実装に使用
+	"net/http/httptest" // テスト用の画像サーバーの起動に使用
+	"os"                // 保存された画像の確認に使用
+	"path/filepath"     // 保存先のパスの組み立てに使用
+	"strings"           // 画像の内容の作成に使用
+	"sync/atomic"       // 画像のリクエスト数の数え上げに使用
+	"testing"           // テストの実行に使用
+)
+
+// imageServer はどのパスにも size バイトの画像を返すテスト用のサーバーを起動し、リクエスト数のカウンターと合わせて返します。
+func imageServer(t *testing.T, size int) (*httptest.Server, *atomic.Int64) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(strings.Repeat("x", size)))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// imageComment は画像URLを本文に含むコメントを作成します。
+func imageComment(urls ...string) Comment {
+	var b strings.Builder
+	for _, u := range urls {
+		fmt.Fprintf(&b, "![img](%s)\n", u)
+	}
+	return Comment{Body: b.String()}
+}
+
+// savedImages は保存された画像の数を返します。
+func savedImages(t *testing.T, dir string) int {
+	files, err := filepath.Glob(filepath.Join(dir, imageAssetsDir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+// TestImageLimitConcurrent は並行してダウンロードする場合も、合計サイズが --max-image-mb の上限を超えないことと、
+// 保存しなかった画像の分を解放することを確認します。
+func TestImageLimitConcurrent(t *testing.T) {
+	server, _ := imageServer(t, 100)
+	dir := t.TempDir()
+	d := newImageDownloader(context.Background(), dir, "t", 8, 250, newConsole(""))
+	var urls []string
+	for i := 0; i < 8; i++ {
+		urls = append(urls, fmt.Sprintf("%s/%d.png", server.URL, i))
+	}
+	d.localize([]Comment{imageComment(urls...)}, true)
+	if n := savedImages(t, dir); n != 2 {
+		t.Errorf("saved %d images of 100 bytes under a 250-byte limit, want 2", n)
+	}
+	if d.totalBytes != 200 {
+		t.Errorf("totalBytes = %d, want 200 (the size of the saved images)", d.totalBytes)
+	}
+}
+
+// TestImageLimitCountsExisting は以前の実行で保存済みの画像を再利用した場合も、その大きさを合計サイズに数えることを確認します。
+func TestImageLimitCountsExisting(t *testing.T) {
+	server, requests := imageServer(t, 100)
+	dir := t.TempDir()
+	existing := server.URL + "/existing.png"
+	sum := sha256.Sum256([]byte(existing))
+	if err := os.MkdirAll(filepath.Join(dir, imageAssetsDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, imageAssetsDir, hex.EncodeToString(sum[:])[:16]+".png"), []byte(strings.Repeat("y", 200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := newImageDownloader(context.Background(), dir, "t", 1, 250, newConsole(""))
+	comments := []Comment{imageComment(existing), imageComment(server.URL + "/new.png")}
+	d.localize(comments[:1], true)
+	d.localize(comments[1:], true)
+	if requests.Load() != 1 {
+		t.Errorf("%d requests, want 1 (only the new image)", requests.Load())
+	}
+	if d.totalBytes != 200 {
+		t.Errorf("totalBytes = %d, want 200 (the reused image)", d.totalBytes)
+	}
+	if !strings.Contains(comments[0].Body, imageAssetsDir+"/") {
+		t.Errorf("the existing image was not reused: %q", comments[0].Body)
+	}
+	if !strings.Contains(comments[1].Body, server.URL) {
+		t.Errorf("the new image was saved although the existing one used 200 of 250 bytes: %q", comments[1].Body)
+	}
+	if n := savedImages(t, dir); n != 1 {
+		t.Errorf("%d images in %s, want only the existing one", n, imageAssetsDir)
+	}
+}
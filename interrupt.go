@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"   // 実行全体のコンテキストの中断に使用
+	"log"       // 中断したことの表示に使用
+	"os"        // シグナルの種類と強制終了に使用
+	"os/signal" // シグナルの受信に使用
+	"syscall"   // SIGTERM の指定に使用
+)
+
+// exitInterrupted は Ctrl-C（SIGINT）または SIGTERM で実行を中断した場合の終了コードです（シェルの慣例に合わせて128+2）。
+const exitInterrupted = 130
+
+// handleInterrupts は SIGINT と SIGTERM を受け取ったときに、実行全体のコンテキストを中断します。
+// 1回目は実行中のリクエストを止めて、それまでに取得した結果を保存してから終了させます。
+// 保存を待たずに終了したい場合のため、2回目を受け取ったときは直ちに終了します。
+//
+// パラメータ:
+//   - stop: 実行全体のコンテキストを中断する関数（中断の理由として limitError を渡す）
+func handleInterrupts(stop context.CancelCauseFunc) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %v; stopping and saving the results collected so far (press Ctrl-C again to quit immediately)", sig)
+		stop(&limitError{Flag: "interrupt", Interrupted: true})
+		<-signals
+		log.Print("Interrupted again; exiting without saving")
+		os.Exit(exitInterrupted)
+	}()
+}
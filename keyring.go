@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"   // 標準入力からのトークンの読み込みに使用
+	"errors"  // トークンが保存されていないことを表すエラーに使用
+	"fmt"     // フォーマット済み入出力に使用
+	"os"      // 標準入力・標準エラー出力に使用
+	"strings" // 入力の整形に使用
+)
+
+// keyringService はOSのキーリングにトークンを保存するときのサービス名です（アカウント名にはREST APIのホスト名を使います）。
+const keyringService = "fetch_pr_comments"
+
+// errKeyringNotFound はOSのキーリングに、指定されたホストのトークンが保存されていないことを表します。
+var errKeyringNotFound = errors.New("no token is stored in the OS keyring")
+
+// errNoKeyring はOSのキーリングを使えないことを表します。
+var errNoKeyring = errors.New("no OS keyring is available")
+
+// keyringUnavailable はOSのキーリングを使えない場合のエラーを作成します。平文のファイルには保存せず、代わりの方法を案内します。
+func keyringUnavailable(detail string) error {
+	return fmt.Errorf("%w (%s); the token is not stored in plaintext instead. Use --token-file with a file only you can read, or --login", errNoKeyring, detail)
+}
+
+// runAuthCommand は auth サブコマンド（auth set・auth get・auth delete）を実行します。
+// トークンはREST APIのホスト名（--api-url）ごとに、OSのキーリング（macOS はキーチェーン、Linux は Secret Service、Windows は資格情報マネージャー）に保存します。
+// auth set は、コマンドラインの履歴やプロセス一覧に残らないよう、トークンを標準入力の1行目から読み込みます。
+//
+// パラメータ:
+//   - args: auth に続く位置引数（例: ["set"]）
+//
+// 戻り値:
+//   - error: 不正な引数の場合やキーリングを使えない場合のエラー情報、成功時はnil
+func runAuthCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: fetch_pr_comments auth set|get|delete [--api-url URL]")
+	}
+	host := apiHost()
+	switch args[0] {
+	case "set":
+		fmt.Fprintf(os.Stderr, "Paste the token for %s and press Enter: ", host)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read the token from standard input: %v", err)
+		}
+		token := strings.TrimSpace(line)
+		if token == "" {
+			return fmt.Errorf("the token is empty")
+		}
+		if err := keyringSet(host, token); err != nil {
+			return err
+		}
+		fmt.Printf("Stored the token for %s in the OS keyring\n", host)
+	case "get":
+		token, err := keyringGet(host)
+		if err == errKeyringNotFound {
+			return fmt.Errorf("%v for %s; run fetch_pr_comments auth set", err, host)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+	case "delete":
+		if err := keyringDelete(host); err == errKeyringNotFound {
+			return fmt.Errorf("%v for %s", err, host)
+		} else if err != nil {
+			return err
+		}
+		fmt.Printf("Removed the token for %s from the OS keyring\n", host)
+	default:
+		return fmt.Errorf("unknown auth command %q (expected set, get or delete)", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"  // コマンドの終了コードの判定に使用
+	"fmt"     // エラーメッセージの作成に使用
+	"os/exec" // security・secret-tool コマンドの実行に使用
+	"runtime" // OSの判定に使用
+	"strings" // 出力の整形に使用
+)
+
+// keyringCommand はOSのキーリングを操作するコマンド（macOS は security、それ以外は secret-tool）を実行し、標準出力を返します。
+// コマンドがない場合はインストール方法を含むエラーを、失敗した場合は標準エラー出力の内容を含むエラーを返します。
+func keyringCommand(stdin string, args ...string) (string, error) {
+	name := "secret-tool"
+	if runtime.GOOS == "darwin" {
+		name = "security"
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		if name == "security" {
+			return "", keyringUnavailable("the security command was not found")
+		}
+		return "", keyringUnavailable("secret-tool was not found; install libsecret-tools (or libsecret) and run a Secret Service such as GNOME Keyring or KWallet")
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && len(strings.TrimSpace(string(ee.Stderr))) > 0 {
+		err = fmt.Errorf("%s: %s: %w", name, strings.TrimSpace(string(ee.Stderr)), err)
+	}
+	return strings.TrimSpace(string(out)), err
+}
+
+// keyringSet はOSのキーリングに、指定されたホストのトークンを保存します（既に保存されている場合は上書きします）。
+// トークンはコマンドライン引数ではなく標準入力で渡し、プロセス一覧に表示されないようにします。
+func keyringSet(host, token string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		// security -i は標準入力からコマンドを読み込む
+		_, err = keyringCommand(fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", keyringService, host, token), "-i")
+	case "plan9", "js", "wasip1":
+		return keyringUnavailable("no keyring backend is supported on " + runtime.GOOS)
+	default:
+		_, err = keyringCommand(token, "store", "--label=fetch_pr_comments token for "+host, "service", keyringService, "host", host)
+	}
+	if err != nil && !errors.Is(err, errNoKeyring) {
+		// Secret Service が起動していない場合など
+		return keyringUnavailable(err.Error())
+	}
+	return err
+}
+
+// keyringGet はOSのキーリングから、指定されたホストのトークンを読み込みます。
+// 保存されていない場合は errKeyringNotFound を返します。
+func keyringGet(host string) (string, error) {
+	var (
+		token string
+		err   error
+	)
+	switch runtime.GOOS {
+	case "darwin":
+		token, err = keyringCommand("", "find-generic-password", "-s", keyringService, "-a", host, "-w")
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && ee.ExitCode() == 44 {
+			// errSecItemNotFound
+			return "", errKeyringNotFound
+		}
+	case "plan9", "js", "wasip1":
+		return "", keyringUnavailable("no keyring backend is supported on " + runtime.GOOS)
+	default:
+		token, err = keyringCommand("", "lookup", "service", keyringService, "host", host)
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && len(ee.Stderr) == 0 {
+			// secret-tool lookup は見つからない場合に何も出力せず終了コード 1 を返す
+			return "", errKeyringNotFound
+		}
+	}
+	if err != nil && !errors.Is(err, errNoKeyring) {
+		return "", keyringUnavailable(err.Error())
+	}
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", errKeyringNotFound
+	}
+	return token, nil
+}
+
+// keyringDelete はOSのキーリングから、指定されたホストのトークンを削除します。
+// 保存されていない場合は errKeyringNotFound を返します。
+func keyringDelete(host string) error {
+	if _, err := keyringGet(host); err != nil {
+		return err
+	}
+	var err error
+	if runtime.GOOS == "darwin" {
+		_, err = keyringCommand("", "delete-generic-password", "-s", keyringService, "-a", host)
+	} else {
+		_, err = keyringCommand("", "clear", "service", keyringService, "host", host)
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall" // 資格情報マネージャーのAPI（advapi32.dll）の呼び出しに使用
+	"unsafe"  // APIに渡す構造体のポインタの変換に使用
+)
+
+// 資格情報マネージャーのAPIです。
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// 資格情報マネージャーのAPIで使う定数です。
+const (
+	credTypeGeneric         = 1    // CRED_TYPE_GENERIC
+	credPersistLocalMachine = 2    // CRED_PERSIST_LOCAL_MACHINE（ログオンセッションをまたいで保存する）
+	errorNotFound           = 1168 // ERROR_NOT_FOUND
+)
+
+// winCredential は資格情報マネージャーの CREDENTIALW 構造体です。
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credTarget は資格情報マネージャーに保存するときのターゲット名を返します（例: fetch_pr_comments:api.github.com）。
+func credTarget(host string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keyringService + ":" + host)
+}
+
+// credError は資格情報マネージャーのAPIのエラーを変換します。
+func credError(err error) error {
+	if errno, ok := err.(syscall.Errno); ok && errno == errorNotFound {
+		return errKeyringNotFound
+	}
+	return keyringUnavailable("Windows Credential Manager: " + err.Error())
+}
+
+// keyringSet は資格情報マネージャーに、指定されたホストのトークンを保存します（既に保存されている場合は上書きします）。
+func keyringSet(host, token string) error {
+	target, err := credTarget(host)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(host)
+	if err != nil {
+		return err
+	}
+	blob := []byte(token)
+	cred := winCredential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+// keyringGet は資格情報マネージャーから、指定されたホストのトークンを読み込みます。
+// 保存されていない場合は errKeyringNotFound を返します。
+func keyringGet(host string) (string, error) {
+	target, err := credTarget(host)
+	if err != nil {
+		return "", err
+	}
+	var cred *winCredential
+	if r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); r == 0 {
+		return "", credError(err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", errKeyringNotFound
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+// keyringDelete は資格情報マネージャーから、指定されたホストのトークンを削除します。
+// 保存されていない場合は errKeyringNotFound を返します。
+func keyringDelete(host string) error {
+	target, err := credTarget(host)
+	if err != nil {
+		return err
+	}
+	if r, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		return credError(err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings" // 文字列操作に使用
+	"unicode" // 文字の種類の判定に使用
+)
+
+// 言語判定の結果の定数です。
+const (
+	langJapanese = "ja"      // 日本語
+	langEnglish  = "en"      // 英語
+	langUnknown  = "unknown" // 文字数が少なく判定できない
+)
+
+// minLangLetters は言語を判定するのに必要な最小の文字数です。これより短い本文は "unknown" とします。
+const minLangLetters = 5
+
+// detectLang はコメント本文の言語をひらがな・カタカナ・漢字とラテン文字の文字数の多い方で判定します。
+// コードブロック・インラインコード・URLは英語に偏るため数えません。
+//
+// パラメータ:
+//   - body: コメント本文
+//
+// 戻り値:
+//   - string: "ja"、"en"、または文字数が少ない場合は "unknown"
+func detectLang(body string) string {
+	var prose strings.Builder
+	mapMarkdownText(body, func(text string) string {
+		prose.WriteString(text)
+		prose.WriteByte(' ')
+		return text
+	})
+	text := mediaPattern.ReplaceAllString(prose.String(), " ")
+
+	japanese, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han):
+			japanese++
+		case unicode.In(r, unicode.Latin) && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	switch {
+	case japanese+latin < minLangLetters:
+		return langUnknown
+	case japanese >= latin:
+		return langJapanese
+	}
+	return langEnglish
+}
+
+// annotateLang は各コメントに本文の言語を設定します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func annotateLang(comments []Comment) {
+	for i := range comments {
+		comments[i].Lang = detectLang(comments[i].Body)
+	}
+}
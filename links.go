@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"     // フォーマット済み入出力に使用
+	"path"    // 相対リンクのパスの正規化に使用
+	"regexp"  // 参照表記の検出に使用
+	"strings" // 文字列操作に使用
+)
+
+// リンク書き換えモードの定数です。
+const (
+	rewriteLinksAuto   = "auto"   // Markdown/HTML出力の場合のみ書き換える（デフォルト）
+	rewriteLinksAlways = "always" // すべての出力形式で書き換える
+	rewriteLinksNever  = "never"  // 書き換えない
+)
+
+// shouldRewriteLinks はリンク書き換えモードと出力形式から、書き換えを行うかどうかを判定します。
+func shouldRewriteLinks(mode, format string) bool {
+	switch mode {
+	case rewriteLinksAlways:
+		return true
+	case rewriteLinksAuto:
+		return format == formatMarkdown || format == formatHTML
+	}
+	return false
+}
+
+// referencePattern は書き換え対象の参照表記にマッチします。
+// 既存のリンクやURLの中身を書き換えないよう、それらは先頭のグループで丸ごとマッチさせて読み飛ばします。
+// RE2には後読みがないため、直前の1文字をグループとして取り込み境界を判定します。
+var referencePattern = regexp.MustCompile(
+	`(\[[^\]]*\]\([^)]*\)|<[^>\s]+>|https?://[^\s<>()]+)` + // 1: 既存のリンク・自動リンク・URL（そのまま残す）
+		`|(^|[^\w@/.\-])([\w.\-]+/[\w.\-]+)#(\d+)\b` + // 2,3,4: owner/repo#N
+		`|(^|[^\w&/#])#(\d+)\b` + // 5,6: #N
+		`|(^|[^\w.+\-/])@([A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9]){0,38})\b` + // 7,8: @user
+		`|(^|[^\w/])([0-9a-f]{7,40})\b`) // 9,10: コミットSHA
+
+// rewriteLinks はコメント本文中の #N、owner/repo#N、コミットSHA、@user と、
+// ファイルへの相対リンク（[text](src/a.go) など）を絶対URLのリンクに書き換えます。
+// フェンスドコードブロックとインラインコードの中は書き換えません。
+//
+// パラメータ:
+//   - body: コメント本文（Markdown）
+//   - owner: 参照元リポジトリのオーナー名（#N の解決に使用）
+//   - repo: 参照元リポジトリ名
+//   - sha: 相対リンクの解決に使うコミットのSHA（空の場合は相対リンクを書き換えない）
+//   - linkMentions: @user をプロフィールへのリンクにするか（--strip-mentions で置き換えた名前は実在のユーザーではないためリンクしない）
+//
+// 戻り値:
+//   - string: 書き換え後の本文
+func rewriteLinks(body, owner, repo, sha string, linkMentions bool) string {
+	return mapMarkdownText(body, func(text string) string {
+		return rewriteReferences(text, owner, repo, sha, linkMentions)
+	})
+}
+
+// rewriteReferences はコード以外のテキスト断片に含まれる参照表記をリンクに書き換えます。
+func rewriteReferences(text, owner, repo, sha string, linkMentions bool) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range referencePattern.FindAllStringSubmatchIndex(text, -1) {
+		group := func(n int) string {
+			if m[2*n] < 0 {
+				return ""
+			}
+			return text[m[2*n]:m[2*n+1]]
+		}
+		b.WriteString(text[last:m[0]])
+		last = m[1]
+
+		switch {
+		case m[2] >= 0: // 既存のリンク・URLはそのまま（ファイルへの相対リンクだけ書き換える）
+			b.WriteString(rewriteRelativeLink(group(1), text[:m[0]], owner, repo, sha))
+		case m[6] >= 0: // owner/repo#N
+			fmt.Fprintf(&b, "%s[%s#%s](%s/%s/issues/%s)", group(2), group(3), group(4), webURL(), group(3), group(4))
+		case m[12] >= 0: // #N
+			fmt.Fprintf(&b, "%s[#%s](%s/%s/%s/issues/%s)", group(5), group(6), webURL(), owner, repo, group(6))
+		case m[16] >= 0 && !linkMentions: // @user（リンクにしない）
+			b.WriteString(text[m[0]:m[1]])
+		case m[16] >= 0: // @user
+			fmt.Fprintf(&b, "%s[@%s](%s/%s)", group(7), group(8), webURL(), group(8))
+		case m[20] >= 0: // コミットSHA（英字と数字の両方を含むものだけを対象にし、英単語や数値を誤検出しない）
+			ref := group(10)
+			if strings.ContainsAny(ref, "0123456789") && strings.ContainsAny(ref, "abcdef") {
+				fmt.Fprintf(&b, "%s[%s](%s/%s/%s/commit/%s)", group(9), shortSHA(ref), webURL(), owner, repo, ref)
+			} else {
+				b.WriteString(text[m[0]:m[1]])
+			}
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// rewriteRelativeLink は Markdown のリンク [text](path) のリンク先がリポジトリ内のファイルへの相対パスであれば、
+// コミット sha 時点のファイルを指す https://<host>/<owner>/<repo>/blob/<sha>/<path> に書き換えます。
+// URL・絶対パス・アンカー（#...）・mailto: などのスキームを持つリンク先、リポジトリの外を指すパス、
+// 画像（![alt](path)）、自動リンク・URLはそのまま返します。
+//
+// パラメータ:
+//   - link: referencePattern の先頭のグループにマッチした文字列
+//   - before: link より前のテキスト（画像の判定に使用）
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - sha: 相対パスを解決するコミットのSHA（空の場合は書き換えない）
+//
+// 戻り値:
+//   - string: 書き換え後のリンク
+func rewriteRelativeLink(link, before, owner, repo, sha string) string {
+	if sha == "" || !strings.HasPrefix(link, "[") || strings.HasSuffix(before, "!") {
+		return link
+	}
+	open := strings.Index(link, "](")
+	if open < 0 {
+		return link
+	}
+	target := link[open+2 : len(link)-1]
+	// リンク先の後ろにタイトル（[text](path "title")）があれば残す
+	title := ""
+	if i := strings.IndexAny(target, " \t"); i >= 0 {
+		target, title = target[:i], target[i:]
+	}
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") {
+		return link
+	}
+	// スキーム（https: や mailto: など）を持つリンク先は相対パスではない
+	if i := strings.IndexAny(target, ":/?#"); i >= 0 && target[i] == ':' {
+		return link
+	}
+	// 行番号などのフラグメントとクエリは正規化の対象から外して残す
+	suffix := ""
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target, suffix = target[:i], target[i:]
+	}
+	clean := path.Clean(target)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return link
+	}
+	return fmt.Sprintf("%s(%s/%s/%s/blob/%s/%s%s%s)", link[:open+1], webURL(), owner, repo, sha, clean, suffix, title)
+}
+
+// shortSHA はコミットSHAを表示用に7文字へ短縮します。
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// mapMarkdownText はMarkdownのうちコード以外のテキスト部分にだけ関数 fn を適用します。
+// フェンスドコードブロック（``` と ~~~）とインラインコード（`code`）はそのまま残します。
+//
+// パラメータ:
+//   - body: Markdownの本文
+//   - fn: コード以外のテキスト断片に適用する関数
+//
+// 戻り値:
+//   - string: 変換後の本文
+func mapMarkdownText(body string, fn func(string) string) string {
+	lines := strings.SplitAfter(body, "\n")
+	var b strings.Builder
+	fenceChar, fenceLen := byte(0), 0 // 現在開いているフェンスの文字と長さ（開いていなければ0）
+	for _, line := range lines {
+		if char, n, ok := fenceMarker(line); ok {
+			switch {
+			case fenceLen == 0:
+				// フェンスの開始
+				fenceChar, fenceLen = char, n
+				b.WriteString(line)
+				continue
+			case char == fenceChar && n >= fenceLen && strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), string(char))) == "":
+				// 同じ文字で同じ長さ以上のフェンスが閉じる（情報文字列は付けられない）
+				fenceChar, fenceLen = 0, 0
+				b.WriteString(line)
+				continue
+			}
+		}
+		if fenceLen > 0 {
+			// コードブロックの中はそのまま
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(mapInlineText(line, fn))
+	}
+	return b.String()
+}
+
+// fenceMarker は行がフェンス（3つ以上の ` または ~、先頭のインデントは3文字まで）で始まるかを判定します。
+//
+// 戻り値:
+//   - byte: フェンスの文字
+//   - int: フェンスの長さ
+//   - bool: フェンス行かどうか
+func fenceMarker(line string) (byte, int, bool) {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 {
+		return 0, 0, false
+	}
+	rest := line[indent:]
+	if rest == "" || (rest[0] != '`' && rest[0] != '~') {
+		return 0, 0, false
+	}
+	char := rest[0]
+	n := len(rest) - len(strings.TrimLeft(rest, string(char)))
+	if n < 3 {
+		return 0, 0, false
+	}
+	// バッククォートのフェンスは情報文字列にバッククォートを含められない
+	if char == '`' && strings.Contains(rest[n:], "`") {
+		return 0, 0, false
+	}
+	return char, n, true
+}
+
+// mapInlineText は1行のうちインラインコード以外の部分に fn を適用します。
+// n個のバッククォートで始まるコードは、同じ個数のバッククォートで閉じられた場合のみコードとみなします。
+// 閉じられていないバッククォートは通常の文字として扱います。
+func mapInlineText(line string, fn func(string) string) string {
+	var b strings.Builder
+	textStart := 0
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		// バッククォートの連続数を数える
+		n := 0
+		for i+n < len(line) && line[i+n] == '`' {
+			n++
+		}
+		end := findBacktickRun(line, i+n, n)
+		if end < 0 {
+			// 閉じられていないので通常の文字として扱う
+			i += n
+			continue
+		}
+		b.WriteString(fn(line[textStart:i]))
+		b.WriteString(line[i : end+n])
+		i = end + n
+		textStart = i
+	}
+	b.WriteString(fn(line[textStart:]))
+	return b.String()
+}
+
+// findBacktickRun は from 以降で、ちょうど n 個連続したバッククォートの開始位置を返します。見つからなければ-1を返します。
+func findBacktickRun(s string, from, n int) int {
+	for i := from; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(s) && s[i+run] == '`' {
+			run++
+		}
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings" // 変換の目印に使用
+	"testing" // テストの実行に使用
+)
+
+// TestMapMarkdownText はフェンスドコードブロック（``` と ~~~）の中には関数を適用しないことを確認します（変換は大文字化）。
+func TestMapMarkdownText(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"no code", "fix #1\nand #2", "FIX #1\nAND #2"},
+		{"backtick fence", "a\n```go\nx := a\n```\nb", "A\n```go\nx := a\n```\nB"},
+		{"tilde fence", "a\n~~~\nx\n~~~\nb", "A\n~~~\nx\n~~~\nB"},
+		{"longer fence contains a shorter one", "````\n```\nx\n```\n````\nb", "````\n```\nx\n```\n````\nB"},
+		{"tilde fence is not closed by backticks", "~~~\nx\n```\ny\n~~~\nb", "~~~\nx\n```\ny\n~~~\nB"},
+		{"closing fence may be longer", "```\nx\n`````\nb", "```\nx\n`````\nB"},
+		{"closing fence cannot have an info string", "```\nx\n``` go\ny\n```\nb", "```\nx\n``` go\ny\n```\nB"},
+		{"unclosed fence runs to the end", "a\n```\nx\ny", "A\n```\nx\ny"},
+		{"indented up to three spaces", "   ```\nx\n   ```\nb", "   ```\nx\n   ```\nB"},
+		{"four spaces is not a fence", "    ```\nx", "    ```\nX"},
+		{"two backticks is not a fence", "``\nx", "``\nX"},
+		{"backtick info string with a backtick is not a fence", "```a`b\nx", "```A`B\nX"},
+		{"suggestion block", "see\n```suggestion\nfoo()\n```\n", "SEE\n```suggestion\nfoo()\n```\n"},
+		{"crlf", "a\r\n```\r\nx\r\n```\r\nb", "A\r\n```\r\nx\r\n```\r\nB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapMarkdownText(tt.body, strings.ToUpper); got != tt.want {
+				t.Errorf("mapMarkdownText(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapInlineText は同じ個数のバッククォートで閉じられたインラインコードの中には関数を適用しないことを確認します。
+func TestMapInlineText(t *testing.T) {
+	tests := []struct {
+		name, line, want string
+	}{
+		{"single", "use `foo` here", "USE `foo` HERE"},
+		{"double with a backtick inside", "a ``x ` y`` b", "A ``x ` y`` B"},
+		{"triple", "a ```x `` y``` b", "A ```x `` y``` B"},
+		{"different lengths do not close", "a ``x` b", "A ``X` B"},
+		{"unclosed", "a `b", "A `B"},
+		{"adjacent spans", "`a``b`c", "`a``b`C"},
+		{"two spans", "x `a` y `b` z", "X `a` Y `b` Z"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapInlineText(tt.line, strings.ToUpper); got != tt.want {
+				t.Errorf("mapInlineText(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRewriteLinks は参照表記の種類ごとの書き換えと、書き換えない場合を確認します。
+func TestRewriteLinks(t *testing.T) {
+	const sha = "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d"
+	tests := []struct {
+		name, body, want string
+		linkMentions     bool
+	}{
+		{"#N", "see #12.", "see [#12](https://github.com/o/r/issues/12).", true},
+		{"#N at the start", "#3 fixes it", "[#3](https://github.com/o/r/issues/3) fixes it", true},
+		{"owner/repo#N", "dup of acme/api#7", "dup of [acme/api#7](https://github.com/acme/api/issues/7)", true},
+		{"full SHA", "fixed in " + sha, "fixed in [1a2b3c4](https://github.com/o/r/commit/" + sha + ")", true},
+		{"short SHA", "see 1a2b3c4", "see [1a2b3c4](https://github.com/o/r/commit/1a2b3c4)", true},
+		{"hex word is not a SHA", "deadbeef and 1234567", "deadbeef and 1234567", true},
+		{"@user", "thanks @alice-b!", "thanks [@alice-b](https://github.com/alice-b)!", true},
+		{"@user without mention links", "thanks @alice", "thanks @alice", false},
+		{"email is not a mention", "mail a.b@example.com", "mail a.b@example.com", true},
+		{"HTML entity is not #N", "it&#39;s", "it&#39;s", true},
+		{"URL fragment is not #N", "https://example.com/page#12", "https://example.com/page#12", true},
+		{"existing link", "[#5](https://x.test/5) and <https://x.test/#6>", "[#5](https://x.test/5) and <https://x.test/#6>", true},
+		{"anchor word is not #N", "a#1", "a#1", true},
+		{"inline code", "run `git show 1a2b3c4` for #2", "run `git show 1a2b3c4` for [#2](https://github.com/o/r/issues/2)", true},
+		{"fenced code", "```\n#1 @bob\n```\n#1", "```\n#1 @bob\n```\n[#1](https://github.com/o/r/issues/1)", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteLinks(tt.body, "o", "r", "", tt.linkMentions); got != tt.want {
+				t.Errorf("rewriteLinks(%q) =\n  %q\nwant\n  %q", tt.body, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("enterprise", func(t *testing.T) {
+		withAPIBaseURL(t, "https://github.example.com/api/v3")
+		want := "[#4](https://github.example.com/o/r/issues/4) [@bob](https://github.example.com/bob)"
+		if got := rewriteLinks("#4 @bob", "o", "r", "", true); got != want {
+			t.Errorf("rewriteLinks on GitHub Enterprise Server = %q, want %q", got, want)
+		}
+	})
+}
+
+// TestRewriteRelativeLinks はファイルへの相対リンクがコメントのコミットの blob URL に書き換わり、
+// それ以外のリンク先はそのまま残ることを確認します。
+func TestRewriteRelativeLinks(t *testing.T) {
+	const sha = "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d"
+	const blob = "https://github.com/o/r/blob/" + sha + "/"
+	tests := []struct{ name, body, want string }{
+		{"relative path", "see [a.go](src/a.go)", "see [a.go](" + blob + "src/a.go)"},
+		{"dot slash", "[doc](./docs/../README.md)", "[doc](" + blob + "README.md)"},
+		{"line fragment and title", `[L3](src/a.go#L3 "line 3")`, `[L3](` + blob + `src/a.go#L3 "line 3")`},
+		{"URL", "[x](https://example.com/a)", "[x](https://example.com/a)"},
+		{"absolute path", "[x](/o/r/pull/1)", "[x](/o/r/pull/1)"},
+		{"anchor", "[x](#usage)", "[x](#usage)"},
+		{"mailto", "[x](mailto:a@example.com)", "[x](mailto:a@example.com)"},
+		{"outside the repository", "[x](../other/a.go)", "[x](../other/a.go)"},
+		{"image", "![shot](img/a.png)", "![shot](img/a.png)"},
+		{"inline code", "`[a](src/a.go)`", "`[a](src/a.go)`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteLinks(tt.body, "o", "r", sha, true); got != tt.want {
+				t.Errorf("rewriteLinks(%q) =\n  %q\nwant\n  %q", tt.body, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("without a commit", func(t *testing.T) {
+		if got := rewriteLinks("[a.go](src/a.go)", "o", "r", "", true); got != "[a.go](src/a.go)" {
+			t.Errorf("a relative link was rewritten without a commit SHA: %q", got)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"  // JSON形式の出力に使用
+	"fmt"            // フォーマット済み入出力に使用
+	"io"             // 出力先のインタフェースに使用
+	"strings"        // ラベル名の連結に使用
+	"text/tabwriter" // 表の列をそろえるために使用
+)
+
+// prListEntry は --list-prs のJSON出力の1件分の情報を表す構造体です。
+type prListEntry struct {
+	Number   int      `json:"number"`    // プルリクエスト番号
+	Title    string   `json:"title"`     // タイトル
+	Author   string   `json:"author"`    // 作成者
+	MergedAt *string  `json:"merged_at"` // マージされた日時（マージされていない場合はnull）
+	Labels   []string `json:"labels"`    // 付与されているラベル
+}
+
+// writePRList は選択されたPRの一覧を書き込みます（--list-prs 用）。
+// JSON形式の場合はJSONの配列、それ以外の場合は列をそろえた表として書き込みます。
+//
+// パラメータ:
+//   - w: 書き込み先（通常は標準出力）
+//   - prs: 選択されたプルリクエスト
+//   - format: 出力形式（json の場合のみJSON、それ以外は表）
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func writePRList(w io.Writer, prs []PullRequest, format string) error {
+	if format == formatJSON {
+		entries := make([]prListEntry, 0, len(prs))
+		for _, pr := range prs {
+			entries = append(entries, prListEntry{
+				Number:   pr.Number,
+				Title:    pr.Title,
+				Author:   pr.User.Login,
+				MergedAt: pr.MergedAt,
+				Labels:   pr.LabelNames(),
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NUMBER\tTITLE\tAUTHOR\tMERGED_AT\tLABELS")
+	for _, pr := range prs {
+		merged := "-"
+		if pr.MergedAt != nil {
+			merged = *pr.MergedAt
+		}
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%s\n", pr.Number, pr.Title, pr.User.Login, merged, strings.Join(pr.LabelNames(), ", "))
+	}
+	return tw.Flush()
+}
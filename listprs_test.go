@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings" // 出力の確認に使用
+	"testing" // テストの実行に使用
+)
+
+// TestListPRsAnonymize は --list-prs と --anonymize を併用した場合に、表とJSONのどちらでもPRの作成者を仮名で表示することを確認します。
+func TestListPRsAnonymize(t *testing.T) {
+	server := newFakeGitHub(t, []map[string]interface{}{fakePR(2, "2024-05-02T00:00:00Z"), fakePR(1, "2024-05-01T00:00:00Z")}, nil)
+	alias := newAnonymizer("k").pseudonym("alice")
+	for _, format := range []string{formatText, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			code, out := runTool(t, t.TempDir(), "--owner", "o", "--repo", "r", "--count", "2", "--no-cache", "--token", "t", "--api-url", server.apiURL(),
+				"--list-prs", "--format", format, "--anonymize", "--anonymize-key", "k")
+			if code != 0 {
+				t.Fatalf("exited with %d:\n%s", code, out)
+			}
+			if strings.Contains(out, "alice") {
+				t.Errorf("the real login was printed:\n%s", out)
+			}
+			if strings.Count(out, alias) != 2 {
+				t.Errorf("the pseudonym %s is not shown for both PRs:\n%s", alias, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json" // 保存したトークンとAPIのレスポンスのエンコード・デコードに使用
+	"fmt"           // フォーマット済み入出力に使用
+	"net/http"      // HTTPクライアントの実装を提供
+	"net/url"       // フォームの値のエンコードに使用
+	"os"            // 保存したトークンのファイル操作に使用
+	"path/filepath" // 保存先のパスの組み立てに使用
+	"strings"       // リクエストの本文の作成に使用
+	"time"          // 認可を待つ間隔の管理に使用
+)
+
+// oauthScopes は --login で要求するスコープです（プライベートリポジトリのPRとチームのメンバーの読み込みに必要）。
+const oauthScopes = "repo read:org"
+
+// storedTokensFile は --login で取得したトークンを保存するファイルのパスを返します（OSごとのユーザー設定ディレクトリの下）。
+func storedTokensFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "fetch_pr_comments", "tokens.json"), nil
+}
+
+// loadStoredTokens は --login で保存したトークン（ホスト名 → トークン）を読み込みます。ファイルがない場合は空のマップを返します。
+func loadStoredTokens() (map[string]string, error) {
+	tokens := make(map[string]string)
+	path, err := storedTokensFile()
+	if err != nil {
+		return tokens, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return tokens, err
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return tokens, fmt.Errorf("%s: %v", path, err)
+	}
+	return tokens, nil
+}
+
+// saveStoredTokens は保存するトークンを書き込みます。本人だけが読めるよう、ファイルは 0600、ディレクトリは 0700 で作成します。
+// 保存するトークンがなくなった場合はファイルを削除します。
+func saveStoredTokens(tokens map[string]string) (string, error) {
+	path, err := storedTokensFile()
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return path, err
+		}
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return path, fmt.Errorf("failed to create directory: %v", err)
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return path, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return path, err
+	}
+	// 既存のファイルの権限が広い場合も 0600 にする
+	return path, os.Chmod(path, 0600)
+}
+
+// storedToken は --login で保存した、指定されたホストのトークンを返します。保存されていない場合は空文字を返します。
+func storedToken(host string) string {
+	tokens, err := loadStoredTokens()
+	if err != nil {
+		return ""
+	}
+	return tokens[host]
+}
+
+// postForm はOAuthのエンドポイントにフォームを送り、JSONのレスポンスをデコードします。
+func postForm(client *http.Client, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// deviceLogin はGitHubのOAuthデバイスフローでトークンを取得します。
+// ユーザーコードと認可用のURLを表示し、ユーザーがブラウザで認可するまでポーリングします。
+// slow_down の場合は間隔を広げて待ち続け、expired_token・access_denied の場合はエラーを返します。
+//
+// パラメータ:
+//   - clientID: デバイスフローを有効にしたOAuth Appのクライアント ID
+//
+// 戻り値:
+//   - string: 取得したトークン
+//   - error: 認可されなかった場合や通信に失敗した場合のエラー情報
+func deviceLogin(clientID string) (string, error) {
+	client := apiClient
+	var code struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+		Error           string `json:"error"`
+		ErrorDesc       string `json:"error_description"`
+	}
+	if err := postForm(client, webURL()+"/login/device/code", url.Values{"client_id": {clientID}, "scope": {oauthScopes}}, &code); err != nil {
+		return "", err
+	}
+	if code.Error != "" {
+		return "", fmt.Errorf("device flow: %s: %s", code.Error, code.ErrorDesc)
+	}
+	fmt.Printf("First copy your one-time code: %s\n", code.UserCode)
+	fmt.Printf("Then open %s in your browser and enter the code. Waiting for authorization...\n", code.VerificationURI)
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for {
+		time.Sleep(interval)
+		var result struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Interval    int    `json:"interval"`
+		}
+		form := url.Values{"client_id": {clientID}, "device_code": {code.DeviceCode}, "grant_type": {"urn:ietf:params:oauth:grant-type:device_code"}}
+		if err := postForm(client, webURL()+"/login/oauth/access_token", form, &result); err != nil {
+			return "", err
+		}
+		switch result.Error {
+		case "":
+			if result.AccessToken == "" {
+				return "", fmt.Errorf("device flow returned no access token")
+			}
+			return result.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			// 指定された間隔（なければ5秒延ばした間隔）でポーリングを続ける
+			if result.Interval > 0 {
+				interval = time.Duration(result.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+		case "expired_token":
+			return "", fmt.Errorf("the code %s expired before it was authorized; run --login again", code.UserCode)
+		case "access_denied":
+			return "", fmt.Errorf("authorization was denied")
+		default:
+			return "", fmt.Errorf("device flow: %s", result.Error)
+		}
+		if code.ExpiresIn > 0 && time.Now().After(deadline) {
+			return "", fmt.Errorf("the code %s expired before it was authorized; run --login again", code.UserCode)
+		}
+	}
+}
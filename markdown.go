@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt" // フォーマット済み入出力に使用
+	"io"  // 入出力の基本インタフェースを提供
+)
+
+// writeMarkdownReport はPRごとのセクションをMarkdownレポートとして書き込みます。
+// コメント本文はMarkdownのまま埋め込むため、GitHubやWikiに貼り付けるとそのまま表示できます。
+//
+// パラメータ:
+//   - w: 書き込み先
+//   - title: レポートのタイトル
+//   - sections: 表示するPRとコメントのセクション
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func writeMarkdownReport(w io.Writer, title string, sections []prSection) error {
+	if _, err := fmt.Fprintf(w, "# %s\n\n", title); err != nil {
+		return err
+	}
+	for _, s := range sections {
+		if err := writeMarkdownSection(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMarkdownSection は1つのPRの見出しとコメントをMarkdownで書き込みます。
+func writeMarkdownSection(w io.Writer, s prSection) error {
+	// PRの見出し
+	_, err := fmt.Fprintf(w, "## %sPR #%d by %s%s%s%s%s\n\n- Requested reviewers: %s\n- Assignees: %s\n\n",
+		repositoryPrefix(s.PR), s.PR.Number, s.PR.User.Login, labelSuffix(s.PR), sizeSuffix(s.PR), stateSuffix(s.PR), truncationSuffix(s.PR), joinOrNone(userLogins(s.PR.RequestedReviewers)), joinOrNone(userLogins(s.PR.Assignees)))
+	if err != nil {
+		return err
+	}
+	// 各コメント
+	for _, c := range s.Comments {
+		if _, err := fmt.Fprintf(w, "### %s%s%s (%s)\n\n%s\n\n---\n\n", categoryPrefix(c), c.AuthorLogin(), authorSuffix(c), displayCreatedAt(c), parentContext(c)+c.Body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
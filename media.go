@@ -0,0 +1,29 @@
+package main
+
+import (
+	"regexp"  // 画像とURLの検出に使用
+	"strings" // 文字列操作に使用
+)
+
+// mediaPattern は画像のMarkdown・imgタグ・自動リンク・URLだけのリンク・裸のURLにマッチします。
+var mediaPattern = regexp.MustCompile(
+	`!\[[^\]]*\]\([^)]*\)` + // Markdownの画像
+		`|(?is)<img\s[^>]*>` + // HTMLの画像
+		`|<https?://[^>\s]+>` + // 自動リンク
+		`|\[\s*https?://[^\]\s]+\s*\]\([^)]*\)` + // リンクテキストがURLそのもののリンク
+		`|https?://[^\s<>()]+`) // 裸のURL
+
+// isMediaOnly は本文が画像やURLだけでできているかを判定します。
+// 画像・URLを取り除いた後に空白しか残らない場合に true を返します（画像やURLを1つも含まない空の本文は対象外）。
+//
+// パラメータ:
+//   - body: コメント本文
+//
+// 戻り値:
+//   - bool: 画像やURLだけのコメントの場合はtrue
+func isMediaOnly(body string) bool {
+	if !mediaPattern.MatchString(body) {
+		return false
+	}
+	return strings.TrimSpace(mediaPattern.ReplaceAllString(body, "")) == ""
+}
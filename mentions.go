@@ -0,0 +1,70 @@
+package main
+
+import (
+	"regexp"  // @メンションの検出に使用
+	"strings" // 文字列操作に使用
+)
+
+// mentionPlaceholder は --strip-mentions で @メンションのユーザー名を置き換える中立的な名前です（"@reviewer" になります）。
+const mentionPlaceholder = "reviewer"
+
+// teamMentionPlaceholder は @org/team のチームメンションを丸ごと置き換える名前です（"@team" になります）。
+// 組織名もチーム名も残さないよう、仮名に置き換える場合もこの名前を使います。
+const teamMentionPlaceholder = "team"
+
+// mentionPattern は @メンションにマッチします。
+// URLやメールアドレスの一部を書き換えないよう、URLは先頭のグループで丸ごとマッチさせて読み飛ばし、
+// @ の直前が英数字や "." などの場合（user@example.com など）はメンションとみなしません。
+var mentionPattern = regexp.MustCompile(
+	`(https?://[^\s<>()]+)` + // 1: URL（そのまま残す）
+		`|(^|[^\w.+\-/@])@([A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9]){0,38})` + // 2,3: @user
+		`(/[A-Za-z0-9](?:[\w.\-]*[A-Za-z0-9])?)?\b`) // 4: @org/team の /team
+
+// rewriteMentions は本文中の @メンションを置き換えます。コードブロック・インラインコード・メールアドレスの中は書き換えません。
+// @org/team のチームメンションは、replace を使わずに全体を @team に置き換えます。
+//
+// パラメータ:
+//   - body: コメント本文（Markdown）
+//   - replace: ユーザー名から置き換え後の名前（"@" を含まない）を返す関数
+//
+// 戻り値:
+//   - string: 置き換え後の本文
+func rewriteMentions(body string, replace func(login string) string) string {
+	return mapMarkdownText(body, func(text string) string {
+		var b strings.Builder
+		last := 0
+		for _, m := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+			if m[2] >= 0 {
+				// URLはそのまま残す
+				continue
+			}
+			// 直前の1文字と "@" を残し、ユーザー名の部分（チームの場合は組織名とチーム名）だけを置き換える
+			b.WriteString(text[last:m[6]])
+			if m[8] >= 0 {
+				b.WriteString(teamMentionPlaceholder)
+			} else {
+				b.WriteString(replace(text[m[6]:m[7]]))
+			}
+			last = m[1]
+		}
+		b.WriteString(text[last:])
+		return b.String()
+	})
+}
+
+// stripMentions は各コメントの本文（と返信先の抜粋）の @メンションを置き換えます。
+// 置き換えたコメントはレンダリング済みHTMLを破棄し、HTML出力でも置き換え後の本文が表示されるようにします。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+//   - replace: ユーザー名から置き換え後の名前を返す関数
+func stripMentions(comments []Comment, replace func(login string) string) {
+	for i := range comments {
+		body := rewriteMentions(comments[i].Body, replace)
+		if body != comments[i].Body {
+			comments[i].Body = body
+			comments[i].BodyHTML = ""
+		}
+		comments[i].ParentSnippet = rewriteMentions(comments[i].ParentSnippet, replace)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings" // 置き換え後の名前の作成に使用
+	"testing" // テストの実行に使用
+)
+
+// TestRewriteMentions は行頭・文中・括弧内の @メンションを置き換え、コード・メールアドレス・URLの中は残し、
+// @org/team は組織名もチーム名も残さずに置き換えることを確認します。
+func TestRewriteMentions(t *testing.T) {
+	upper := func(login string) string { return "P-" + strings.ToUpper(login) }
+	tests := []struct {
+		name, body, want string
+	}{
+		{"start of line", "@alice please check", "@P-ALICE please check"},
+		{"start of later line", "LGTM\n@alice thoughts?", "LGTM\n@P-ALICE thoughts?"},
+		{"mid sentence", "thanks @bob-smith, fixed", "thanks @P-BOB-SMITH, fixed"},
+		{"several", "@a and @b", "@P-A and @P-B"},
+		{"parentheses", "(cc @alice)", "(cc @P-ALICE)"},
+		{"brackets and quotes", `[@alice] "@bob"`, `[@P-ALICE] "@P-BOB"`},
+		{"end of sentence", "ask @alice.", "ask @P-ALICE."},
+		{"underscore after name", "@alice_x", "@alice_x"},
+		{"inline code", "use `@alice` here, @bob", "use `@alice` here, @P-BOB"},
+		{"code block", "```\n@alice\n```\n@bob", "```\n@alice\n```\n@P-BOB"},
+		{"email", "mail alice@example.com or a.b@example.com", "mail alice@example.com or a.b@example.com"},
+		{"email-like handle", "user+tag@example.com", "user+tag@example.com"},
+		{"url", "see https://github.com/@alice and https://example.com/x@y", "see https://github.com/@alice and https://example.com/x@y"},
+		{"path", "foo/@alice", "foo/@alice"},
+		{"double at", "@@alice", "@@alice"},
+		{"team", "cc @acme/platform-team please", "cc @team please"},
+		{"team in parentheses", "(@acme/core)", "(@team)"},
+		{"team at end of sentence", "ping @acme/core.", "ping @team."},
+		{"team with dots", "@acme/web.frontend", "@team"},
+		{"slash without team", "@acme/ is the org", "@P-ACME/ is the org"},
+		{"team in code", "`@acme/core`", "`@acme/core`"},
+		{"too long", "@" + strings.Repeat("a", 40), "@" + strings.Repeat("a", 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteMentions(tt.body, upper); got != tt.want {
+				t.Errorf("rewriteMentions(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStripMentions は本文と返信先の抜粋の両方を置き換え、本文を置き換えたコメントだけレンダリング済みHTMLを破棄することを確認します。
+func TestStripMentions(t *testing.T) {
+	comments := []Comment{
+		{Body: "@alice fix", BodyHTML: "<p>@alice fix</p>", ParentSnippet: "@bob said"},
+		{Body: "no mentions", BodyHTML: "<p>no mentions</p>"},
+	}
+	stripMentions(comments, func(string) string { return mentionPlaceholder })
+	if comments[0].Body != "@reviewer fix" || comments[0].BodyHTML != "" || comments[0].ParentSnippet != "@reviewer said" {
+		t.Errorf("first comment = %+v", comments[0])
+	}
+	if comments[1].BodyHTML == "" {
+		t.Error("the HTML of a comment without mentions was dropped")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"         // PRごとにまとめて書き込むためのバッファーに使用
+	"encoding/csv"  // CSV形式の追記に使用
+	"encoding/json" // JSON形式の追記に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"html/template" // 打ち切った理由のエスケープに使用
+	"os"            // ファイル操作に使用
+	"strings"       // 区切り線の作成に使用
+)
+
+// mergedWriter は --merge のまとめたファイルに、PRごとのコメントを取得した順に追記します。
+// すべてのコメントをメモリにためずに済み、実行が途中で止まった場合も、それまでのPRのコメントはファイルに残ります。
+// 最初のPRを追記するときにファイルを作成するため、コメントが1件もない場合はファイルを作成しません。
+type mergedWriter struct {
+	dir     string        // 保存先ディレクトリ
+	path    string        // 書き込むファイルのパス
+	title   string        // HTML・Markdownの見出し
+	format  string        // 出力形式（text/json/csv/html/markdown）
+	f       *os.File      // 書き込み中のファイル（まだ作成していない場合はnil）
+	w       *bufio.Writer // ファイルへのバッファー（PRごとにフラッシュする）
+	csv     *csv.Writer   // CSV形式の場合の書き込み先
+	records int           // 書き込んだコメントの数
+	prs     int           // 書き込んだPRの数
+}
+
+// newMergedWriter は --merge のまとめたファイルに追記する mergedWriter を作成します（ファイルは最初の追記で作成します）。
+func newMergedWriter(owner, repo, format string) *mergedWriter {
+	return &mergedWriter{
+		dir:    commentsDir(owner, repo),
+		path:   mergedCommentsFile(owner, repo, format),
+		title:  fmt.Sprintf("%s/%s review comments", owner, repo),
+		format: format,
+	}
+}
+
+// open はファイルを作成し、形式ごとの先頭部分（見出し・CSVのヘッダー行・JSON配列の開始）を書き込みます。
+func (m *mergedWriter) open() error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	f, err := os.Create(m.path)
+	if err != nil {
+		return err
+	}
+	m.f, m.w = f, bufio.NewWriter(f)
+	switch m.format {
+	case formatHTML:
+		err = htmlReportTemplate.ExecuteTemplate(m.w, "head", struct{ Title string }{m.title})
+	case formatMarkdown:
+		_, err = fmt.Fprintf(m.w, "# %s\n\n", m.title)
+	case formatCSV:
+		m.csv = csv.NewWriter(m.w)
+		err = m.csv.Write(csvHeader)
+	case formatJSON:
+		_, err = m.w.WriteString("[")
+	}
+	return err
+}
+
+// add は1つのPRの見出しとコメントをファイルに追記し、ファイルに書き出します。
+//
+// パラメータ:
+//   - pr: コメントが属するプルリクエスト
+//   - comments: 追記するコメント（絞り込み・並べ替えの後）
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func (m *mergedWriter) add(pr PullRequest, comments []Comment) error {
+	if m.f == nil {
+		if err := m.open(); err != nil {
+			return err
+		}
+	}
+	var err error
+	switch m.format {
+	case formatHTML:
+		err = htmlReportTemplate.ExecuteTemplate(m.w, "section", prSection{PR: pr, Comments: comments})
+	case formatMarkdown:
+		err = writeMarkdownSection(m.w, prSection{PR: pr, Comments: comments})
+	case formatCSV:
+		for _, c := range comments {
+			if err = m.csv.Write(newCommentRecord(PRComment{PRNumber: pr.Number, Labels: pr.LabelNames(), Comment: c}).csvRow()); err != nil {
+				break
+			}
+		}
+		m.csv.Flush()
+		if err == nil {
+			err = m.csv.Error()
+		}
+	case formatJSON:
+		// writeRecords（json.Encoder のインデント付きの出力）と同じ形式になるよう、要素ごとに書き込む
+		for _, c := range comments {
+			data, merr := json.MarshalIndent(newCommentRecord(PRComment{PRNumber: pr.Number, Labels: pr.LabelNames(), Comment: c}), "  ", "  ")
+			if merr != nil {
+				err = merr
+				break
+			}
+			sep := ",\n  "
+			if m.records == 0 {
+				sep = "\n  "
+			}
+			m.w.WriteString(sep)
+			m.w.Write(data)
+			m.records++
+		}
+	default:
+		if err = writePRHeader(m.w, pr); err != nil {
+			break
+		}
+		for _, c := range comments {
+			if err = writeTextComment(m.w, fmt.Sprintf("PR #%d ", pr.Number), c); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if m.format != formatJSON {
+		m.records += len(comments)
+	}
+	m.prs++
+	return m.w.Flush()
+}
+
+// close は形式ごとの末尾部分を書き込んでファイルを閉じます。
+// 実行を途中で打ち切った場合は、テキスト・HTML・Markdownの末尾にその理由を書き込みます。
+//
+// パラメータ:
+//   - partial: 実行を途中で打ち切った場合はその理由、すべて取得した場合は空文字
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func (m *mergedWriter) close(partial string) error {
+	if m.f == nil {
+		return nil
+	}
+	var err error
+	notice := ""
+	if partial != "" {
+		notice = fmt.Sprintf("PARTIAL RESULTS: %s; PRs after the last one above were not fetched", partial)
+	}
+	switch m.format {
+	case formatHTML:
+		if notice != "" {
+			_, err = fmt.Fprintf(m.w, "<p><strong>%s</strong></p>\n", template.HTMLEscapeString(notice))
+		}
+		if err == nil {
+			err = htmlReportTemplate.ExecuteTemplate(m.w, "foot", nil)
+		}
+	case formatMarkdown:
+		if notice != "" {
+			_, err = fmt.Fprintf(m.w, "**%s**\n", notice)
+		}
+	case formatJSON:
+		if m.records == 0 {
+			_, err = m.w.WriteString("]\n")
+		} else {
+			_, err = m.w.WriteString("\n]\n")
+		}
+	case formatText:
+		if notice != "" {
+			_, err = fmt.Fprintf(m.w, "%s\n%s\n", notice, strings.Repeat("=", 40))
+		}
+	}
+	if ferr := m.w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"         // 出力の解析に使用
+	"encoding/csv"  // CSV形式の解析に使用
+	"encoding/json" // JSON形式の解析に使用
+	"os"            // 出力の読み込みに使用
+	"strings"       // 出力の確認に使用
+	"testing"       // テストの実行に使用
+)
+
+// TestMergedWriterPartial は一部のPRだけを追記して途中で閉じたまとめたファイルが、すべての形式で正しい形をしていることを確認します。
+// テキスト・HTML・Markdownは末尾に打ち切った理由を記録します。JSON・CSVは形式を崩さないよう本文には書かず、
+// 取得しなかったPRを prs.json の status に記録します（そのため、ここでは記録がないことを確認します）。
+func TestMergedWriterPartial(t *testing.T) {
+	const reason = "--max-api-calls limit of 10 reached"
+	merged := "2024-05-01T00:00:00Z"
+	prs := []PullRequest{
+		{Number: 3, Title: "feat: add x", User: User{Login: "alice"}, MergedAt: &merged, Labels: []Label{{Name: "backend"}}},
+		{Number: 2, Title: "fix: <y> & z", User: User{Login: "bob"}, MergedAt: &merged},
+	}
+	comments := [][]Comment{
+		{
+			{ID: 10, User: &User{Login: "bob"}, Body: "nit: rename \"this\", please\nsecond line", CreatedAt: "2024-05-01T10:00:00Z", Path: "a.go"},
+			{ID: 11, User: &User{Login: "alice"}, Body: "これは日本語のコメントです", CreatedAt: "2024-05-01T11:00:00Z", Path: "a.go"},
+		},
+		{
+			{ID: 20, Body: "<script>alert(1)</script>", CreatedAt: "2024-05-02T10:00:00Z", Path: "b.go"},
+		},
+	}
+	for _, format := range []string{formatText, formatJSON, formatCSV, formatHTML, formatMarkdown} {
+		t.Run(format, func(t *testing.T) {
+			chdirTemp(t)
+			w := newMergedWriter("o", "r", format)
+			for i, pr := range prs {
+				if err := w.add(pr, comments[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.close(reason); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(mergedCommentsFile("o", "r", format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := string(data)
+			notice := "PARTIAL RESULTS: " + reason
+			switch format {
+			case formatJSON:
+				var records []commentRecord
+				if err := json.Unmarshal(data, &records); err != nil {
+					t.Fatalf("partial JSON is not well-formed: %v\n%s", err, out)
+				}
+				if len(records) != 3 || records[0].PRNumber != 3 || records[2].PRNumber != 2 {
+					t.Errorf("partial JSON has %d records: %s", len(records), out)
+				}
+			case formatCSV:
+				rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+				if err != nil {
+					t.Fatalf("partial CSV is not well-formed: %v\n%s", err, out)
+				}
+				if len(rows) != 4 || strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+					t.Errorf("partial CSV has %d rows: %s", len(rows), out)
+				}
+			case formatHTML:
+				if !strings.HasSuffix(strings.TrimSpace(out), "</html>") {
+					t.Errorf("partial HTML is not closed:\n%s", out)
+				}
+				if strings.Contains(out, "<script>") {
+					t.Errorf("partial HTML contains an unescaped comment body:\n%s", out)
+				}
+			}
+			switch format {
+			case formatJSON, formatCSV:
+				if strings.Contains(out, "PARTIAL") {
+					t.Errorf("%s output contains the notice, which breaks the format:\n%s", format, out)
+				}
+			default:
+				if !strings.Contains(out, notice) {
+					t.Errorf("output does not contain %q:\n%s", notice, out)
+				}
+				// 理由は最後のPRのコメントより後に書く
+				if last := strings.LastIndex(out, "alert(1)"); last < 0 || last > strings.Index(out, notice) {
+					t.Errorf("notice is not at the end:\n%s", out)
+				}
+				if !strings.Contains(out, "これは日本語のコメントです") {
+					t.Errorf("output lost a comment:\n%s", out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context" // 実行全体の期限の伝達に使用
+	"fmt"     // フォーマット済み入出力に使用
+	"net/url" // クエリパラメータの組み立てに使用
+	"strings" // 文字列操作に使用
+)
+
+// Milestone はGitHubのマイルストーンの情報を格納する構造体です。
+type Milestone struct {
+	Number int    `json:"number"` // マイルストーン番号
+	Title  string `json:"title"`  // マイルストーンのタイトル
+	State  string `json:"state"`  // 状態（open/closed）
+}
+
+// fetchMilestones はリポジトリのマイルストーンを、オープン・クローズ済みの両方とも取得します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - token: GitHub APIアクセス用のトークン
+//
+// 戻り値:
+//   - []Milestone: マイルストーンの配列
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func fetchMilestones(ctx context.Context, owner, repo, token string) ([]Milestone, error) {
+	var milestones []Milestone // マイルストーンを格納するスライス
+
+	// クエリパラメータを設定（ページ番号は paginate が設定する）
+	q := url.Values{}
+	q.Add("state", "all")    // オープン・クローズ済みの両方を取得
+	q.Add("per_page", "100") // 1ページあたり100件取得
+
+	// 全ページのマイルストーンを取得（レスポンスボディはページごとに閉じる）
+	_, err := paginate(ctx, apiClient, apiRequest{URL: apiURL("/repos/%s/%s/milestones", owner, repo), Query: q, Token: token}, func(_ int, ms []Milestone, _ apiResponse) (bool, error) {
+		milestones = append(milestones, ms...)
+		return len(ms) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return milestones, nil
+}
+
+// resolveMilestone はタイトルからマイルストーンを探します。
+// 見つからない場合は、指定できるマイルストーンの一覧をエラーに含めます。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - title: マイルストーンのタイトル（大文字小文字を区別しない）
+//   - token: GitHub APIアクセス用のトークン
+//
+// 戻り値:
+//   - Milestone: 見つかったマイルストーン
+//   - error: 見つからない場合や取得に失敗した場合のエラー情報
+func resolveMilestone(ctx context.Context, owner, repo, title, token string) (Milestone, error) {
+	milestones, err := fetchMilestones(ctx, owner, repo, token)
+	if err != nil {
+		return Milestone{}, err
+	}
+	var available []string
+	for _, m := range milestones {
+		if strings.EqualFold(m.Title, title) {
+			return m, nil
+		}
+		available = append(available, fmt.Sprintf("%q (%s)", m.Title, m.State))
+	}
+	if len(available) == 0 {
+		return Milestone{}, fmt.Errorf("unknown milestone %q: %s/%s has no milestones", title, owner, repo)
+	}
+	return Milestone{}, fmt.Errorf("unknown milestone %q (available: %s)", title, strings.Join(available, ", "))
+}
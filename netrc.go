@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"           // エラーメッセージの作成に使用
+	"os"            // ファイル操作と環境変数の読み込みに使用
+	"path/filepath" // ファイルパス操作に使用
+	"strings"       // トークンの分割に使用
+)
+
+// netrcPath は読み込む .netrc のパスを返します（環境変数 NETRC、なければホームディレクトリの .netrc）。
+func netrcPath() string {
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// netrcPassword は .netrc の内容から、指定されたホストの machine エントリの password を返します。
+// login・password・account はどの順序でも、複数行にまたがっていても受け付け、macdef のマクロ定義（空行まで）は読み飛ばします。
+// 一致する machine がない場合は default エントリの password を使います。
+//
+// パラメータ:
+//   - data: .netrc の内容
+//   - host: 探すホスト名（例: api.github.com）
+//
+// 戻り値:
+//   - string: password の値
+//   - bool: 一致する machine または default エントリがあったかどうか
+func netrcPassword(data, host string) (string, bool) {
+	var (
+		matched, inDefault        bool
+		password, defaultPassword string
+		found, foundDefault       bool
+	)
+	lines := strings.Split(data, "\n")
+	var fields []string
+	i, j := -1, 0
+	// next は次の単語を返します（行をまたいで読み進め、最後まで読んだら空文字を返します）
+	next := func() string {
+		for j >= len(fields) {
+			if i+1 >= len(lines) {
+				return ""
+			}
+			i++
+			fields, j = strings.Fields(lines[i]), 0
+		}
+		j++
+		return fields[j-1]
+	}
+	for word := next(); word != ""; word = next() {
+		switch word {
+		case "machine":
+			name := next()
+			matched = strings.EqualFold(name, host)
+			inDefault = false
+			if matched {
+				found = true
+			}
+		case "default":
+			matched, inDefault = false, true
+			foundDefault = true
+		case "password":
+			value := next()
+			if matched && password == "" {
+				password = value
+			} else if inDefault && defaultPassword == "" {
+				defaultPassword = value
+			}
+		case "login", "account":
+			next()
+		case "macdef":
+			// マクロ定義は次の空行まで続く
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+				i++
+			}
+			j = len(fields)
+		}
+	}
+	if found {
+		return password, true
+	}
+	return defaultPassword, foundDefault
+}
+
+// netrcToken は .netrc（環境変数 NETRC またはホームディレクトリの .netrc）から、REST APIのホストのトークンを読み込みます。
+// ファイルがない場合は空文字を返し、ファイルはあるが一致する machine がない場合はその旨のエラーを返します。
+//
+// パラメータ:
+//   - host: REST APIのホスト名（例: api.github.com）
+//
+// 戻り値:
+//   - string: トークン（machine エントリの password）
+//   - string: 読み込んだ .netrc のパス
+//   - error: 読み込みに失敗した場合や一致する machine がない場合はエラー情報、成功時またはファイルがない場合はnil
+func netrcToken(host string) (string, string, error) {
+	path := netrcPath()
+	if path == "" {
+		return "", "", nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", path, nil
+	}
+	if err != nil {
+		return "", path, err
+	}
+	password, ok := netrcPassword(string(data), host)
+	if !ok {
+		return "", path, fmt.Errorf("%s has no \"machine %s\" entry", path, host)
+	}
+	if password == "" {
+		return "", path, fmt.Errorf("the \"machine %s\" entry in %s has no password", host, path)
+	}
+	return password, path, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"            // テスト用の .netrc の書き込みに使用
+	"path/filepath" // テスト用の .netrc のパスの組み立てに使用
+	"strings"       // エラーメッセージの確認に使用
+	"testing"       // テストの実行に使用
+)
+
+// TestNetrcPassword は代表的な .netrc の書き方から、ホストの machine エントリの password を読み取れることを確認します。
+func TestNetrcPassword(t *testing.T) {
+	const host = "api.github.com"
+	tests := []struct {
+		name  string
+		data  string
+		want  string
+		found bool
+	}{
+		{"one line", "machine api.github.com login u password tok1\n", "tok1", true},
+		{"password before login", "machine api.github.com password tok1 login u\n", "tok1", true},
+		{"account between", "machine api.github.com account a password tok1 login u", "tok1", true},
+		{"host case", "machine API.GitHub.com login u password tok1", "tok1", true},
+		{"several lines", "machine api.github.com\n  login u\n  password tok1\n", "tok1", true},
+		{"tabs and blank lines", "\n\nmachine\tapi.github.com\n\n\tlogin\tu\n\tpassword\ttok1\n", "tok1", true},
+		{"keyword and value on separate lines", "machine\napi.github.com\npassword\ntok1\n", "tok1", true},
+		{"other machines around", "machine github.com login u password web\nmachine api.github.com login u password tok1\nmachine example.com password other\n", "tok1", true},
+		{"first password wins", "machine api.github.com password tok1\nmachine api.github.com password tok2\n", "tok1", true},
+		{"default entry", "machine example.com password other\ndefault login u password fallback\n", "fallback", true},
+		{"machine wins over earlier default", "default password fallback\nmachine api.github.com password tok1\n", "tok1", true},
+		{"machine wins over later default", "machine api.github.com password tok1\ndefault password fallback\n", "tok1", true},
+		{"macdef skipped", "macdef init\npassword fake\nmachine api.github.com password fake2\n\nmachine api.github.com password tok1\n", "tok1", true},
+		{"empty macdef", "macdef init\n\nmachine api.github.com password tok1\n", "tok1", true},
+		{"macdef at end", "machine api.github.com password tok1\nmacdef upload\nput file\n", "tok1", true},
+		{"matching machine without password", "machine api.github.com login u\nmachine example.com password other\n", "", true},
+		{"no matching machine", "machine github.com login u password web\nmachine example.com password other\n", "", false},
+		{"host as a prefix only", "machine api.github.com.evil.test password bad\n", "", false},
+		{"empty file", "", "", false},
+		{"CRLF line endings", "machine api.github.com\r\nlogin u\r\npassword tok1\r\n", "tok1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := netrcPassword(tt.data, host)
+			if got != tt.want || found != tt.found {
+				t.Errorf("netrcPassword(%q) = %q, %v; want %q, %v", tt.data, got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+// TestNetrcToken は NETRC のファイルから読み込み、ファイルがない場合は黙って使わず、
+// 一致する machine がない場合や password がない場合はその旨のエラーになることを確認します。
+func TestNetrcToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "netrc")
+	t.Setenv("NETRC", path)
+
+	if token, _, err := netrcToken("api.github.com"); token != "" || err != nil {
+		t.Errorf("missing .netrc: got %q, %v; want no token and no error", token, err)
+	}
+
+	tests := []struct {
+		data  string
+		token string
+		err   string // 空でなければ、エラーメッセージに含まれるべき文字列
+	}{
+		{"machine api.github.com login u password tok1\n", "tok1", ""},
+		{"machine github.com login u password web\n", "", `has no "machine api.github.com" entry`},
+		{"machine api.github.com login u\n", "", `"machine api.github.com" entry in ` + path + " has no password"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		token, from, err := netrcToken("api.github.com")
+		if from != path {
+			t.Errorf("netrcToken read %q, want %q", from, path)
+		}
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) || !strings.Contains(err.Error(), path) {
+				t.Errorf("netrcToken(%q) = %q, %v; want an error naming %s and containing %q", tt.data, token, err, path, tt.err)
+			}
+			continue
+		}
+		if err != nil || token != tt.token {
+			t.Errorf("netrcToken(%q) = %q, %v; want %q", tt.data, token, err, tt.token)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"encoding/csv"  // CSV形式の出力に使用
+	"encoding/json" // JSON形式の出力に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"io"            // 入出力の基本インタフェースを提供
+	"os"            // ファイル操作に使用
+	"path/filepath" // ファイルパス操作に使用
+	"strconv"       // 数値の文字列変換に使用
+	"strings"       // 文字列操作に使用
+)
+
+// 出力形式の定数です。
+const (
+	formatText     = "text"     // 人が読むためのテキスト形式（デフォルト）
+	formatJSON     = "json"     // 機械処理向けのJSON形式
+	formatCSV      = "csv"      // 表計算ソフト向けのCSV形式
+	formatHTML     = "html"     // ブラウザで閲覧するためのHTMLレポート
+	formatMarkdown = "markdown" // GitHubやWikiに貼り付けるためのMarkdown形式
+)
+
+// validFormat は指定された出力形式がサポートされているかを返します。
+func validFormat(format string) bool {
+	switch format {
+	case formatText, formatJSON, formatCSV, formatHTML, formatMarkdown:
+		return true
+	}
+	return false
+}
+
+// formatExt は出力形式に対応するファイル拡張子を返します。
+func formatExt(format string) string {
+	switch format {
+	case formatJSON:
+		return ".json"
+	case formatCSV:
+		return ".csv"
+	case formatHTML:
+		return ".html"
+	case formatMarkdown:
+		return ".md"
+	}
+	return ".txt"
+}
+
+// commentRecord はJSON/CSV形式で出力する1コメント分のレコードです。
+type commentRecord struct {
+	Owner            string   `json:"owner,omitempty"`    // コメントが属するリポジトリのオーナー名（--merge-across-repos の場合のみ）
+	Repo             string   `json:"repo,omitempty"`     // コメントが属するリポジトリ名（--merge-across-repos の場合のみ）
+	PRNumber         int      `json:"pr_number"`          // コメントが属するプルリクエスト番号
+	ID               int64    `json:"id"`                 // コメントID
+	InReplyToID      *int64   `json:"in_reply_to_id"`     // 返信先のコメントID（ルートコメントの場合はnil）
+	User             string   `json:"user"`               // コメントを投稿したユーザー名
+	CreatedAt        string   `json:"created_at"`         // コメントが作成された日時
+	Body             string   `json:"body"`               // コメント本文
+	ReplyCount       int      `json:"reply_count"`        // ルートコメントへの返信数
+	AnsweredByAuthor bool     `json:"answered_by_author"` // PR作成者が返信したかどうか
+	IsSelfReview     bool     `json:"is_self_review"`     // PR作成者自身が投稿したコメントかどうか
+	Labels           []string `json:"labels"`             // コメントが属するプルリクエストのラベル名
+	Category         string   `json:"category"`           // コメントの分類
+	Truncated        bool     `json:"truncated"`          // --max-body で本文を切り詰めたかどうか
+	MediaOnly        bool     `json:"media_only"`         // --mark-media-only で画像やURLだけと判定されたかどうか
+	Lang             string   `json:"lang"`               // 本文の言語（ja/en/unknown）
+	ParentUser       string   `json:"parent_user"`        // --replies-to 指定時、返信先のコメントの投稿者
+	ParentSnippet    string   `json:"parent_snippet"`     // --replies-to 指定時、返信先のコメントの本文の抜粋
+}
+
+// csvHeader はCSV出力のヘッダー行です。commentRecord のフィールド順と一致させます（JSONでのみ意味を持つ media_only は含めません）。
+var csvHeader = []string{"pr_number", "id", "in_reply_to_id", "user", "created_at", "body", "reply_count", "answered_by_author", "is_self_review", "labels", "category", "truncated", "lang", "parent_user", "parent_snippet"}
+
+// newCommentRecord はコメントから出力用のレコードを作成します。
+func newCommentRecord(pc PRComment) commentRecord {
+	c := pc.Comment
+	return commentRecord{
+		Owner:            pc.Owner,
+		Repo:             pc.Repo,
+		PRNumber:         pc.PRNumber,
+		ID:               c.ID,
+		InReplyToID:      c.InReplyToID,
+		User:             c.AuthorLogin(),
+		CreatedAt:        c.CreatedAt,
+		Body:             c.Body,
+		ReplyCount:       c.ReplyCount,
+		AnsweredByAuthor: c.AnsweredByAuthor,
+		IsSelfReview:     c.IsSelfReview,
+		Labels:           pc.Labels,
+		Category:         c.Category,
+		Truncated:        c.Truncated,
+		MediaOnly:        c.MediaOnly,
+		Lang:             c.Lang,
+		ParentUser:       c.ParentAuthor,
+		ParentSnippet:    c.ParentSnippet,
+	}
+}
+
+// csvRow はレコードをCSVの1行に変換します。
+func (r commentRecord) csvRow() []string {
+	inReplyTo := ""
+	if r.InReplyToID != nil {
+		inReplyTo = strconv.FormatInt(*r.InReplyToID, 10)
+	}
+	row := []string{
+		strconv.Itoa(r.PRNumber),
+		strconv.FormatInt(r.ID, 10),
+		inReplyTo,
+		r.User,
+		r.CreatedAt,
+		r.Body,
+		strconv.Itoa(r.ReplyCount),
+		strconv.FormatBool(r.AnsweredByAuthor),
+		strconv.FormatBool(r.IsSelfReview),
+		strings.Join(r.Labels, ";"), // CSVでは複数のラベルをセミコロン区切りで1列にまとめる
+		r.Category,
+		strconv.FormatBool(r.Truncated),
+		r.Lang,
+		r.ParentUser,
+		r.ParentSnippet,
+	}
+	// リポジトリをまたいでまとめた場合は、先頭にリポジトリの列を加える
+	if r.Owner != "" {
+		row = append([]string{r.Owner, r.Repo}, row...)
+	}
+	return row
+}
+
+// commentsDir はリポジトリごとの保存先ディレクトリパスを返します。
+// comments/owner_repo 形式のパスになります。
+func commentsDir(owner, repo string) string {
+	return filepath.Join("comments", fmt.Sprintf("%s_%s", owner, repo))
+}
+
+// prCommentsFile は1つのPRのコメントを保存するファイルパスを返します。
+func prCommentsFile(owner, repo string, prNumber int, format string) string {
+	return filepath.Join(commentsDir(owner, repo), fmt.Sprintf("pr_%d_comments%s", prNumber, formatExt(format)))
+}
+
+// mergedCommentsFile はマージモードでコメントをまとめて保存するファイルパスを返します。
+func mergedCommentsFile(owner, repo string, format string) string {
+	return filepath.Join(commentsDir(owner, repo), "all_pr_comments"+formatExt(format))
+}
+
+// userLogins はユーザーの配列からユーザー名の配列を作成します。
+func userLogins(users []User) []string {
+	logins := make([]string, 0, len(users))
+	for _, u := range users {
+		logins = append(logins, u.Login)
+	}
+	return logins
+}
+
+// joinOrNone は文字列の配列をカンマ区切りで連結します。空の場合は "(none)" を返します。
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "(none)"
+	}
+	return strings.Join(values, ", ")
+}
+
+// labelSuffix はPRの見出しに付けるラベルの一覧を " [backend, ios]" の形式で返します。ラベルがない場合は空文字を返します。
+func labelSuffix(pr PullRequest) string {
+	if len(pr.Labels) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(pr.LabelNames(), ", ") + "]"
+}
+
+// stateSuffix はPRの見出しに付ける状態の表示を返します。
+// オープン中のPRの場合は、コメントがその時点のものであることがわかるよう " (open as of 2024-05-01T10:00:00Z)" の形式で、
+// マージされずにクローズされたPRの場合は " [closed without merge]" を返します。
+func stateSuffix(pr PullRequest) string {
+	switch {
+	case pr.SnapshotAt != "":
+		return " (open as of " + pr.SnapshotAt + ")"
+	case pr.State == "closed" && pr.MergedAt == nil:
+		return " [closed without merge]"
+	}
+	return ""
+}
+
+// availableCount は取得できるコメントの総数を表示用の文字列にします。総数がわからない場合は "more" を返します。
+func availableCount(available int) string {
+	if available == 0 {
+		return "more"
+	}
+	return strconv.Itoa(available)
+}
+
+// truncationSuffix はPRの見出しに付ける、コメントの取得を打ち切ったことの表示を " [truncated: true, 900 comments available]" の形式で返します。
+// 打ち切っていない場合は空文字を返します。
+func truncationSuffix(pr PullRequest) string {
+	if !pr.CommentsTruncated {
+		return ""
+	}
+	return fmt.Sprintf(" [truncated: true, %s comments available]", availableCount(pr.CommentsAvailable))
+}
+
+// hasSize はPRの規模（変更行数・ファイル数）を取得できたかを返します。
+func hasSize(pr PullRequest) bool {
+	return pr.Additions > 0 || pr.Deletions > 0 || pr.ChangedFiles > 0
+}
+
+// sizeSuffix はPRの見出しに付ける規模の表示を " (+412 −98 across 14 files)" の形式で返します。
+// 規模を取得できなかった場合は空文字を返します。
+func sizeSuffix(pr PullRequest) string {
+	if !hasSize(pr) {
+		return ""
+	}
+	return fmt.Sprintf(" (+%d −%d across %d files)", pr.Additions, pr.Deletions, pr.ChangedFiles)
+}
+
+// commentsPer100Lines は変更行数100行あたりのコメント数を返します。変更行数がわからない場合は0を返します。
+func commentsPer100Lines(pr PullRequest, comments int) float64 {
+	lines := pr.Additions + pr.Deletions
+	if lines == 0 {
+		return 0
+	}
+	return float64(comments) * 100 / float64(lines)
+}
+
+// writePRHeader はプルリクエストの見出し（作成者・ラベル・レビュー依頼者・アサイン者を含む）を書き込みます。
+//
+// パラメータ:
+//   - w: 書き込み先
+//   - pr: 見出しを書き込むプルリクエスト
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func writePRHeader(w io.Writer, pr PullRequest) error {
+	// "PR #番号 by 作成者 [ラベル]\nRequested reviewers: ...\nAssignees: ...\n区切り線" の形式で書き込み
+	_, err := fmt.Fprintf(w, "%sPR #%d by %s%s%s%s%s\nRequested reviewers: %s\nAssignees: %s\n%s\n",
+		repositoryPrefix(pr), pr.Number, pr.User.Login, labelSuffix(pr), sizeSuffix(pr), stateSuffix(pr), truncationSuffix(pr), joinOrNone(userLogins(pr.RequestedReviewers)), joinOrNone(userLogins(pr.Assignees)), strings.Repeat("=", 40))
+	return err
+}
+
+// repositoryPrefix はリポジトリをまたいでまとめた出力で、PRの見出しの先頭に付ける "owner/repo " を返します。
+func repositoryPrefix(pr PullRequest) string {
+	if pr.Repository == "" {
+		return ""
+	}
+	return pr.Repository + " "
+}
+
+// authorSuffix はテキスト・Markdown・HTML出力でユーザー名の後ろに付ける表示を返します。
+// セルフレビューの場合は " [self]"、返信がある場合は " (3 replies)" のような表示になります。
+func authorSuffix(c Comment) string {
+	suffix := ""
+	if c.IsSelfReview {
+		suffix += " [self]"
+	}
+	return suffix + replySuffix(c)
+}
+
+// categoryPrefix はテキスト・Markdown・HTML出力でコメントの先頭に付ける分類の表示を "[nit] " の形式で返します。
+func categoryPrefix(c Comment) string {
+	if c.Category == "" {
+		return ""
+	}
+	return "[" + c.Category + "] "
+}
+
+// replySuffix はテキスト出力でユーザー名の後ろに付ける返信数の表示を返します。
+// 返信のないコメントや、返信そのものには何も付けません。
+func replySuffix(c Comment) string {
+	switch {
+	case c.ReplyCount == 1:
+		return " (1 reply)"
+	case c.ReplyCount > 1:
+		return fmt.Sprintf(" (%d replies)", c.ReplyCount)
+	}
+	return ""
+}
+
+// parentLine は --replies-to 指定時に表示する返信先の情報を "in reply to bob: ..." の形式で返します。
+// 返信先の情報がないコメントには空文字を返します。
+func parentLine(c Comment) string {
+	if c.ParentAuthor == "" {
+		return ""
+	}
+	return fmt.Sprintf("in reply to %s: %s", c.ParentAuthor, c.ParentSnippet)
+}
+
+// parentContext はテキスト・Markdown出力で本文の前に置く返信先の情報を引用の形式で返します。
+func parentContext(c Comment) string {
+	if line := parentLine(c); line != "" {
+		return "> " + line + "\n"
+	}
+	return ""
+}
+
+// displayCreatedAt はテキスト・Markdown・HTML出力で表示する作成日時を返します。
+// --timezone 指定時は変換した読みやすい形式、それ以外はAPIの値をそのまま返します。
+func displayCreatedAt(c Comment) string {
+	if c.CreatedDisplay != "" {
+		return c.CreatedDisplay
+	}
+	return c.CreatedAt
+}
+
+// writeTextComment は1件のコメントをテキスト形式で書き込みます。
+// prefix はマージモードで先頭に付ける "PR #番号 " のような文字列です。
+func writeTextComment(w io.Writer, prefix string, c Comment) error {
+	// "[日時] ユーザー名:\nコメント本文\n区切り線" の形式で書き込み
+	_, err := fmt.Fprintf(w, "%s%s[%s] %s%s:\n%s\n%s\n", prefix, categoryPrefix(c), displayCreatedAt(c), c.AuthorLogin(), authorSuffix(c), parentContext(c)+c.Body, strings.Repeat("-", 40))
+	return err
+}
+
+// writeRecords はレコードをJSONまたはCSV形式で書き込みます。
+func writeRecords(w io.Writer, format string, records []commentRecord) error {
+	if format == formatCSV {
+		cw := csv.NewWriter(w)
+		header := csvHeader
+		if len(records) > 0 && records[0].Owner != "" {
+			header = append([]string{"owner", "repo"}, csvHeader...)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(r.csvRow()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	// JSONは人が読みやすいようにインデント付きで出力
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// prSection はHTML/Markdownレポートで1つのPRとして表示する単位です。
+type prSection struct {
+	PR       PullRequest // 対象のプルリクエスト
+	Comments []Comment   // そのPRのコメント
+}
+
+// groupSections はマージモードのコメントを、PRが切り替わるごとにセクションへまとめます。
+//
+// パラメータ:
+//   - prByNumber: PR番号からPR情報を引くためのマップ
+//   - allComments: すべてのPRのコメント
+//
+// 戻り値:
+//   - []prSection: PRごとのセクション（allComments の順序を保持）
+func groupSections(prByNumber map[int]PullRequest, allComments []PRComment) []prSection {
+	var sections []prSection
+	for _, pc := range allComments {
+		if len(sections) == 0 || sections[len(sections)-1].PR.Number != pc.PRNumber {
+			sections = append(sections, prSection{PR: prByNumber[pc.PRNumber]})
+		}
+		last := &sections[len(sections)-1]
+		last.Comments = append(last.Comments, pc.Comment)
+	}
+	return sections
+}
+
+// saveComments は1つのプルリクエストのコメントをファイルに保存します。
+// テキスト形式の場合、ファイルの先頭にはプルリクエストの見出しが書き込まれます。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - pr: コメントが属するプルリクエスト
+//   - comments: 保存するコメントの配列
+//   - format: 出力形式（text/json/csv/html/markdown）
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func saveComments(owner, repo string, pr PullRequest, comments []Comment, format string) error {
+	// 保存先ディレクトリを作成（パーミッション0755）
+	if err := os.MkdirAll(commentsDir(owner, repo), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	// ファイルを作成（既存の場合は上書き）
+	f, err := os.Create(prCommentsFile(owner, repo, pr.Number, format))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// HTML/Markdown形式の場合はレポートとして書き込み
+	title := fmt.Sprintf("%s/%s PR #%d", owner, repo, pr.Number)
+	switch format {
+	case formatHTML:
+		return writeHTMLReport(f, title, []prSection{{PR: pr, Comments: comments}})
+	case formatMarkdown:
+		return writeMarkdownReport(f, title, []prSection{{PR: pr, Comments: comments}})
+	}
+	// JSON/CSV形式の場合はレコードとして書き込み
+	if format != formatText {
+		records := make([]commentRecord, 0, len(comments))
+		for _, c := range comments {
+			records = append(records, newCommentRecord(PRComment{PRNumber: pr.Number, Labels: pr.LabelNames(), Comment: c}))
+		}
+		return writeRecords(f, format, records)
+	}
+
+	// PRの見出しを書き込み
+	if err := writePRHeader(f, pr); err != nil {
+		return err
+	}
+	// 各コメントを順番に書き込み
+	for _, c := range comments {
+		if err := writeTextComment(f, "", c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partialTitle は途中で打ち切った実行のまとめたファイルの見出しに、一部だけの結果であることを書き添えます。
+// partial が空の場合は title をそのまま返します。
+func partialTitle(title, partial string) string {
+	if partial == "" {
+		return title
+	}
+	return fmt.Sprintf("%s (PARTIAL: %s)", title, partial)
+}
+
+// writePartialNotice はテキスト形式のまとめたファイルの先頭に、一部だけの結果であることを書き込みます。
+// partial が空の場合は何も書き込みません。
+func writePartialNotice(w io.Writer, partial string) error {
+	if partial == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "PARTIAL RESULTS: %s; PRs after the last one below were not fetched\n%s\n", partial, strings.Repeat("=", 40))
+	return err
+}
+
+// saveMergedComments はすべてのPRのコメントを1つのファイルにまとめて保存します。
+// テキスト形式の場合、PRが切り替わるごとにそのPRの見出しが書き込まれます。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - prs: 見出しの作成に使用するプルリクエストの配列
+//   - allComments: すべてのPRのコメント
+//   - format: 出力形式（text/json/csv/html/markdown）
+//   - partial: 実行を途中で打ち切った場合はその理由（テキスト・HTML・Markdownの見出しに記録する）、すべて取得した場合は空文字
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func saveMergedComments(owner, repo string, prs []PullRequest, allComments []PRComment, format, partial string) error {
+	// 保存先ディレクトリを作成（パーミッション0755）
+	if err := os.MkdirAll(commentsDir(owner, repo), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	// ファイルを作成（既存の場合は上書き）
+	f, err := os.Create(mergedCommentsFile(owner, repo, format))
+	if err != nil {
+		return err
+	}
+	defer f.Close() // 関数終了時にファイルをクローズ
+
+	// PR番号からPR情報を引けるようにしておく
+	prByNumber := make(map[int]PullRequest, len(prs))
+	for _, pr := range prs {
+		prByNumber[pr.Number] = pr
+	}
+
+	// HTML/Markdown形式の場合はPRごとのセクションにまとめてレポートとして書き込み
+	title := partialTitle(fmt.Sprintf("%s/%s review comments", owner, repo), partial)
+	switch format {
+	case formatHTML:
+		return writeHTMLReport(f, title, groupSections(prByNumber, allComments))
+	case formatMarkdown:
+		return writeMarkdownReport(f, title, groupSections(prByNumber, allComments))
+	}
+	// JSON/CSV形式の場合はレコードとして書き込み
+	if format != formatText {
+		records := make([]commentRecord, 0, len(allComments))
+		for _, pc := range allComments {
+			records = append(records, newCommentRecord(pc))
+		}
+		return writeRecords(f, format, records)
+	}
+
+	if err := writePartialNotice(f, partial); err != nil {
+		return err
+	}
+	// すべてのコメントを順番に書き込み
+	currentPR := 0
+	for _, pc := range allComments {
+		// PRが切り替わったら見出しを書き込み
+		if pc.PRNumber != currentPR {
+			if err := writePRHeader(f, prByNumber[pc.PRNumber]); err != nil {
+				return err
+			}
+			currentPR = pc.PRNumber
+		}
+		if err := writeTextComment(f, fmt.Sprintf("PR #%d ", pc.PRNumber), pc.Comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
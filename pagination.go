@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"net/http" // HTTPクライアントの受け渡しに使用
+	"net/url"  // LinkヘッダーのURLの解析に使用
+	"strconv"  // ページ番号の変換に使用
+	"strings"  // Linkヘッダーの分割に使用
+)
+
+// pageLinks はレスポンスのLinkヘッダー（RFC 8288、旧RFC 5988）から読み取ったページ送りの情報です。
+// GitHub APIは次のページがある場合にだけ rel="next" を返すため、空のページを取得して終わりを確かめる必要はありません。
+type pageLinks struct {
+	HasNext bool // 次のページがあるか（rel="next"）
+	Next    int  // 次のページ番号（URLに page がない場合は0）
+	Last    int  // 最後のページ番号（rel="last" がない場合は0）
+}
+
+// parseLinkHeader はLinkヘッダーを解析し、次のページと最後のページを返します。
+// `<URL>; rel="next", <URL>; rel="last"` の形式の各リンクを読み、rel は引用符の有無と空白区切りの複数の値を受け付けます。
+// 形式が正しくないリンクは無視します（Linkヘッダーがない場合や、すべてのリンクが不正な場合は、次のページがないものとして扱います）。
+//
+// パラメータ:
+//   - header: Linkヘッダーの値
+//
+// 戻り値:
+//   - pageLinks: 読み取ったページ送りの情報
+func parseLinkHeader(header string) pageLinks {
+	var links pageLinks
+	for _, link := range splitLinks(header) {
+		link = strings.TrimSpace(link)
+		if !strings.HasPrefix(link, "<") {
+			continue
+		}
+		end := strings.Index(link, ">")
+		if end < 0 {
+			continue
+		}
+		u, err := url.Parse(link[1:end])
+		if err != nil {
+			continue
+		}
+		page, _ := strconv.Atoi(u.Query().Get("page"))
+		for _, param := range strings.Split(link[end+1:], ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+				switch strings.ToLower(rel) {
+				case "next":
+					links.HasNext, links.Next = true, page
+				case "last":
+					links.Last = page
+				}
+			}
+		}
+	}
+	return links
+}
+
+// splitLinks はLinkヘッダーを個々のリンクに分けます。
+// URLの中（< と > の間）のカンマでは分けず、> で閉じられないまま次の < が現れた場合は、そこから新しいリンクとして扱います。
+func splitLinks(header string) []string {
+	var links []string
+	start, inURL := 0, false
+	for i, c := range header {
+		switch c {
+		case '<':
+			if inURL {
+				links = append(links, header[start:i])
+				start = i
+			}
+			inURL = true
+		case '>':
+			inURL = false
+		case ',':
+			if !inURL {
+				links = append(links, header[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(links, header[start:])
+}
+
+// nextPage は現在のページの次に取得するページ番号を返します。
+// rel="next" のURLにページ番号がない場合は、現在のページの次の番号を返します。
+func (l pageLinks) nextPage(page int) int {
+	if l.Next > page {
+		return l.Next
+	}
+	return page + 1
+}
+
+// walkPages は1ページ目から、Linkヘッダーの rel="next" が示すページを順にたどります。
+// ページごとに fetch で取得してから visit を呼び、rel="next" がない場合や visit が false を返した場合に終了します。
+// ページ送りの判断はここだけで行い、各APIの取得処理はページの取得と中身の処理だけを受け持ちます。
+//
+// パラメータ:
+//   - fetch: 指定したページを取得し、そのページのLinkヘッダーの情報を返す関数
+//   - visit: 取得したページを処理する関数（続けて次のページを取得する場合は true を返す）
+//
+// 戻り値:
+//   - error: fetch または visit が返したエラー、成功時はnil
+func walkPages(fetch func(page int) (pageLinks, error), visit func(page int, links pageLinks) (bool, error)) error {
+	page := 1 // ページネーション用の初期ページ番号
+	for {
+		links, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		more, err := visit(page, links)
+		if err != nil {
+			return err
+		}
+		// 最後のページ（Linkヘッダーに rel="next" がない）の場合は終了
+		if !more || !links.HasNext {
+			return nil
+		}
+		page = links.nextPage(page) // 次のページへ
+	}
+}
+
+// paginate は r のページを1ページ目から順に取得して T にデコードし、ページごとに visit を呼びます。
+// r.Query の page はページごとに設定し直します（per_page などの他のパラメータはそのまま送ります）。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - client: リクエストに使用するHTTPクライアント
+//   - r: 1ページ目のリクエストの内容
+//   - visit: デコードしたページを処理する関数（続けて次のページを取得する場合は true を返す）
+//
+// 戻り値:
+//   - apiResponse: 最後に取得したページのステータスコード・ヘッダー・ページ送りの情報（失敗した場合も設定する）
+//   - error: エラーが発生した場合はエラー情報（ステータスコードが200以外の場合は *apiError）、成功時はnil
+func paginate[T any](ctx context.Context, client *http.Client, r apiRequest, visit func(page int, body T, resp apiResponse) (bool, error)) (apiResponse, error) {
+	var (
+		body T           // 最後に取得したページのデコード結果
+		resp apiResponse // 最後に取得したページのレスポンスの情報
+	)
+	err := walkPages(func(page int) (pageLinks, error) {
+		var err error
+		body = *new(T)
+		resp, err = fetchJSON(ctx, client, pageRequest(r, page), &body)
+		return resp.Links, err
+	}, func(page int, _ pageLinks) (bool, error) {
+		return visit(page, body, resp)
+	})
+	return resp, err
+}
+
+// pageRequest は r のクエリの page を指定したページ番号にしたリクエストを返します（r は変更しません）。
+func pageRequest(r apiRequest, page int) apiRequest {
+	q := url.Values{}
+	for k, v := range r.Query {
+		q[k] = append([]string(nil), v...)
+	}
+	q.Set("page", strconv.Itoa(page))
+	r.Query = q
+	return r
+}
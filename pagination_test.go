@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"  // リクエストのコンテキストに使用
+	"errors"   // エラーの判定に使用
+	"fmt"      // 結果の比較に使用
+	"net/http" // テスト用のサーバーの実装に使用
+	"net/url"  // リクエストのクエリの確認に使用
+	"testing"  // テストの実行に使用
+)
+
+// TestParseLinkHeader は1ページだけの場合・複数ページの場合・形式が正しくない場合のLinkヘッダーの解析を確認します。
+func TestParseLinkHeader(t *testing.T) {
+	const base = "https://api.github.com/repos/o/r/pulls"
+	tests := []struct {
+		name   string
+		header string
+		want   pageLinks
+	}{
+		{"no header", "", pageLinks{}},
+		{"single page", `<` + base + `?page=1>; rel="last", <` + base + `?page=1>; rel="first"`, pageLinks{Last: 1}},
+		{"first of many", `<` + base + `?per_page=100&page=2>; rel="next", <` + base + `?per_page=100&page=5>; rel="last"`, pageLinks{HasNext: true, Next: 2, Last: 5}},
+		{"middle page", `<` + base + `?page=1>; rel="first", <` + base + `?page=2>; rel="prev", <` + base + `?page=4>; rel="next", <` + base + `?page=5>; rel="last"`, pageLinks{HasNext: true, Next: 4, Last: 5}},
+		{"last page", `<` + base + `?page=4>; rel="prev", <` + base + `?page=1>; rel="first"`, pageLinks{}},
+		{"no spaces", `<` + base + `?page=2>;rel="next",<` + base + `?page=3>;rel="last"`, pageLinks{HasNext: true, Next: 2, Last: 3}},
+		{"unquoted and upper-case rel", `<` + base + `?page=2>; REL=Next, <` + base + `?page=3>; rel=last`, pageLinks{HasNext: true, Next: 2, Last: 3}},
+		{"several rel values", `<` + base + `?page=3>; rel="next last"`, pageLinks{HasNext: true, Next: 3, Last: 3}},
+		{"other params", `<` + base + `?page=2>; title="x"; rel="next"`, pageLinks{HasNext: true, Next: 2}},
+		{"comma in URL", `<` + base + `?labels=a,b&page=3>; rel="next", <` + base + `?labels=a,b&page=9>; rel="last"`, pageLinks{HasNext: true, Next: 3, Last: 9}},
+		{"next without page", `<https://api.github.com/resource?cursor=abc>; rel="next"`, pageLinks{HasNext: true}},
+		// 形式が正しくないリンクは無視する
+		{"missing brackets", base + `?page=2; rel="next"`, pageLinks{}},
+		{"unclosed bracket", `<` + base + `?page=2; rel="next"`, pageLinks{}},
+		{"unclosed bracket before a valid link", `<` + base + `?page=2; rel="next", <` + base + `?page=3>; rel="last"`, pageLinks{Last: 3}},
+		{"missing rel", `<` + base + `?page=2>`, pageLinks{}},
+		{"invalid URL", `<%zz?page=2>; rel="next"`, pageLinks{}},
+		{"garbage", `,,; rel=next,<>`, pageLinks{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinkHeader(tt.header); got != tt.want {
+				t.Errorf("parseLinkHeader(%q) = %+v, want %+v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNextPage は rel="next" のURLのページ番号を使い、ない場合は現在のページの次に進むことを確認します。
+func TestNextPage(t *testing.T) {
+	if got := (pageLinks{HasNext: true, Next: 4}).nextPage(2); got != 4 {
+		t.Errorf("nextPage with page=4 in rel=next = %d, want 4", got)
+	}
+	if got := (pageLinks{HasNext: true}).nextPage(2); got != 3 {
+		t.Errorf("nextPage without a page in rel=next = %d, want 3", got)
+	}
+	// 前に戻るページ番号は使わない（同じページを繰り返し取得しないため）
+	if got := (pageLinks{HasNext: true, Next: 1}).nextPage(2); got != 3 {
+		t.Errorf("nextPage with an earlier page in rel=next = %d, want 3", got)
+	}
+}
+
+// TestPaginate は paginate が rel="next" のある間だけページをたどり、空のページを取得せず、
+// visit が false を返した場合とエラーの場合にそこで止まることを確認します。
+func TestPaginate(t *testing.T) {
+	var prs []map[string]interface{}
+	for n := 5; n >= 1; n-- {
+		prs = append(prs, fakePR(n, fmt.Sprintf("2024-05-%02dT00:00:00Z", n)))
+	}
+	q := url.Values{}
+	q.Add("state", "closed")
+	q.Add("per_page", "2")
+
+	t.Run("all pages", func(t *testing.T) {
+		server := newFakeGitHub(t, prs, nil)
+		var numbers, pages []int
+		_, err := paginate(context.Background(), http.DefaultClient, apiRequest{URL: server.apiURL() + "/repos/o/r/pulls", Query: q, Token: "t"}, func(page int, body []PullRequest, resp apiResponse) (bool, error) {
+			pages = append(pages, page)
+			for _, pr := range body {
+				numbers = append(numbers, pr.Number)
+			}
+			if resp.Links.Last != 3 {
+				t.Errorf("page %d: rel=last page %d, want 3", page, resp.Links.Last)
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(numbers) != "[5 4 3 2 1]" || fmt.Sprint(pages) != "[1 2 3]" {
+			t.Errorf("visited pages %v with PRs %v, want pages [1 2 3] with PRs [5 4 3 2 1]", pages, numbers)
+		}
+		// 最後のページの後に空のページを取得しない
+		if n := server.requestCount(); n != 3 {
+			t.Errorf("sent %d requests, want 3: %v", n, server.requests)
+		}
+		for _, uri := range server.requests {
+			u, _ := url.Parse(uri)
+			if u.Query().Get("state") != "closed" || u.Query().Get("per_page") != "2" {
+				t.Errorf("request %s lost the query parameters", uri)
+			}
+		}
+		if q.Get("page") != "" {
+			t.Errorf("paginate modified the caller's query: %v", q)
+		}
+	})
+
+	t.Run("stop early", func(t *testing.T) {
+		server := newFakeGitHub(t, prs, nil)
+		_, err := paginate(context.Background(), http.DefaultClient, apiRequest{URL: server.apiURL() + "/repos/o/r/pulls", Query: q, Token: "t"}, func(page int, _ []PullRequest, _ apiResponse) (bool, error) {
+			return page < 2, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := server.requestCount(); n != 2 {
+			t.Errorf("sent %d requests after visit returned false on page 2, want 2", n)
+		}
+	})
+
+	t.Run("visit error", func(t *testing.T) {
+		server := newFakeGitHub(t, prs, nil)
+		stop := errors.New("stop")
+		_, err := paginate(context.Background(), http.DefaultClient, apiRequest{URL: server.apiURL() + "/repos/o/r/pulls", Query: q, Token: "t"}, func(int, []PullRequest, apiResponse) (bool, error) {
+			return true, stop
+		})
+		if !errors.Is(err, stop) || server.requestCount() != 1 {
+			t.Errorf("paginate returned %v after %d requests, want the visit error after 1", err, server.requestCount())
+		}
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		server := newFakeGitHub(t, prs, nil)
+		resp, err := paginate(context.Background(), http.DefaultClient, apiRequest{URL: server.apiURL() + "/repos/o/missing/pulls/x/y", Query: q, Token: "t"}, func(int, []PullRequest, apiResponse) (bool, error) {
+			t.Error("visit called for a failed page")
+			return true, nil
+		})
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || resp.Status != http.StatusNotFound {
+			t.Errorf("paginate returned status %d and %v, want 404 and an *apiError", resp.Status, err)
+		}
+	})
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"regexp"  // 表の区切り行などの判定に使用
+	"strings" // 文字列操作に使用
+)
+
+// htmlCommentPattern はHTMLコメント（PRテンプレートの説明文など、複数行を含む）にマッチします。
+var htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?(-->|$)`)
+
+// Markdownのブロック要素の判定に使う正規表現です。
+var (
+	plainHeadingPattern   = regexp.MustCompile(`^ {0,3}#{1,6}(?:\s+|$)`)              // 見出し
+	plainListPattern      = regexp.MustCompile(`^(\s*)(?:[-*+]|\d{1,9}[.)])\s+(.*)$`) // リスト項目
+	plainRulePattern      = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	plainTableDelimiter   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`) // 表の区切り行
+	plainHTMLTagPattern   = regexp.MustCompile(`</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`)
+	plainLineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// markdownToPlain はMarkdownの本文を読みやすいプレーンテキストに変換します。
+// 行ごとにブロック要素（見出し・リスト・表・引用・コードブロック）を判定し、その中身のインライン要素を変換します。
+//   - リンクはリンクテキストの後ろに括弧でURLを付ける（"text (url)"）
+//   - 強調の記号、HTMLコメント、HTMLタグは取り除く
+//   - リストは入れ子の深さを保ったまま "- " で始まる行にする
+//   - 見出しは記号を取り除いた通常の行にする
+//   - 表はセルを " | " で区切った行にし、区切り行は取り除く
+//   - コードブロックはフェンスを取り除いて中身をそのまま残す
+//
+// パラメータ:
+//   - body: コメント本文（Markdown）
+//
+// 戻り値:
+//   - string: プレーンテキスト
+func markdownToPlain(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	var out []string
+	fenceChar, fenceLen := byte(0), 0 // 現在開いているフェンスの文字と長さ（開いていなければ0）
+	inComment := false                // 複数行にわたるHTMLコメントの中かどうか
+	for _, line := range strings.Split(body, "\n") {
+		if char, n, ok := fenceMarker(line); ok && !inComment {
+			switch {
+			case fenceLen == 0:
+				fenceChar, fenceLen = char, n
+				continue
+			case char == fenceChar && n >= fenceLen && strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), string(char))) == "":
+				fenceChar, fenceLen = 0, 0
+				continue
+			}
+		}
+		if fenceLen > 0 {
+			// コードブロックの中はそのまま残す
+			out = append(out, line)
+			continue
+		}
+		// HTMLコメントを取り除く（行をまたぐコメントは閉じるまで読み飛ばす）
+		if inComment {
+			end := strings.Index(line, "-->")
+			if end < 0 {
+				continue
+			}
+			line, inComment = line[end+3:], false
+		}
+		line = htmlCommentPattern.ReplaceAllStringFunc(line, func(m string) string {
+			if !strings.HasSuffix(m, "-->") {
+				inComment = true
+			}
+			return ""
+		})
+		if plain, keep := plainBlockLine(line); keep {
+			out = append(out, plain)
+		}
+	}
+	return collapseBlankLines(strings.Join(out, "\n"))
+}
+
+// plainBlockLine はコードブロック外の1行をプレーンテキストに変換します。
+// 表の区切り行や水平線のように出力しない行の場合は keep に false を返します。
+func plainBlockLine(line string) (plain string, keep bool) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return "", true
+	case plainRulePattern.MatchString(line):
+		return "", false
+	case plainHeadingPattern.MatchString(line):
+		// 見出しは記号（閉じ側の # も含む）を取り除く
+		text := plainHeadingPattern.ReplaceAllString(line, "")
+		text = strings.TrimRight(strings.TrimRight(text, " "), "#")
+		return plainInline(strings.TrimSpace(text)), true
+	case strings.HasPrefix(trimmed, ">"):
+		// 引用は "> " を残し、中身を変換する
+		text := strings.TrimSpace(strings.TrimLeft(trimmed, "> "))
+		if p, ok := plainBlockLine(text); ok {
+			return "> " + p, true
+		}
+		return ">", true
+	case strings.Contains(trimmed, "|") && plainTableDelimiter.MatchString(trimmed) && strings.Contains(trimmed, "-"):
+		return "", false
+	case strings.HasPrefix(trimmed, "|"):
+		// 表の行はセルを " | " で区切る
+		cells := splitTableRow(trimmed)
+		for i, cell := range cells {
+			cells[i] = plainInline(cell)
+		}
+		return strings.Join(cells, " | "), true
+	}
+	if m := plainListPattern.FindStringSubmatch(line); m != nil {
+		// タブは4文字として深さを数え、2文字単位のインデントにそろえる
+		depth := len(strings.ReplaceAll(m[1], "\t", "    ")) / 2
+		item := m[2]
+		// タスクリストのチェックボックスは [x] / [ ] の表記として残す
+		if strings.HasPrefix(item, "[ ] ") || strings.HasPrefix(strings.ToLower(item), "[x] ") {
+			return strings.Repeat("  ", depth) + "- " + item[:4] + plainInline(item[4:]), true
+		}
+		return strings.Repeat("  ", depth) + "- " + plainInline(item), true
+	}
+	return plainInline(strings.TrimRight(line, " \t")), true
+}
+
+// splitTableRow は表の1行をセルに分割します。インラインコード内とエスケープされた "|" では分割しません。
+func splitTableRow(row string) []string {
+	row = strings.TrimPrefix(strings.TrimSuffix(row, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(row); i++ {
+		switch c := row[i]; {
+		case c == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// plainInline は1行分のインライン要素をプレーンテキストに変換します。
+// インラインコードの中身はそのまま残し、それ以外の部分でリンク・強調・HTMLタグを変換します。
+func plainInline(text string) string {
+	var b strings.Builder
+	textStart := 0
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(text) && text[i+n] == '`' {
+			n++
+		}
+		end := findBacktickRun(text, i+n, n)
+		if end < 0 {
+			i += n
+			continue
+		}
+		b.WriteString(plainSpan(text[textStart:i]))
+		b.WriteString(strings.TrimSpace(text[i+n : end]))
+		i = end + n
+		textStart = i
+	}
+	b.WriteString(plainSpan(text[textStart:]))
+	return b.String()
+}
+
+// plainSpan はコードを含まないテキスト断片のリンク・強調・HTMLタグ・エスケープを変換します。
+func plainSpan(text string) string {
+	text = plainLineBreakPattern.ReplaceAllString(text, " ")
+	text = plainHTMLTagPattern.ReplaceAllString(text, "")
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!|~<>", text[i+1]) >= 0:
+			// バックスラッシュエスケープは記号そのものにする
+			b.WriteByte(text[i+1])
+			i += 2
+			continue
+		case c == '[' || (c == '!' && i+1 < len(text) && text[i+1] == '['):
+			// リンクと画像は "text (url)" にする
+			if label, url, next, ok := parseInlineLink(text, i); ok {
+				label = plainSpan(label)
+				switch {
+				case url == "" || label == url:
+					b.WriteString(firstNonEmpty(label, url))
+				case label == "":
+					b.WriteString(url)
+				default:
+					b.WriteString(label + " (" + url + ")")
+				}
+				i = next
+				continue
+			}
+		case c == '<':
+			// 自動リンク <https://...> は山括弧を取り除く
+			if end := strings.IndexByte(text[i:], '>'); end > 0 && strings.Contains(text[i:i+end], "://") && !strings.ContainsAny(text[i+1:i+end], " \t") {
+				b.WriteString(text[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		case c == '*' || c == '_' || c == '~':
+			// 強調と取り消し線の記号を取り除く
+			// 前後が空白の記号や、snake_case の "_"、2*3 のような数式の "*" は通常の文字として残す
+			n := 1
+			for i+n < len(text) && text[i+n] == c {
+				n++
+			}
+			before, after := byteBefore(text, i), byteAt(text, i+n)
+			intraword := (c == '_' && isWordByte(before) && isWordByte(after)) || (c == '*' && isDigitByte(before) && isDigitByte(after))
+			if (c != '~' || n >= 2) && isEmphasisDelimiter(text, i, n) && !intraword {
+				i += n
+				continue
+			}
+			b.WriteString(text[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// parseInlineLink は start の位置から始まる [text](url) または ![alt](url) を解析します。
+//
+// 戻り値:
+//   - string: リンクテキスト
+//   - string: URL（タイトルは除く）
+//   - int: リンクの直後の位置
+//   - bool: リンクとして解析できたかどうか
+func parseInlineLink(text string, start int) (string, string, int, bool) {
+	open := start
+	if text[open] == '!' {
+		open++
+	}
+	// 角括弧の対応を取りながらリンクテキストの終わりを探す
+	depth := 0
+	closeBracket := -1
+	for j := open; j < len(text) && closeBracket < 0; j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeBracket = j
+			}
+		}
+	}
+	if closeBracket < 0 || closeBracket+1 >= len(text) || text[closeBracket+1] != '(' {
+		return "", "", 0, false
+	}
+	closeParen := -1
+	depth = 0
+	for j := closeBracket + 1; j < len(text) && closeParen < 0; j++ {
+		switch text[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				closeParen = j
+			}
+		}
+	}
+	if closeParen < 0 {
+		return "", "", 0, false
+	}
+	dest := strings.TrimSpace(text[closeBracket+2 : closeParen])
+	// リンクのタイトル（"..."）を取り除く
+	if sp := strings.IndexAny(dest, " \t"); sp >= 0 {
+		dest = dest[:sp]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	return text[open+1 : closeBracket], dest, closeParen + 1, true
+}
+
+// isEmphasisDelimiter は位置 i からの n 文字の記号列が強調の開始または終了になり得るかを判定します。
+// 前後のどちらかが空白でない場合に強調の記号とみなします。
+func isEmphasisDelimiter(text string, i, n int) bool {
+	before, after := byteBefore(text, i), byteAt(text, i+n)
+	return !isSpaceByte(after) || !isSpaceByte(before)
+}
+
+// byteBefore は位置 i の直前のバイトを返します（先頭の場合は空白とみなす）。
+func byteBefore(text string, i int) byte {
+	if i == 0 {
+		return ' '
+	}
+	return text[i-1]
+}
+
+// byteAt は位置 i のバイトを返します（末尾を超える場合は空白とみなす）。
+func byteAt(text string, i int) byte {
+	if i >= len(text) {
+		return ' '
+	}
+	return text[i]
+}
+
+// isSpaceByte は空白文字かどうかを判定します。
+func isSpaceByte(c byte) bool { return c == ' ' || c == '\t' || c == '\n' }
+
+// isDigitByte は数字かどうかを判定します。
+func isDigitByte(c byte) bool { return '0' <= c && c <= '9' }
+
+// isWordByte は英数字（またはマルチバイト文字の一部）かどうかを判定します。
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// firstNonEmpty は空でない最初の文字列を返します。
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// collapseBlankLines は連続する空行を1行にまとめ、前後の空行を取り除きます。
+func collapseBlankLines(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for _, line := range lines {
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// plainComments は各コメントの本文をプレーンテキストに変換します。
+// HTML出力でも変換後の本文が表示されるよう、レンダリング済みHTMLは破棄します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func plainComments(comments []Comment) {
+	for i := range comments {
+		comments[i].Body = markdownToPlain(comments[i].Body)
+		comments[i].BodyHTML = ""
+	}
+}
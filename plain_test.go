@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"          // ゴールデンファイルの更新フラグに使用
+	"os"            // ゴールデンファイルの読み書きに使用
+	"path/filepath" // ゴールデンファイルのパスの組み立てに使用
+	"strings"       // 拡張子の置き換えに使用
+	"testing"       // テストの実行に使用
+)
+
+// updateGolden は testdata のゴールデンファイルを現在の出力で書き換えるフラグです（go test -run TestMarkdownToPlainGolden -update）。
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata with the current output")
+
+// TestMarkdownToPlainGolden は testdata/plain の各 .md を --plain で変換した結果が、同じ名前の .txt（ゴールデンファイル）と一致することを確認します。
+func TestMarkdownToPlainGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "plain", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden inputs in testdata/plain")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".md")
+		t.Run(name, func(t *testing.T) {
+			body, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := markdownToPlain(string(body)) + "\n"
+			golden := strings.TrimSuffix(input, ".md") + ".txt"
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("markdownToPlain(%s) =\n%s\nwant (%s):\n%s", input, got, golden, want)
+			}
+			// CRLFの本文も同じ結果になる
+			if crlf := markdownToPlain(strings.ReplaceAll(string(body), "\n", "\r\n")) + "\n"; crlf != got {
+				t.Errorf("markdownToPlain of the CRLF body differs:\n%s", crlf)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"sync"     // 並行して取得するPRからのキャッシュの更新の排他制御に使用
+)
+
+// prDetails はPRの詳細（/pulls/N）の取得結果をPRごとにキャッシュします。
+// 一覧のレスポンスに含まれない情報（merged_by など）を複数の機能が必要とする場合も、PRごとに1回だけ取得します。
+// 複数のゴルーチンから同時に使えます。
+type prDetails struct {
+	mu     sync.Mutex      // cache と errors を保護する
+	ctx    context.Context // 実行全体の期限を伝えるコンテキスト
+	owner  string
+	repo   string
+	token  string
+	client *http.Client        // 詳細の取得で使い回すHTTPクライアント
+	cache  map[int]PullRequest // PR番号 → 取得済みの詳細
+	errors map[int]error       // PR番号 → 取得に失敗したときのエラー（同じPRを再取得しない）
+}
+
+// newPRDetails はPRの詳細を取得するためのキャッシュを作成します。
+func newPRDetails(ctx context.Context, owner, repo, token string) *prDetails {
+	return &prDetails{
+		ctx:    ctx,
+		owner:  owner,
+		repo:   repo,
+		token:  token,
+		client: apiClient,
+		cache:  make(map[int]PullRequest),
+		errors: make(map[int]error),
+	}
+}
+
+// has はPRの詳細を取得済みか（取得に失敗した場合を含む）を返します。
+func (d *prDetails) has(number int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, cached := d.cache[number]
+	_, failed := d.errors[number]
+	return cached || failed
+}
+
+// get はPRの詳細を返します。初めて要求されたPRだけをAPIから取得します。
+//
+// パラメータ:
+//   - number: プルリクエスト番号
+//
+// 戻り値:
+//   - PullRequest: PRの詳細
+//   - error: 取得に失敗した場合のエラー情報
+func (d *prDetails) get(number int) (PullRequest, error) {
+	d.mu.Lock()
+	pr, cached := d.cache[number]
+	err, failed := d.errors[number]
+	d.mu.Unlock()
+	if cached {
+		return pr, nil
+	}
+	if failed {
+		return PullRequest{}, err
+	}
+	// 取得中はロックを外し、他のPRの詳細の取得を待たせない
+	pr, err = fetchPullRequest(d.ctx, d.owner, d.repo, number, d.token, d.client)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.errors[number] = err
+		return PullRequest{}, err
+	}
+	d.cache[number] = pr
+	return pr, nil
+}
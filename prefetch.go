@@ -0,0 +1,68 @@
+package main
+
+import "sync" // 取得の打ち切りを一度だけ通知するために使用
+
+// prFetch は1つのPRのレビューコメントの取得結果です。
+type prFetch struct {
+	Comments  []Comment // 取得したコメント
+	Available int       // PRのコメントの総数（--max-comments-per-pr で打ち切った場合は取得数より多い）
+	Err       error     // 取得に失敗した場合のエラー
+}
+
+// commentPrefetcher は最大 --concurrency 個のPRのコメントを並行して先に取得し、結果を元のPRの順に受け渡します。
+// 取得が完了した順に関係なく、呼び出し側はPRの順に結果を受け取って処理・保存するため、出力は直列に取得した場合と変わりません。
+// 取得中のPRと、呼び出し側が処理中のPRの数は同時実行数までに制限し、処理中のPRの枠は呼び出し側が次のPRを求めたときに空けます。
+// そのため、呼び出し側が次のPRを求める前に stop を呼べば、それ以降に新しいPRの取得は始まりません。
+type commentPrefetcher struct {
+	results []chan prFetch // PRの順の取得結果（それぞれ1件だけ送られる）
+	window  chan struct{}  // 取得中または処理中のPRの数を制限する
+	done    chan struct{}  // 閉じると新しい取得を始めない
+	once    sync.Once
+}
+
+// newCommentPrefetcher はPRのコメントの並行した取得を始めます。
+//
+// パラメータ:
+//   - prs: コメントを取得するPR（この順に結果を受け渡す）
+//   - workers: 同時に取得するPRの数
+//   - fetch: 1つのPRのコメントを取得する関数（複数のゴルーチンから同時に呼び出される）
+//
+// 戻り値:
+//   - *commentPrefetcher: 取得結果を受け取るための commentPrefetcher
+func newCommentPrefetcher(prs []PullRequest, workers int, fetch func(PullRequest) prFetch) *commentPrefetcher {
+	p := &commentPrefetcher{
+		results: make([]chan prFetch, len(prs)),
+		window:  make(chan struct{}, workers),
+		done:    make(chan struct{}),
+	}
+	for i := range p.results {
+		p.results[i] = make(chan prFetch, 1)
+	}
+	go func() {
+		for i, pr := range prs {
+			select {
+			case p.window <- struct{}{}:
+			case <-p.done:
+				return
+			}
+			go func(i int, pr PullRequest) {
+				p.results[i] <- fetch(pr)
+			}(i, pr)
+		}
+	}()
+	return p
+}
+
+// next は i 番目のPRの取得結果を、取得が終わるまで待って返します。i は0から順に呼び出します。
+// 呼び出すと、処理を終えた1つ前のPRの枠を空け、次のPRの取得を始められるようにします。
+func (p *commentPrefetcher) next(i int) prFetch {
+	if i > 0 {
+		<-p.window
+	}
+	return <-p.results[i]
+}
+
+// stop は新しいPRの取得を始めないようにします。取得中のPRは完了しますが、その結果は使いません。
+func (p *commentPrefetcher) stop() {
+	p.once.Do(func() { close(p.done) })
+}
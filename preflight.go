@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"      // エラーメッセージの作成に使用
+	"io"       // レスポンスの本文の読み込みに使用
+	"net/http" // HTTPクライアントの実装を提供
+	"strings"  // ヘッダーの解析に使用
+)
+
+// preflightTarget は事前チェックで確認する、リポジトリとそのリポジトリに使うトークンです。
+type preflightTarget struct {
+	Owner     string // オーナー名
+	Repo      string // リポジトリ名
+	Token     string // このリポジトリに使うトークン
+	TokenName string // トークンの読み込み元の表示名（トークンの値は含まない）
+	App       bool   // GitHub App のインストールトークンを使うか
+}
+
+// preflightResponse は事前チェックのリクエストのステータスコード・ヘッダー・エラーです。
+type preflightResponse struct {
+	Status int
+	Header http.Header
+	Err    *apiError // ステータスコードが200以外の場合のエラー
+}
+
+// preflightGet は事前チェックのGETリクエストを送ります。
+func preflightGet(client *http.Client, url, token string) (preflightResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return preflightResponse{}, err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", mediaTypeJSON)
+	resp, err := client.Do(req)
+	if err != nil {
+		return preflightResponse{}, err
+	}
+	defer resp.Body.Close()
+	r := preflightResponse{Status: resp.StatusCode, Header: resp.Header}
+	if resp.StatusCode != http.StatusOK {
+		r.Err = newAPIError(resp)
+	} else {
+		io.Copy(io.Discard, resp.Body)
+	}
+	return r, nil
+}
+
+// tokenScopes はクラシックな個人用アクセストークンのスコープ（X-OAuth-Scopes）を返します。
+// ヘッダーがない場合（fine-grained トークンやインストールトークン）は false を返します。
+func tokenScopes(h http.Header) ([]string, bool) {
+	values := h.Values("X-OAuth-Scopes")
+	if len(values) == 0 {
+		return nil, false
+	}
+	var scopes []string
+	for _, s := range strings.Split(strings.Join(values, ","), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, true
+}
+
+// ssoURL は X-GitHub-SSO ヘッダー（例: required; url=https://github.com/orgs/acme/sso?authorization_request=...）から、認可に使うURLを返します。
+func ssoURL(h http.Header) (string, bool) {
+	v := h.Get("X-GitHub-SSO")
+	if !strings.HasPrefix(v, "required") {
+		return "", false
+	}
+	_, u, _ := strings.Cut(v, "url=")
+	return strings.TrimSpace(u), true
+}
+
+// preflight はPRの取得を始める前に、トークンと対象のリポジトリを確認します。
+// 各トークンで /rate_limit（レート制限に数えられません）を呼んで有効かどうかを確かめ、各リポジトリのエンドポイントを呼んで、
+// トークンの無効・スコープの不足・リポジトリが存在しない（または見えない）・SAML SSO の認可が必要、を区別した対処方法を返します。
+//
+// パラメータ:
+//   - base: トークンを切り替えずにリクエストを送るトランスポート（各トークンを個別に確認するため）
+//   - targets: 対象のリポジトリとそのリポジトリに使うトークン
+//   - installationID: GitHub App のインストールとして認証する場合はインストールのID（それ以外は0）
+//
+// 戻り値:
+//   - error: 問題が見つかった場合は対処方法を含むエラー情報、問題がない場合はnil
+func preflight(base http.RoundTripper, targets []preflightTarget, installationID int64) error {
+	tokenClient := &http.Client{Transport: base}
+	client := apiClient
+
+	scopes := make(map[string][]string) // トークン → クラシックなトークンのスコープ
+	checked := make(map[string]bool)
+	for _, t := range targets {
+		if checked[t.Token] {
+			continue
+		}
+		checked[t.Token] = true
+		resp, err := preflightGet(tokenClient, apiURL("/rate_limit"), t.Token)
+		if err != nil {
+			return err
+		}
+		switch resp.Status {
+		case http.StatusOK:
+		case http.StatusUnauthorized:
+			return accessDenied("the token from %s is invalid, expired or revoked (GitHub returned 401 %s); create a new token and pass it with --token", t.TokenName, resp.Err.Message)
+		default:
+			return fmt.Errorf("checking the token from %s: %w", t.TokenName, resp.Err)
+		}
+		if s, ok := tokenScopes(resp.Header); ok {
+			scopes[t.Token] = s
+		}
+	}
+
+	for _, t := range targets {
+		name := t.Owner + "/" + t.Repo
+		resp, err := preflightGet(client, apiURL("/repos/%s/%s", t.Owner, t.Repo), t.Token)
+		if err != nil {
+			return err
+		}
+		if u, ok := ssoURL(resp.Header); ok && resp.Status != http.StatusOK {
+			return accessDenied("the token from %s must be authorized for SAML single sign-on to access %s: open %s and authorize it", t.TokenName, name, u)
+		}
+		switch resp.Status {
+		case http.StatusOK:
+			continue
+		case http.StatusUnauthorized:
+			return accessDenied("the token from %s was rejected for %s (GitHub returned 401 %s); create a new token and pass it with --token", t.TokenName, name, resp.Err.Message)
+		case http.StatusNotFound:
+		default:
+			if resp.Status == http.StatusForbidden && !resp.Err.RateLimited {
+				return accessDenied("the token from %s is not allowed to read %s (GitHub returned 403 %s)", t.TokenName, name, resp.Err.Message)
+			}
+			return fmt.Errorf("checking %s: %w", name, resp.Err)
+		}
+		// GitHub は、存在しないリポジトリとトークンから見えないプライベートリポジトリのどちらにも 404 を返す
+		if t.App {
+			return accessDenied("GitHub App installation %d cannot access %s: install the app on this repository or use another --installation-id", installationID, name)
+		}
+		if s, classic := scopes[t.Token]; classic && !containsString(s, "repo") {
+			return accessDenied("%s was not found: it does not exist, or it is private and the token from %s lacks the repo scope (its scopes: %s); add the repo scope to the token", name, t.TokenName, strings.Join(s, ", "))
+		}
+		if owner, err := preflightGet(client, apiURL("/users/%s", t.Owner), t.Token); err == nil && owner.Status == http.StatusNotFound {
+			return accessDenied("%s was not found: the owner %s does not exist", name, t.Owner)
+		}
+		return accessDenied("%s was not found: it does not exist, or it is private and not visible to the token from %s (for a fine-grained token, grant it access to this repository; for an organization repository, check your membership)", name, t.TokenName)
+	}
+	return nil
+}
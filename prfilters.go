@@ -0,0 +1,163 @@
+package main
+
+import (
+	"regexp"  // Conventional Commits のタイトルの判定に使用
+	"strings" // ラベル名の比較に使用
+)
+
+// prFilter はPRの一覧に適用する絞り込み条件をまとめた構造体です。
+// コメントを取得する前に適用するため、除外されたPRはAPIの呼び出しを増やさず、--count にも数えません。
+type prFilter struct {
+	labels         []string        // すべて付与されている必要があるラベル（小文字）
+	anyLabels      []string        // いずれかが付与されている必要があるラベル（小文字）
+	authors        map[string]bool // PRの作成者（小文字、空なら全員）
+	excludeAuthors map[string]bool // 除外するPRの作成者（小文字）
+	skipBots       bool            // botが作成したPRを除外するか
+	botAccounts    map[string]bool // botとして扱う追加のアカウント（小文字）
+	titleMatch     []patternGroup  // タイトルが一致しなければならない正規表現（グループ間はAND）
+	excludeTitle   []patternGroup  // いずれかに一致したら除外するタイトルの正規表現
+	milestone      int             // PRが属するマイルストーンの番号（0なら絞り込まない）
+	milestoneTitle string          // マイルストーンのタイトル（--use-search の検索クエリに使用）
+	rangeCommits   map[string]bool // マージコミットが含まれていなければならないコミットの集合（--strict-range 指定時のみ）
+	reverts        *revertTracker  // 取り消されたPRと取り消しのPRの記録（--exclude-reverted 指定時のみ）
+	mergedBy       map[string]bool // PRをマージしたユーザー（小文字、空なら絞り込まない）
+	details        *prDetails      // 一覧に含まれない情報（merged_by）を取得するためのPRの詳細
+	touches        []string        // 変更されたファイルのいずれかが一致しなければならないglob（空なら絞り込まない）
+	concurrency    int             // 変更ファイルを並行して判定するPRの数
+	out            *console        // 詳細の取得に失敗した場合のエラーの出力先
+
+	Skipped  map[string]int // 除外された理由ごとのPR数
+	Examined int            // --touches のために変更ファイルを調べたPRの数
+}
+
+// PRが除外された理由です。実行の最後に理由ごとの件数を表示します。
+const (
+	skipLabel       = "--label"
+	skipAnyLabel    = "--any-label"
+	skipAuthor      = "--pr-author"
+	skipExcluded    = "--exclude-pr-author"
+	skipBotPR       = "--skip-bot-prs"
+	skipTitle       = "--title-match/--conventional-types"
+	skipExTitle     = "--exclude-title"
+	skipMilestone   = "--milestone"
+	skipMergedBy    = "--merged-by"
+	skipRange       = "--strict-range"
+	skipFewComments = "--min-comments" // コメントを取得した後に判定する
+)
+
+// keep はPRが条件を満たすかを判定し、満たさない場合は除外の理由ごとの件数を数えます。
+//
+// パラメータ:
+//   - pr: 判定するプルリクエスト
+//
+// 戻り値:
+//   - bool: 条件を満たす場合はtrue
+func (f *prFilter) keep(pr PullRequest) bool {
+	reason := f.skipReason(pr)
+	if reason == "" {
+		return true
+	}
+	f.skip(reason)
+	return false
+}
+
+// skip は除外したPRを理由ごとに数えます。
+func (f *prFilter) skip(reason string) {
+	if f.Skipped == nil {
+		f.Skipped = make(map[string]int)
+	}
+	f.Skipped[reason]++
+}
+
+// observe は一覧で見つけたマージ済みPRを、絞り込み条件を適用する前に記録します（--exclude-reverted 用）。
+func (f *prFilter) observe(pr PullRequest) {
+	if f.reverts != nil {
+		f.reverts.observe(pr)
+	}
+}
+
+// skipReason は1件のPRを除外する理由を返します。残す場合は空文字を返します。
+func (f *prFilter) skipReason(pr PullRequest) string {
+	if f.reverts != nil {
+		if reason := f.reverts.skipReason(pr); reason != "" {
+			return reason
+		}
+	}
+	if f.excludeAuthors[strings.ToLower(pr.User.Login)] {
+		return skipExcluded
+	}
+	if f.skipBots && isBot(&pr.User, f.botAccounts) {
+		return skipBotPR
+	}
+	if len(f.authors) > 0 && !f.authors[strings.ToLower(pr.User.Login)] {
+		return skipAuthor
+	}
+	for _, g := range f.excludeTitle {
+		if g.matches(pr.Title) {
+			return skipExTitle
+		}
+	}
+	for _, g := range f.titleMatch {
+		if !g.matches(pr.Title) {
+			return skipTitle
+		}
+	}
+	if f.milestone != 0 && (pr.Milestone == nil || pr.Milestone.Number != f.milestone) {
+		return skipMilestone
+	}
+	if len(f.labels) > 0 || len(f.anyLabels) > 0 {
+		names := lowerSet(pr.LabelNames())
+		for _, l := range f.labels {
+			if !names[l] {
+				return skipLabel
+			}
+		}
+		if len(f.anyLabels) > 0 {
+			found := false
+			for _, l := range f.anyLabels {
+				if names[l] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return skipAnyLabel
+			}
+		}
+	}
+	if f.rangeCommits != nil && !f.rangeCommits[pr.MergeCommitSHA] {
+		return skipRange
+	}
+	// マージしたユーザーは一覧に含まれないため、他の条件をすべて満たしたPRについてだけ詳細を取得する
+	if len(f.mergedBy) > 0 {
+		detail, err := f.details.get(pr.Number)
+		if err != nil {
+			f.out.Logf("Error fetching details of PR #%d for --merged-by: %v", pr.Number, err)
+			return skipMergedBy
+		}
+		if detail.MergedBy == nil || !f.mergedBy[strings.ToLower(detail.MergedBy.Login)] {
+			return skipMergedBy
+		}
+	}
+	return ""
+}
+
+// lowerAll は文字列の配列をすべて小文字にして返します。
+func lowerAll(values []string) []string {
+	lower := make([]string, len(values))
+	for i, v := range values {
+		lower[i] = strings.ToLower(v)
+	}
+	return lower
+}
+
+// conventionalTypesGroup は Conventional Commits の種類（feat、fix など）で始まるタイトルに一致する正規表現のグループを作成します。
+// "feat: ..."、"feat(api): ..."、"feat!: ..." の形式に大文字・小文字を区別せず一致します。
+func conventionalTypesGroup(types []string) patternGroup {
+	quoted := make([]string, len(types))
+	for i, t := range types {
+		quoted[i] = regexp.QuoteMeta(strings.TrimSpace(t))
+	}
+	re := regexp.MustCompile(`(?i)^\s*(?:` + strings.Join(quoted, "|") + `)(?:\([^)]*\))?!?:`)
+	return patternGroup{source: strings.Join(types, ","), patterns: []*regexp.Regexp{re}}
+}
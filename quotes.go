@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"      // 文字列操作に使用
+	"unicode/utf8" // 取り除いた文字数の計算に使用
+)
+
+// shortQuoteMaxRunes は残す短い引用の最大文字数です。
+// 1行だけの短い引用は、返信がどの部分に対するものかを示すために残します。
+const shortQuoteMaxRunes = 80
+
+// stripLeadingQuotes は返信の先頭にある引用ブロック（">" で始まる連続した行）を取り除きます。
+// 誤って内容を消さないよう、次の場合は取り除きません。
+//   - 1行だけの短い引用（返信の対象を示すためのもの。ただし後に画像やURLしかない場合は取り除く）
+//   - 引用の後に本文がない場合（引用を取り除くと何も残らない）
+//   - 本文の途中にある引用
+//
+// パラメータ:
+//   - body: コメント本文
+//
+// 戻り値:
+//   - string: 引用を取り除いた本文
+//   - int: 取り除いた文字数
+func stripLeadingQuotes(body string) (string, int) {
+	lines := strings.SplitAfter(body, "\n")
+	quoteLines := 0 // 先頭の引用ブロックの行数（ブロック間の空行を含む）
+	quoted := 0     // そのうち引用の行数
+	quotedRunes := 0
+scan:
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, ">"):
+			quoted++
+			quotedRunes += utf8.RuneCountInString(strings.TrimSpace(strings.TrimLeft(trimmed, "> ")))
+		case trimmed == "" && quoted > 0:
+			// 空行で区切られた引用ブロックが続く場合もまとめて取り除く
+		case trimmed == "":
+			// 本文先頭の空行
+		default:
+			break scan
+		}
+		quoteLines++
+	}
+	if quoted == 0 {
+		return body, 0
+	}
+	rest := strings.Join(lines[quoteLines:], "")
+	if strings.TrimSpace(rest) == "" {
+		return body, 0
+	}
+	// 短い引用は、続く本文が画像やURLだけの場合を除いて残す
+	if quoted == 1 && quotedRunes <= shortQuoteMaxRunes && !isMediaOnly(rest) {
+		return body, 0
+	}
+	return rest, utf8.RuneCountInString(body) - utf8.RuneCountInString(rest)
+}
+
+// stripQuotesFromReplies は返信コメントの本文の先頭にある引用を取り除きます。
+// ルートコメントは対象外です。引用を取り除いたコメントはレンダリング済みHTMLを破棄します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+//
+// 戻り値:
+//   - int: 取り除いた文字数の合計
+func stripQuotesFromReplies(comments []Comment) int {
+	removed := 0
+	for i := range comments {
+		if comments[i].InReplyToID == nil {
+			continue
+		}
+		body, n := stripLeadingQuotes(comments[i].Body)
+		if n > 0 {
+			comments[i].Body = body
+			comments[i].BodyHTML = ""
+			removed += n
+		}
+	}
+	return removed
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings" // 長い引用の作成に使用
+	"testing" // テストの実行に使用
+)
+
+// TestStripLeadingQuotes は返信の先頭の引用を取り除く場合と、誤って内容を消さないよう残す場合を確認します。
+func TestStripLeadingQuotes(t *testing.T) {
+	long := "> " + strings.Repeat("x", shortQuoteMaxRunes+1) + "\n"
+	tests := []struct {
+		name, body, want string
+	}{
+		{"short one-line quote kept", "> use a map here?\nYes, done.", "> use a map here?\nYes, done."},
+		{"long one-line quote removed", long + "Done.", "Done."},
+		{"multi-line quote removed", "> first line\n> second line\nFixed.", "Fixed."},
+		{"blank line after quote", "> first\n> second\n\nFixed.", "Fixed."},
+		{"several quote blocks", "> a\n\n> b\nFixed.", "Fixed."},
+		{"leading blank lines", "\n> a\n> b\nFixed.", "Fixed."},
+		{"nested quote", ">> a\n> b\nFixed.", "Fixed."},
+		{"short quote then image only", "> this?\n![screenshot](https://example.com/a.png)", "![screenshot](https://example.com/a.png)"},
+		{"short quote then URL only", "> this?\nhttps://example.com/a.png", "https://example.com/a.png"},
+		{"short quote then image and text", "> this?\n![s](https://example.com/a.png) see here", "> this?\n![s](https://example.com/a.png) see here"},
+		{"quote in the middle", "I think\n> a\n> b\nis wrong.", "I think\n> a\n> b\nis wrong."},
+		{"quote at the end", "Agreed with:\n> a\n> b", "Agreed with:\n> a\n> b"},
+		{"quote only", "> a\n> b\n", "> a\n> b\n"},
+		{"quote and blank lines only", "> a\n> b\n\n  \n", "> a\n> b\n\n  \n"},
+		{"no quote", "Fixed.", "Fixed."},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := stripLeadingQuotes(tt.body)
+			if got != tt.want {
+				t.Errorf("stripLeadingQuotes(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+			if removed := len([]rune(tt.body)) - len([]rune(got)); n != removed {
+				t.Errorf("stripLeadingQuotes(%q) reported %d removed characters, want %d", tt.body, n, removed)
+			}
+		})
+	}
+}
+
+// TestStripQuotesFromReplies は返信だけを対象にし、引用を取り除いたコメントのレンダリング済みHTMLを破棄することを確認します。
+func TestStripQuotesFromReplies(t *testing.T) {
+	parent := int64(1)
+	body := "> first\n> second\nFixed."
+	comments := []Comment{
+		{ID: 1, Body: body, BodyHTML: "<blockquote>…</blockquote>"},
+		{ID: 2, InReplyToID: &parent, Body: body, BodyHTML: "<blockquote>…</blockquote>"},
+	}
+	if n := stripQuotesFromReplies(comments); n != len([]rune(body))-len("Fixed.") {
+		t.Errorf("removed %d characters", n)
+	}
+	if comments[0].Body != body || comments[0].BodyHTML == "" {
+		t.Errorf("the root comment was changed: %+v", comments[0])
+	}
+	if comments[1].Body != "Fixed." || comments[1].BodyHTML != "" {
+		t.Errorf("reply = %+v", comments[1])
+	}
+}
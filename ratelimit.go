@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"       // 読み込んだレスポンスの本文の復元に使用
+	"io"          // レスポンスの本文の読み込みに使用
+	"log"         // 待機とレート制限の状況の表示に使用
+	"net/http"    // HTTPクライアントの実装を提供
+	"strconv"     // レート制限のヘッダーの変換に使用
+	"strings"     // APIのURLとエラーメッセージの判定に使用
+	"sync"        // 並行したリクエストからの更新の排他制御に使用
+	"sync/atomic" // 二次レート制限による待機の回数の数え上げに使用
+	"time"        // 制限が解除されるまでの待機に使用
+)
+
+// rateState は1つのトークンのレート制限の状況です。
+type rateState struct {
+	remaining int       // 直近のレスポンスの X-RateLimit-Remaining（まだわからない場合は -1）
+	reset     time.Time // 直近のレスポンスの X-RateLimit-Reset
+	announced time.Time // 待機を表示した解除時刻（並行したリクエストが同じ待機を何度も表示しないため）
+}
+
+// defaultSecondaryWait は二次レート制限のレスポンスに Retry-After がない場合に待つ時間です。
+const defaultSecondaryWait = 60 * time.Second
+
+// rateLimitTransport はすべてのGitHub APIのレスポンスの X-RateLimit-Remaining と X-RateLimit-Reset を読み、
+// 残りのリクエスト数が floor 以下になったら、制限が解除されるまで待ってから続きのリクエストを送る http.RoundTripper です。
+// レート制限の超過による 403・429 のレスポンスも、同じように解除を待って送り直します（GET の場合のみ）。
+// 二次レート制限（短時間に多くのリクエストを送った場合の制限）の 403・429 は、Retry-After（なければ defaultSecondaryWait）だけ待って、
+// retries 回まで同じリクエストを送り直します。
+// 待っている間は Ctrl-C で中断できます。検索APIは別の制限を持ち、searchMergedPRs が個別に扱うため対象外です。
+// 複数のトークンを切り替えて使う場合（pool が設定されている場合）、pool のトークンのリクエストは残りが少なくても待たず、
+// レート制限の超過のレスポンスもそのまま返して、別のトークンへの切り替えと、すべてのトークンを使い切った場合の待機を pool に任せます。
+type rateLimitTransport struct {
+	base    http.RoundTripper // 実際にリクエストを送るトランスポート
+	floor   int               // 待ち始める残りのリクエスト数（--rate-limit-floor）
+	noWait  bool              // 待たずにそのまま送るか（--no-wait）
+	verbose bool              // レスポンスごとにレート制限の状況を表示するか（--verbose）
+	retries int               // 二次レート制限で送り直す回数の上限（--secondary-retries）
+	pool    *tokenPool        // 複数のトークンを切り替えて使う場合のトークン（このトランスポートの上に重ねる。使わない場合はnil）
+
+	SecondaryPauses atomic.Int64 // 二次レート制限で待った回数
+
+	mu    sync.Mutex            // state を保護する
+	state map[string]*rateState // トークンのフィンガープリント → レート制限の状況
+}
+
+// newRateLimitTransport は rateLimitTransport を作成します。
+func newRateLimitTransport(base http.RoundTripper, floor int, noWait, verbose bool, retries int) *rateLimitTransport {
+	return &rateLimitTransport{base: base, floor: floor, noWait: noWait, verbose: verbose, retries: retries, state: make(map[string]*rateState)}
+}
+
+// stateFor はリクエストのトークンのレート制限の状況を返します。
+func (t *rateLimitTransport) stateFor(req *http.Request) (string, *rateState) {
+	key := tokenFingerprint(strings.TrimPrefix(req.Header.Get("Authorization"), "token "))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.state[key]
+	if !ok {
+		s = &rateState{remaining: -1}
+		t.state[key] = s
+	}
+	return key, s
+}
+
+// waitUntil は、トークンの残りが floor 以下の場合に制限が解除される時刻を返します（待つ必要がない場合はゼロ値）。
+func (t *rateLimitTransport) waitUntil(key string, s *rateState) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s.remaining < 0 || s.remaining > t.floor || !time.Now().Before(s.reset) {
+		return time.Time{}
+	}
+	if !s.announced.Equal(s.reset) {
+		s.announced = s.reset
+		log.Printf("Rate limit nearly exhausted (%d requests remaining for %s); waiting %s until %s (use --no-wait to fail instead)",
+			s.remaining, key, time.Until(s.reset).Round(time.Second), s.reset.Local().Format("15:04:05"))
+	}
+	return s.reset
+}
+
+// observe はレスポンスのレート制限のヘッダーを記録します。
+func (t *rateLimitTransport) observe(req *http.Request, s *rateState, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	var reset time.Time
+	if r, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(r, 0)
+	}
+	if t.verbose {
+		log.Printf("%s %s: %d (%d requests remaining, resets at %s)", req.Method, strings.TrimPrefix(req.URL.Path, apiPath()), resp.StatusCode, remaining, reset.Local().Format("15:04:05"))
+	}
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s.remaining, s.reset = remaining, reset
+}
+
+// sleep は指定された時間だけ待ちます。リクエストが取り消された場合は false を返します。
+func sleep(req *http.Request, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// peekBody はレスポンスの本文を小文字にして返します。読み込んだ本文は、呼び出し元が読めるよう元に戻します。
+func peekBody(resp *http.Response) string {
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return strings.ToLower(string(body))
+}
+
+// secondaryLimited はレスポンスが二次レート制限による 403・429 かどうかを判定し、待つ時間を返します。
+// Retry-After がある場合はその秒数、ない場合は defaultSecondaryWait です。
+func secondaryLimited(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+	retryAfter := resp.Header.Get("Retry-After")
+	if retryAfter == "" && !strings.Contains(peekBody(resp), "secondary rate limit") {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	return defaultSecondaryWait, true
+}
+
+// rateLimitedResponse はレスポンスがレート制限の超過による 403・429 かどうかを返します。
+func rateLimitedResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	return resp.Header.Get("X-RateLimit-Remaining") == "0" || strings.Contains(peekBody(resp), "rate limit")
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.String(), apiBaseURL+"/") || strings.HasPrefix(req.URL.Path, apiPath()+"/search/") {
+		return t.base.RoundTrip(req)
+	}
+	key, s := t.stateFor(req)
+	// トークンを切り替えて使う場合は、このトークンの解除を待たずに pool に別のトークンを選ばせる
+	waitForReset := !t.noWait && (t.pool == nil || !t.pool.member(strings.TrimPrefix(req.Header.Get("Authorization"), "token ")))
+	for attempt := 1; ; attempt++ {
+		if waitForReset {
+			if until := t.waitUntil(key, s); !until.IsZero() && !sleep(req, time.Until(until)+time.Second) {
+				return nil, req.Context().Err()
+			}
+		}
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+		t.observe(req, s, resp)
+		if wait, ok := secondaryLimited(resp); ok && req.Method == http.MethodGet {
+			if attempt > t.retries {
+				log.Printf("Secondary rate limit on %s %s; giving up after %d retries", req.Method, strings.TrimPrefix(req.URL.Path, apiPath()), t.retries)
+				return resp, nil
+			}
+			// 二次レート制限の場合は、Retry-After だけ待って同じリクエストを送り直す
+			t.SecondaryPauses.Add(1)
+			log.Printf("Secondary rate limit on %s %s; waiting %s before retry %d of %d", req.Method, strings.TrimPrefix(req.URL.Path, apiPath()), wait, attempt, t.retries)
+			resp.Body.Close()
+			if !sleep(req, wait) {
+				return nil, req.Context().Err()
+			}
+			continue
+		}
+		if !waitForReset || req.Method != http.MethodGet || !rateLimitedResponse(resp) {
+			return resp, nil
+		}
+		// レート制限を超過した場合は、解除を待って同じリクエストを送り直す
+		t.mu.Lock()
+		if s.reset.IsZero() || !time.Now().Before(s.reset) {
+			// 解除時刻がわからない場合は1分待つ
+			s.reset = time.Now().Add(time.Minute)
+		}
+		s.remaining = 0
+		t.mu.Unlock()
+		resp.Body.Close()
+	}
+}
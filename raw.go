@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256" // ファイル名に含めるクエリのハッシュに使用
+	"encoding/hex"  // ハッシュの文字列化に使用
+	"encoding/json" // マニフェストの書き込みに使用
+	"fmt"           // ファイル名の作成に使用
+	"log"           // 保存の失敗の表示に使用
+	"net/http"      // HTTPクライアントの実装を提供
+	"net/url"       // リクエストのURLの分解に使用
+	"os"            // ファイル操作に使用
+	"path/filepath" // 保存先のパスの組み立てに使用
+	"strconv"       // ページ番号の変換に使用
+	"strings"       // GitHub APIへのリクエストの判定とパスの分割に使用
+	"sync"          // マニフェストへの書き込みの排他制御に使用
+	"sync/atomic"   // 保存したレスポンスの数の集計に使用
+	"time"          // 取得日時の記録に使用
+)
+
+// rawDir は --save-raw で、GitHub APIのレスポンスをそのまま保存するディレクトリです。
+var rawDir = filepath.Join("comments", "raw")
+
+// rawManifestFile は --save-raw で保存した各ファイルが答えるリクエストを、1行に1件のJSONで記録するファイルです。
+// 同じファイルを何度も保存した場合は、後の行が有効です。
+const rawManifestFile = "manifest.jsonl"
+
+// rawEntry は --save-raw のマニフェストの1行分（保存した1つのレスポンス）を表す構造体です。
+type rawEntry struct {
+	File      string    `json:"file"`           // rawDir からの相対パス（区切りは /）
+	URL       string    `json:"url"`            // リクエストのURL
+	Accept    string    `json:"accept"`         // リクエストの Accept ヘッダー
+	Status    int       `json:"status"`         // HTTPステータスコード
+	ETag      string    `json:"etag,omitempty"` // レスポンスの ETag
+	Link      string    `json:"link,omitempty"` // レスポンスの Link ヘッダー（ページの移動に使用）
+	FetchedAt time.Time `json:"fetched_at"`     // レスポンスを受け取った日時（UTC）
+	Size      int64     `json:"size"`           // 本文のバイト数
+	SHA256    string    `json:"sha256"`         // 本文のハッシュ
+}
+
+// rawTransport はGitHub APIの GET リクエストに対する 200 のレスポンスの本文を、デコードする前のバイト列のまま rawDir に保存する http.RoundTripper です。
+// 保存先はAPIのパスをディレクトリにした決まった名前（例: repos/o/r/pulls/3/comments/page_0002_1a2b3c4d.json）で、
+// 同じリクエストは同じファイルになります。HTTPキャッシュ（cacheTransport）の外側に置くため、304 でキャッシュから返した本文も保存します。
+type rawTransport struct {
+	base http.RoundTripper // 実際にリクエストを送るトランスポート
+	dir  string            // 保存先（rawDir）
+
+	mu       sync.Mutex // manifest を保護する
+	manifest *os.File   // マニフェスト（最初に保存するときに開く）
+
+	Saved atomic.Int64 // 保存したレスポンスの数
+}
+
+// rawFile はリクエストのURLと Accept ヘッダーから、保存するファイルの rawDir からの相対パスを作成します。
+// ページ番号以外のクエリと、既定以外の Accept ヘッダーはハッシュにしてファイル名に含めます。
+func rawFile(u *url.URL, accept string) string {
+	var parts []string
+	for _, segment := range strings.Split(strings.TrimPrefix(u.Path, apiPath()), "/") {
+		if segment == "" {
+			continue
+		}
+		parts = append(parts, rawSegment(segment))
+	}
+	query := u.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	query.Del("page")
+	name := fmt.Sprintf("page_%04d", page)
+	if rest := query.Encode(); rest != "" || accept != mediaTypeJSON {
+		sum := sha256.Sum256([]byte(rest + "\n" + accept))
+		name += "_" + hex.EncodeToString(sum[:])[:8]
+	}
+	return strings.Join(append(parts, name+".json"), "/")
+}
+
+// rawSegment はAPIのパスの1つの要素を、ファイル名に使える文字（英数字と - _ .）だけにします。
+func rawSegment(segment string) string {
+	if segment == "." || segment == ".." {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, segment)
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *rawTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || req.Method != http.MethodGet || !strings.HasPrefix(req.URL.String(), apiBaseURL+"/") {
+		return resp, err
+	}
+	entry := rawEntry{
+		File:      rawFile(req.URL, req.Header.Get("Accept")),
+		URL:       req.URL.String(),
+		Accept:    req.Header.Get("Accept"),
+		Status:    resp.StatusCode,
+		ETag:      resp.Header.Get("ETag"),
+		Link:      resp.Header.Get("Link"),
+		FetchedAt: time.Now().UTC(),
+	}
+	// コメントのファイルと同じ権限で作成する（HTTPキャッシュと異なり、出力の一部として扱う）
+	dest := filepath.Join(t.dir, filepath.FromSlash(entry.File))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		log.Printf("Warning: failed to write raw response: %v", err)
+		return resp, nil
+	}
+	resp.Body = newSpoolBody(resp.Body, filepath.Dir(dest), "raw response", func(body spooledBody) error {
+		if err := os.Chmod(body.Path, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(body.Path, dest); err != nil {
+			return err
+		}
+		entry.Size, entry.SHA256 = body.Size, body.SHA256
+		return t.record(entry)
+	})
+	return resp, nil
+}
+
+// record はマニフェストに1行追記します。
+func (t *rawTransport) record(entry rawEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.manifest == nil {
+		f, err := os.OpenFile(filepath.Join(t.dir, rawManifestFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		t.manifest = f
+	}
+	if _, err := t.manifest.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	t.Saved.Add(1)
+	return nil
+}
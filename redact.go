@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"   // パターンファイルの行単位の読み込みに使用
+	"fmt"     // エラーメッセージの作成に使用
+	"os"      // パターンファイルの読み込みに使用
+	"regexp"  // 秘匿情報の検出に使用
+	"strings" // 文字列操作に使用
+)
+
+// redactPattern は置き換え対象の種類とそれを検出する正規表現の組です。
+type redactPattern struct {
+	Kind string         // 種類（置き換え後は <Kind> と表示される）
+	Re   *regexp.Regexp // 検出に使う正規表現
+}
+
+// builtinRedactPatterns は --redact で常に使用する組み込みのパターンです。
+// 上から順に適用するため、より具体的なもの（JWTやトークン）を先に並べています。
+var builtinRedactPatterns = []redactPattern{
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{"GITHUB_TOKEN", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"AWS_KEY", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"API_TOKEN", regexp.MustCompile(`\b(?:xox[abposr]-[A-Za-z0-9-]{10,}|sk-[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35})`)},
+	{"EMAIL", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{"IP", regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`)},
+	{"IP", regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b(?:[0-9a-f]{1,4}:){1,6}:(?:[0-9a-f]{1,4}:){0,5}[0-9a-f]{1,4}\b`)},
+}
+
+// redactKindPattern は追加パターンファイルで指定できる種類名の形式です。
+var redactKindPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// redactor はコメント本文から秘匿情報を取り除き、種類ごとの置き換え件数を数えます。
+type redactor struct {
+	patterns []redactPattern
+	Counts   map[string]int // 種類ごとの置き換え件数
+}
+
+// newRedactor は組み込みのパターンに、ファイルから読み込んだ追加パターンを加えた redactor を作成します。
+// 追加パターンファイルは1行に1つ "種類=正規表現" の形式で記述します（空行と "#" で始まる行は無視）。
+//
+// パラメータ:
+//   - extraFile: 追加パターンファイルのパス（空の場合は組み込みのパターンのみ）
+//
+// 戻り値:
+//   - *redactor: 作成した redactor
+//   - error: ファイルの読み込みやパターンの解析に失敗した場合のエラー情報
+func newRedactor(extraFile string) (*redactor, error) {
+	r := &redactor{
+		patterns: append([]redactPattern(nil), builtinRedactPatterns...),
+		Counts:   make(map[string]int),
+	}
+	if extraFile == "" {
+		return r, nil
+	}
+	f, err := os.Open(extraFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var extra []redactPattern
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, expr, ok := strings.Cut(line, "=")
+		kind = strings.TrimSpace(kind)
+		if !ok || !redactKindPattern.MatchString(kind) {
+			return nil, fmt.Errorf("%s:%d: expected KIND=regexp with an upper-case KIND", extraFile, lineNo)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern for %s: %v", extraFile, lineNo, kind, err)
+		}
+		extra = append(extra, redactPattern{Kind: kind, Re: re})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// 利用者が指定したパターンは組み込みのものより先に適用する
+	r.patterns = append(extra, r.patterns...)
+	return r, nil
+}
+
+// fresh は同じパターンを使い、件数を0から数える redactor を作成します。
+// 複数のリポジトリを処理する場合に、リポジトリごとの件数を数えるために使用します。
+func (r *redactor) fresh() *redactor {
+	return &redactor{patterns: r.patterns, Counts: make(map[string]int)}
+}
+
+// redact は本文中の秘匿情報を <種類> に置き換えた文字列を返します。
+// コードブロックやdiffの中も含め、本文全体を対象にします。
+//
+// パラメータ:
+//   - text: 対象の文字列
+//
+// 戻り値:
+//   - string: 置き換え後の文字列
+func (r *redactor) redact(text string) string {
+	for _, p := range r.patterns {
+		placeholder := "<" + p.Kind + ">"
+		text = p.Re.ReplaceAllStringFunc(text, func(string) string {
+			r.Counts[p.Kind]++
+			return placeholder
+		})
+	}
+	return text
+}
+
+// redactUncounted は redact と同じように置き換えますが、件数は数えません。
+// 既に数えた内容の別表現や抜粋（レンダリング済みHTML・返信先の抜粋）に使用します。
+func (r *redactor) redactUncounted(text string) string {
+	if text == "" {
+		return text
+	}
+	counts := r.Counts
+	r.Counts = make(map[string]int)
+	text = r.redact(text)
+	r.Counts = counts
+	return text
+}
+
+// apply は各コメントの本文（Markdown とレンダリング済みHTMLの両方）と返信先の抜粋から秘匿情報を取り除きます。
+// 件数はMarkdownの本文についてのみ数えます（HTMLは同じ内容の別表現、返信先の抜粋は返信先のコメントの本文として数えるため）。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func (r *redactor) apply(comments []Comment) {
+	for i := range comments {
+		comments[i].Body = r.redact(comments[i].Body)
+		comments[i].ParentSnippet = r.redactUncounted(comments[i].ParentSnippet)
+		comments[i].BodyHTML = r.redactUncounted(comments[i].BodyHTML)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect" // 件数の比較に使用
+	"strings" // 置き換えの確認に使用
+	"testing" // テストの実行に使用
+)
+
+// TestRedactCountsBodyOnly は本文・レンダリング済みHTML・返信先の抜粋のすべてを置き換え、件数はMarkdownの本文の分だけ数えることを確認します。
+func TestRedactCountsBodyOnly(t *testing.T) {
+	r, err := newRedactor("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := []Comment{
+		{Body: "mail me at a@example.com", BodyHTML: "<p>mail me at a@example.com</p>"},
+		{Body: "ok", ParentSnippet: "mail me at a@example.com"},
+	}
+	r.apply(comments)
+	for _, text := range []string{comments[0].Body, comments[0].BodyHTML, comments[1].ParentSnippet} {
+		if strings.Contains(text, "a@example.com") || !strings.Contains(text, "<EMAIL>") {
+			t.Errorf("not redacted: %q", text)
+		}
+	}
+	if want := map[string]int{"EMAIL": 1}; !reflect.DeepEqual(r.Counts, want) {
+		t.Errorf("Counts = %v, want %v", r.Counts, want)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"         // マニフェストの行単位の読み込みに使用
+	"crypto/sha256" // 保存したレスポンスの検査に使用
+	"encoding/hex"  // ハッシュの文字列化に使用
+	"encoding/json" // マニフェストのデコードに使用
+	"fmt"           // エラーメッセージの作成に使用
+	"io"            // 保存したレスポンスの読み込みに使用
+	"net/http"      // HTTPクライアントの実装を提供
+	"os"            // 保存したレスポンスの読み込みに使用
+	"path/filepath" // 保存先のパスの組み立てに使用
+	"sort"          // 足りないファイルの一覧の並べ替えに使用
+	"strings"       // 足りないファイルの絞り込みに使用
+	"sync"          // 足りないファイルの記録の排他制御に使用
+)
+
+// offlineToken は --offline でトークンが指定されていない場合に、代わりに使う値です（リクエストを送らないため使われません）。
+const offlineToken = "offline"
+
+// missingRawError は --offline で、リクエストに答える保存済みのレスポンス（--save-raw）がないことを表すエラーです。
+type missingRawError struct {
+	Method string // リクエストのメソッド
+	Path   string // リクエストのパス（クエリを含む）
+	File   string // 見つからなかったファイル（rawDir からの相対パス、GET 以外の場合は空）
+}
+
+// Error は error インタフェースの実装です。
+func (e *missingRawError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("--offline cannot send %s %s (only saved GET responses are replayed)", e.Method, e.Path)
+	}
+	return fmt.Sprintf("no saved raw response for %s %s (expected %s; fetch it once with --save-raw)", e.Method, e.Path, filepath.Join(rawDir, filepath.FromSlash(e.File)))
+}
+
+// replayTransport は --offline で、ネットワークには一切接続せず、--save-raw で保存したレスポンスを返す http.RoundTripper です。
+// 保存したときと同じ決まった名前（rawFile）でファイルを探し、マニフェストに記録した Link・ETag ヘッダーと一緒に 200 のレスポンスとして返すため、
+// デコード・絞り込み・出力はオンラインの場合とまったく同じ処理を通ります。
+type replayTransport struct {
+	dir     string              // 保存先（rawDir）
+	entries map[string]rawEntry // rawDir からの相対パス → マニフェストの最後の行
+
+	mu     sync.Mutex      // missed を保護する
+	missed map[string]bool // 見つからなかったファイル（PRごとのエラーの表示に使用）
+}
+
+// newReplayTransport は dir に保存したマニフェストを読み込み、replayTransport を作成します。
+// 同じファイルが複数の行にある場合は、後の行を使います。
+//
+// パラメータ:
+//   - dir: --save-raw の保存先
+//
+// 戻り値:
+//   - *replayTransport: 保存したレスポンスを返すトランスポート
+//   - error: マニフェストがない場合や読み込めない場合はエラー情報、成功時はnil
+func newReplayTransport(dir string) (*replayTransport, error) {
+	path := filepath.Join(dir, rawManifestFile)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no saved raw responses in %s; run once with --save-raw first", dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &replayTransport{dir: dir, entries: make(map[string]rawEntry), missed: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry rawEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		t.entries[entry.File] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	target := &missingRawError{Method: req.Method, Path: req.URL.RequestURI()}
+	if req.Method != http.MethodGet {
+		return nil, target
+	}
+	target.File = rawFile(req.URL, req.Header.Get("Accept"))
+	entry, ok := t.entries[target.File]
+	if !ok {
+		t.miss(target.File)
+		return nil, target
+	}
+	f, err := os.Open(filepath.Join(t.dir, filepath.FromSlash(entry.File)))
+	if err != nil {
+		t.miss(target.File)
+		return nil, target
+	}
+	// 保存した後に書き換えられたファイルは、途中までのデータを使わないようエラーにする
+	// （デコードは本文の最後まで読むとは限らないため、返す前にファイル全体のハッシュを計算する）
+	if err := verifyRaw(f, entry); err != nil {
+		f.Close()
+		return nil, err
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+	}
+	if entry.Link != "" {
+		header.Set("Link", entry.Link)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          f,
+		ContentLength: entry.Size,
+		Request:       req,
+	}, nil
+}
+
+// rawMismatchError は --offline で、保存したレスポンスがマニフェストの記録と一致しない（保存した後に書き換えられた）ことを表すエラーです。
+type rawMismatchError struct {
+	File string // 保存したレスポンスのファイル
+	What string // 一致しなかった項目（"size" または "checksum"）
+}
+
+// Error は error インタフェースの実装です。
+func (e *rawMismatchError) Error() string {
+	return fmt.Sprintf("saved raw response %s does not match its manifest entry (%s changed); fetch it again with --save-raw", e.File, e.What)
+}
+
+// verifyRaw は保存したレスポンスのサイズとハッシュがマニフェストの記録と一致するかを検査し、ファイルの読み込み位置を先頭に戻します。
+func verifyRaw(f *os.File, entry rawEntry) error {
+	mismatch := &rawMismatchError{File: f.Name(), What: "size"}
+	if info, err := f.Stat(); err != nil || info.Size() != entry.Size {
+		return mismatch
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != entry.SHA256 {
+		mismatch.What = "checksum"
+		return mismatch
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// miss は見つからなかったファイルを記録します。
+func (t *replayTransport) miss(file string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.missed[file] = true
+}
+
+// missingFor は1つのPRについて、これまでに見つからなかったファイル（rawDir からの相対パス）の一覧を返します。
+func (t *replayTransport) missingFor(owner, repo string, prNumber int) []string {
+	prefix := fmt.Sprintf("repos/%s/%s/pulls/%d/", rawSegment(owner), rawSegment(repo), prNumber)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var files []string
+	for file := range t.missed {
+		if strings.HasPrefix(file, prefix) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
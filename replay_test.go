@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"            // 保存したレスポンスの書き換えに使用
+	"path/filepath" // 保存先のパスの組み立てに使用
+	"strings"       // 出力の確認に使用
+	"testing"       // テストの実行に使用
+)
+
+// replayFixture は --save-raw と --offline のテストで使う、2つのPRとコメント（複数ページ・日本語・削除されたユーザーを含む）です。
+func replayFixture() ([]map[string]interface{}, map[int][]map[string]interface{}) {
+	prs := []map[string]interface{}{fakePR(2, "2024-05-02T00:00:00Z"), fakePR(1, "2024-05-01T00:00:00Z")}
+	comments := map[int][]map[string]interface{}{
+		1: {fakeComment(10, 1, "bob", "nit: rename this", "2024-05-01T10:00:00Z")},
+	}
+	for i := int64(0); i < 5; i++ {
+		comments[2] = append(comments[2], fakeComment(20+i, 2, "carol", "コメント **その"+string(rune('1'+i))+"** see #1", "2024-05-02T10:00:00Z"))
+	}
+	comments[2] = append(comments[2], fakeComment(30, 2, "", "LGTM", "2024-05-02T11:00:00Z"))
+	return prs, comments
+}
+
+// copyDir は src の下のファイルを dst にコピーします。
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOfflineMatchesOnline は --save-raw で保存したレスポンスを --offline で読み込んだ出力が、
+// オンラインで取得した出力とバイト単位で一致し、ネットワークに接続しないことを確認します。
+func TestOfflineMatchesOnline(t *testing.T) {
+	for _, format := range []string{formatText, formatJSON, formatCSV, formatHTML, formatMarkdown} {
+		t.Run(format, func(t *testing.T) {
+			prs, comments := replayFixture()
+			server := newFakeGitHub(t, prs, comments, func(f *fakeGitHub) { f.maxPer = 2 })
+			args := []string{"--owner", "o", "--repo", "r", "--count", "2", "--no-cache", "--format", format}
+
+			online := t.TempDir()
+			code, out := runTool(t, online, append(args, "--api-url", server.apiURL(), "--token", "t-ok", "--save-raw")...)
+			if code != 0 {
+				t.Fatalf("online run exited with %d:\n%s", code, out)
+			}
+			want := readOutputs(t, online)
+			if len(want) == 0 {
+				t.Fatalf("online run wrote no output:\n%s", out)
+			}
+
+			offline := t.TempDir()
+			copyDir(t, filepath.Join(online, rawDir), filepath.Join(offline, rawDir))
+			server.Close() // オフラインでは接続できないサーバーを指定しても動くことを確認する
+			requests := server.requestCount()
+			code, out = runTool(t, offline, append(args, "--api-url", server.apiURL(), "--offline")...)
+			if code != 0 {
+				t.Fatalf("offline run exited with %d:\n%s", code, out)
+			}
+			if n := server.requestCount(); n != requests {
+				t.Errorf("offline run sent %d requests", n-requests)
+			}
+			got := readOutputs(t, offline)
+			if len(got) != len(want) {
+				t.Errorf("offline run wrote %d files, want %d", len(got), len(want))
+			}
+			for name, data := range want {
+				if got[name] != data {
+					t.Errorf("%s differs between the online and the offline run:\nonline:\n%s\noffline:\n%s", name, data, got[name])
+				}
+			}
+		})
+	}
+}
+
+// TestOfflineRejectsModifiedRaw は保存した後に書き換えたレスポンス（サイズが同じでも）を --offline が使わないことを確認します。
+func TestOfflineRejectsModifiedRaw(t *testing.T) {
+	prs, comments := replayFixture()
+	server := newFakeGitHub(t, prs, comments)
+	dir := t.TempDir()
+	args := []string{"--owner", "o", "--repo", "r", "--count", "2", "--no-cache"}
+	if code, out := runTool(t, dir, append(args, "--api-url", server.apiURL(), "--token", "t-ok", "--save-raw")...); code != 0 {
+		t.Fatalf("online run exited with %d:\n%s", code, out)
+	}
+	replay, err := newReplayTransport(filepath.Join(dir, rawDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var target string
+	for file := range replay.entries {
+		if strings.HasPrefix(file, "repos/o/r/pulls/1/comments/") {
+			target = filepath.Join(dir, rawDir, filepath.FromSlash(file))
+		}
+	}
+	if target == "" {
+		t.Fatal("no saved comments for PR #1")
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同じ長さのまま本文を書き換える
+	if err := os.WriteFile(target, []byte(strings.Replace(string(data), "rename", "RENAME", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, out := runTool(t, dir, append(args, "--api-url", server.apiURL(), "--offline")...)
+	if !strings.Contains(out, "Error fetching comments for PR #1") || !strings.Contains(out, "checksum changed") {
+		t.Errorf("output does not report the checksum mismatch:\n%s", out)
+	}
+	for name, data := range readOutputs(t, dir) {
+		if strings.Contains(data, "RENAME") {
+			t.Errorf("modified raw response was used in %s", name)
+		}
+	}
+}
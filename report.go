@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json" // PR一覧のJSON出力に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"math"          // 100行あたりのコメント数の丸めに使用
+	"os"            // ファイル操作に使用
+	"path/filepath" // ファイルパス操作に使用
+	"sort"          // 集計結果の並べ替えに使用
+	"strings"       // 文字列操作に使用
+)
+
+// prIndexEntry はPR一覧（prs.json）の1件分の情報を表す構造体です。
+type prIndexEntry struct {
+	Number             int            `json:"number"`                       // プルリクエスト番号
+	MergedAt           *string        `json:"merged_at"`                    // マージされた日時
+	ClosedAt           *string        `json:"closed_at,omitempty"`          // マージされずにクローズされた日時
+	RequestedReviewers []string       `json:"requested_reviewers"`          // レビューを依頼されているユーザー
+	Assignees          []string       `json:"assignees"`                    // アサインされているユーザー
+	Labels             []string       `json:"labels"`                       // 付与されているラベル
+	CommentCount       int            `json:"comment_count"`                // 取得したコメント数
+	Status             string         `json:"status,omitempty"`             // 出力から除外した場合の理由（例: "skipped (below threshold)"）
+	Truncated          bool           `json:"truncated,omitempty"`          // --max-comments-per-pr でコメントの取得を打ち切ったかどうか
+	AvailableComments  int            `json:"available_comments,omitempty"` // 打ち切った場合の、取得できるコメントの総数
+	Additions          int            `json:"additions"`                    // 追加された行数
+	Deletions          int            `json:"deletions"`                    // 削除された行数
+	ChangedFiles       int            `json:"changed_files"`                // 変更されたファイル数
+	CommentsPer100     float64        `json:"comments_per_100_lines"`       // 変更行数100行あたりのコメント数
+	Duplicates         []duplicateRef `json:"duplicates,omitempty"`         // --dedupe-bodies で除外したコメントと、最初に出現したコメントのID
+}
+
+// savePRIndex は処理したPRの一覧を prs.json として保存します。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - results: PRごとの取得結果
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func savePRIndex(owner, repo string, results []prResult) error {
+	saveDir := commentsDir(owner, repo)
+	if err := os.MkdirAll(saveDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	entries := make([]prIndexEntry, 0, len(results))
+	for _, r := range results {
+		entries = append(entries, prIndexEntry{
+			Number:             r.PR.Number,
+			MergedAt:           r.PR.MergedAt,
+			ClosedAt:           closedWithoutMerge(r.PR),
+			RequestedReviewers: userLogins(r.PR.RequestedReviewers),
+			Assignees:          userLogins(r.PR.Assignees),
+			Labels:             r.PR.LabelNames(),
+			CommentCount:       len(r.Comments),
+			Status:             r.Status,
+			Truncated:          r.PR.CommentsTruncated,
+			AvailableComments:  r.PR.CommentsAvailable,
+			Additions:          r.PR.Additions,
+			Deletions:          r.PR.Deletions,
+			ChangedFiles:       r.PR.ChangedFiles,
+			CommentsPer100:     math.Round(commentsPer100Lines(r.PR, len(r.Comments))*100) / 100,
+			Duplicates:         r.Duplicates,
+		})
+	}
+
+	// 人が読みやすいようにインデント付きで出力
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(saveDir, "prs.json"), append(data, '\n'), 0644)
+}
+
+// closedWithoutMerge はマージされずにクローズされたPRのクローズ日時を返します。それ以外のPRではnilを返します。
+func closedWithoutMerge(pr PullRequest) *string {
+	if pr.MergedAt != nil {
+		return nil
+	}
+	return pr.ClosedAt
+}
+
+// savedResults は出力から除外したPR（--min-comments に満たないPRなど）を除いた取得結果を返します。
+func savedResults(results []prResult) []prResult {
+	saved := make([]prResult, 0, len(results))
+	for _, r := range results {
+		if r.Status == "" {
+			saved = append(saved, r)
+		}
+	}
+	return saved
+}
+
+// reviewerParticipation はレビュー依頼者のうち、コメントを残した人と残さなかった人を分類します。
+//
+// パラメータ:
+//   - r: PRごとの取得結果
+//
+// 戻り値:
+//   - commented: 1件以上コメントを残したレビュー依頼者
+//   - silent: コメントを残さなかったレビュー依頼者
+func reviewerParticipation(r prResult) (commented, silent []string) {
+	// コメントを残したユーザーの集合を作成
+	authors := make(map[string]bool)
+	for _, c := range r.Comments {
+		if c.IsDeletedUser() {
+			continue // 削除されたユーザーはレビュー依頼者と照合できない
+		}
+		authors[strings.ToLower(c.User.Login)] = true
+	}
+	for _, reviewer := range r.PR.RequestedReviewers {
+		if authors[strings.ToLower(reviewer.Login)] {
+			commented = append(commented, reviewer.Login)
+		} else {
+			silent = append(silent, reviewer.Login)
+		}
+	}
+	return commented, silent
+}
+
+// commentsPerLabel はラベルごとのコメント数を集計します。
+// 複数のラベルが付いたPRのコメントは、それぞれのラベルに数えます。ラベルのないPRは "(no label)" に数えます。
+//
+// パラメータ:
+//   - results: PRごとの取得結果
+//
+// 戻り値:
+//   - []string: ラベル名（コメント数の多い順、同数の場合は名前順）
+//   - map[string]int: ラベル名ごとのコメント数
+func commentsPerLabel(results []prResult) ([]string, map[string]int) {
+	counts := make(map[string]int)
+	for _, r := range results {
+		labels := r.PR.LabelNames()
+		if len(labels) == 0 {
+			labels = []string{"(no label)"}
+		}
+		for _, l := range labels {
+			counts[l] += len(r.Comments)
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names, counts
+}
+
+// saveStats は統計レポートを stats.txt として保存します。
+// PRごとに、レビュー依頼者のうち誰がコメントを残し、誰が残さなかったかを出力し、
+// 続けてラベルごとのコメント数を出力します。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - results: PRごとの取得結果
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func saveStats(owner, repo string, results []prResult) error {
+	saveDir := commentsDir(owner, repo)
+	if err := os.MkdirAll(saveDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	var b strings.Builder
+	for _, r := range results {
+		commented, silent := reviewerParticipation(r)
+		if hasSize(r.PR) {
+			fmt.Fprintf(&b, "PR #%d (%d comments, +%d −%d across %d files, %.2f comments per 100 lines changed)\n",
+				r.PR.Number, len(r.Comments), r.PR.Additions, r.PR.Deletions, r.PR.ChangedFiles, commentsPer100Lines(r.PR, len(r.Comments)))
+		} else {
+			fmt.Fprintf(&b, "PR #%d (%d comments)\n", r.PR.Number, len(r.Comments))
+		}
+		fmt.Fprintf(&b, "  Requested reviewers who commented: %s\n", joinOrNone(commented))
+		fmt.Fprintf(&b, "  Requested reviewers who stayed silent: %s\n", joinOrNone(silent))
+	}
+
+	// 分類ごとのコメント数
+	categories := make(map[string]int)
+	for _, r := range results {
+		for _, c := range r.Comments {
+			categories[c.Category]++
+		}
+	}
+	b.WriteString("\nComments per category\n")
+	for _, name := range sortedKeys(categories) {
+		fmt.Fprintf(&b, "  %s: %d\n", name, categories[name])
+	}
+
+	// ラベルごとのコメント数
+	names, counts := commentsPerLabel(results)
+	b.WriteString("\nComments per label\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s: %d\n", name, counts[name])
+	}
+	return os.WriteFile(filepath.Join(saveDir, "stats.txt"), []byte(b.String()), 0644)
+}
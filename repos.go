@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"         // リポジトリ一覧の行単位の読み込みに使用
+	"context"       // 実行全体の期限の伝達に使用
+	"encoding/json" // リポジトリごとの集計のJSON出力に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"os"            // ファイル操作に使用
+	"path/filepath" // ファイルパス操作に使用
+	"strconv"       // 上書きする値の変換に使用
+	"strings"       // 行の分割に使用
+)
+
+// repoEntry はリポジトリ一覧（--repos-file）の1行分の指定を表す構造体です。
+type repoEntry struct {
+	Owner    string // オーナー名
+	Repo     string // リポジトリ名
+	Count    int    // 行で上書きした取得するPRの数（0の場合は --count に従う）
+	Base     string // 行で指定したマージ先のブランチ（空の場合は制限なし）
+	Inactive string // アーカイブ・無効化されたリポジトリの場合はその状態（archived または disabled）
+}
+
+// Name は owner/repo 形式のリポジトリ名を返します。
+func (e repoEntry) Name() string {
+	return e.Owner + "/" + e.Repo
+}
+
+// loadReposFile はリポジトリ一覧のファイルを読み込みます。
+// 空行と # で始まる行は無視し、各行は owner/repo（またはリポジトリのURL）の後に、
+// count=50 や base=main のような key=value 形式の上書きを空白区切りで続けられます。
+// 不正な行がある場合は、行番号を含むエラーを返します（処理を始める前にすべての行を検査します）。
+//
+// パラメータ:
+//   - path: リポジトリ一覧のファイルパス
+//
+// 戻り値:
+//   - []repoEntry: ファイルに書かれた順のリポジトリの指定
+//   - error: 読み込みに失敗した場合や不正な行がある場合はエラー情報、成功時はnil
+func loadReposFile(path string) ([]repoEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []repoEntry
+	seen := make(map[string]int) // 小文字のリポジトリ名 → 最初に書かれた行番号
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		entry, err := parseRepoEntry(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("%s:%d: invalid override %q (expected key=value, e.g. count=50)", path, line, field)
+			}
+			if err := entry.override(key, value); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+			}
+		}
+		name := strings.ToLower(entry.Name())
+		if first, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s:%d: %s is already listed on line %d", path, line, entry.Name(), first)
+		}
+		seen[name] = line
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s: no repositories listed", path)
+	}
+	return entries, nil
+}
+
+// parseRepoEntry はリポジトリ指定（owner/repo またはリポジトリのURL）から repoEntry を作成します。
+func parseRepoEntry(spec string) (repoEntry, error) {
+	rs, err := parseRepoSpec(spec)
+	if err != nil {
+		return repoEntry{}, err
+	}
+	if rs.Host != githubHost && rs.Host != webHost() {
+		return repoEntry{}, fmt.Errorf("%s/%s is on the host %s, but --api-url points to %s", rs.Owner, rs.Repo, rs.Host, apiBaseURL)
+	}
+	return repoEntry{Owner: rs.Owner, Repo: rs.Repo}, nil
+}
+
+// override はリポジトリごとの上書き（count または base）を設定します。
+func (e *repoEntry) override(key, value string) error {
+	switch key {
+	case "count":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count %q (expected a positive number)", value)
+		}
+		e.Count = n
+	case "base":
+		e.Base = value
+	default:
+		return fmt.Errorf("unknown override %q (expected count or base)", key)
+	}
+	return nil
+}
+
+// repository はリポジトリAPIのレスポンスのうち、リポジトリの状態を格納する構造体です。
+type repository struct {
+	Archived bool `json:"archived"` // アーカイブされているか
+	Disabled bool `json:"disabled"` // 無効化されているか
+}
+
+// inactiveState はアーカイブ・無効化されたリポジトリの場合にその状態を返します。通常のリポジトリの場合は空文字を返します。
+func (r repository) inactiveState() string {
+	switch {
+	case r.Disabled:
+		return "disabled"
+	case r.Archived:
+		return "archived"
+	}
+	return ""
+}
+
+// fetchRepository はリポジトリの状態（アーカイブ・無効化）を取得します。HTTPステータスコードも返します。
+func fetchRepository(ctx context.Context, owner, repo, token string) (repository, int, error) {
+	var r repository
+	status, err := getJSON(ctx, apiClient, apiURL("/repos/%s/%s", owner, repo), token, &r)
+	return r, status, err
+}
+
+// repoSummary はリポジトリごとの集計結果（repos_summary.json の1件分）を表す構造体です。
+type repoSummary struct {
+	Repository string         `json:"repository"`            // owner/repo 形式のリポジトリ名
+	PRs        int            `json:"prs"`                   // 処理したPRの数
+	Comments   int            `json:"comments"`              // 保存したコメントの数
+	SkippedPRs map[string]int `json:"skipped_prs,omitempty"` // PRの絞り込みで除外された理由ごとのPR数
+	Dropped    map[string]int `json:"dropped,omitempty"`     // 絞り込みで除外された理由ごとのコメント数
+	Credential string         `json:"credential,omitempty"`  // 使ったトークンの読み込み元（設定ファイルの tokens: を使う場合。トークンの値は含まない）
+	Stopped    string         `json:"stopped,omitempty"`     // API呼び出しの上限で処理を打ち切った場合の理由
+	Skipped    string         `json:"skipped,omitempty"`     // 処理しなかった場合の理由（例: "archived"）
+	Error      string         `json:"error,omitempty"`       // 処理に失敗した場合のエラー
+}
+
+// newRepoSummary はリポジトリの集計結果から、repos_summary.json の1件分を作成します。
+func newRepoSummary(entry repoEntry, s runSummary) repoSummary {
+	return repoSummary{
+		Repository: entry.Name(),
+		PRs:        s.PRs,
+		Comments:   s.Comments,
+		SkippedPRs: s.SkippedPRs,
+		Dropped:    s.Dropped,
+	}
+}
+
+// printRepoSummaries はリポジトリごとの集計結果を一覧として表示します。
+func printRepoSummaries(summaries []repoSummary) {
+	prs, comments := 0, 0
+	fmt.Printf("Repositories: %d listed\n", len(summaries))
+	for _, s := range summaries {
+		note := ""
+		if s.Stopped != "" {
+			note = " (" + s.Stopped + ")"
+		}
+		if s.Error != "" {
+			note = " (failed: " + s.Error + ")"
+		}
+		if s.Skipped != "" {
+			note = " (skipped: " + s.Skipped + ")"
+		}
+		if s.Credential != "" {
+			note += " [token: " + s.Credential + "]"
+		}
+		fmt.Printf("  %s: %d PRs, %d comments%s\n", s.Repository, s.PRs, s.Comments, note)
+		prs += s.PRs
+		comments += s.Comments
+	}
+	fmt.Printf("  Total: %d PRs, %d comments\n", prs, comments)
+}
+
+// saveRepoSummaries はリポジトリごとの集計結果を comments/repos_summary.json として保存します。
+//
+// パラメータ:
+//   - summaries: リポジトリごとの集計結果（処理した順）
+//
+// 戻り値:
+//   - string: 保存したファイルのパス
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func saveRepoSummaries(summaries []repoSummary) (string, error) {
+	if err := os.MkdirAll("comments", 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	path := filepath.Join("comments", "repos_summary.json")
+	data, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
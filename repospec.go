@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"     // エラーメッセージの作成に使用
+	"net/url" // リポジトリのURLの解析に使用
+	"strings" // リポジトリ指定の分割に使用
+)
+
+// githubHost はGitHub.comのホスト名です。
+const githubHost = "github.com"
+
+// repoSpecForms はリポジトリ指定として受け付ける形式の説明です（エラーメッセージに使用）。
+const repoSpecForms = "accepted forms: owner/repo, https://github.com/owner/repo, https://github.com/owner/repo.git, git@github.com:owner/repo.git, ssh://git@github.com/owner/repo.git, git://github.com/owner/repo.git"
+
+// nonGitHubHosts はGitHub以外のよく知られたGitホスティングサービスのホスト名です。
+// これら以外のホストはGitHub Enterpriseのホストとして扱います。
+var nonGitHubHosts = map[string]bool{
+	"gitlab.com":        true,
+	"bitbucket.org":     true,
+	"codeberg.org":      true,
+	"dev.azure.com":     true,
+	"ssh.dev.azure.com": true,
+	"sourceforge.net":   true,
+}
+
+// repoSpec は解析したリポジトリ指定を格納する構造体です。
+type repoSpec struct {
+	Host  string // ホスト名（owner/repo 形式で指定した場合や github.com の場合は github.com）
+	Owner string // オーナー名
+	Repo  string // リポジトリ名
+}
+
+// parseRepoSpec はリポジトリ指定を、ホスト名・オーナー名・リポジトリ名に分けます。
+// owner/repo 形式のほか、https・ssh・git プロトコルのURLと git@host:owner/repo.git 形式を受け付け、末尾の .git は取り除きます。
+// github.com 以外のホストはGitHub Enterpriseのホストとして Host に記録します。
+// スラッシュが1つでない owner/repo、先頭や末尾のスラッシュ、空の要素、余分なパスを含むURL、GitHub以外のホストはエラーになります。
+//
+// パラメータ:
+//   - spec: リポジトリ指定（例: acme/api、https://github.com/acme/api）
+//
+// 戻り値:
+//   - repoSpec: 解析したリポジトリ指定
+//   - error: 不正な指定の場合は受け付ける形式を含むエラー情報、成功時はnil
+func parseRepoSpec(spec string) (repoSpec, error) {
+	spec = strings.TrimSpace(spec)
+	host, path := githubHost, spec
+	switch {
+	case strings.Contains(spec, "://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return repoSpec{}, fmt.Errorf("invalid repository URL %q (%s)", spec, repoSpecForms)
+		}
+		switch u.Scheme {
+		case "https", "http", "ssh", "git", "git+ssh":
+		default:
+			return repoSpec{}, fmt.Errorf("unsupported URL scheme %q in %q (%s)", u.Scheme, spec, repoSpecForms)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return repoSpec{}, fmt.Errorf("invalid repository URL %q (%s)", spec, repoSpecForms)
+		}
+		host = u.Hostname()
+		path = strings.TrimSuffix(strings.TrimPrefix(u.Path, "/"), "/")
+	case strings.Contains(spec, "@") && strings.Contains(spec, ":"):
+		// git@github.com:owner/repo.git のようなscp形式
+		at := strings.Index(spec, "@")
+		colon := strings.Index(spec[at:], ":") + at
+		host = spec[at+1 : colon]
+		path = spec[colon+1:]
+	}
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" || nonGitHubHosts[host] {
+		return repoSpec{}, fmt.Errorf("%q is not a GitHub repository (%s)", spec, repoSpecForms)
+	}
+	parts := strings.Split(strings.TrimSuffix(path, ".git"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return repoSpec{}, fmt.Errorf("invalid repository %q (%s)", spec, repoSpecForms)
+	}
+	return repoSpec{Host: host, Owner: parts[0], Repo: parts[1]}, nil
+}
+
+// resolveRepository はフラグと位置引数から、対象のリポジトリを決めます。
+// --repo にスラッシュを含む場合は owner/repo 形式またはURLとして扱い、位置引数の owner/repo やURLも受け付けます。
+// 従来どおり --owner と --repo を別々に指定することもできます。
+//
+// パラメータ:
+//   - owner: --owner の値
+//   - repo: --repo の値
+//   - args: 位置引数
+//
+// 戻り値:
+//   - repoSpec: 対象のリポジトリ
+//   - error: 指定が不正・矛盾している場合はエラー情報、成功時はnil
+func resolveRepository(owner, repo string, args []string) (repoSpec, error) {
+	if len(args) > 1 {
+		return repoSpec{}, fmt.Errorf("only one repository can be given per run (got %s)", strings.Join(args, ", "))
+	}
+	if len(args) == 1 {
+		if owner != "" || repo != "" {
+			return repoSpec{}, fmt.Errorf("repository %q given both as an argument and with --owner/--repo", args[0])
+		}
+		return parseRepoSpec(args[0])
+	}
+	if strings.Contains(repo, "/") || strings.Contains(repo, ":") {
+		spec, err := parseRepoSpec(repo)
+		if err != nil {
+			return repoSpec{}, err
+		}
+		if owner != "" && !strings.EqualFold(owner, spec.Owner) {
+			return repoSpec{}, fmt.Errorf("--owner %q conflicts with --repo %q", owner, repo)
+		}
+		return spec, nil
+	}
+	if owner == "" || repo == "" {
+		return repoSpec{}, fmt.Errorf("a repository is required: pass owner/repo, a repository URL, --repo owner/repo, or --owner and --repo")
+	}
+	return repoSpec{Host: githubHost, Owner: owner, Repo: repo}, nil
+}
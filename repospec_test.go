@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strings" // エラーメッセージの確認に使用
+	"testing" // テストの実行に使用
+)
+
+// TestParseRepoSpec は owner/repo 形式と https・ssh・git プロトコルのURLから、ホスト名・オーナー名・リポジトリ名を取り出せることを確認します。
+func TestParseRepoSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want repoSpec
+	}{
+		{"acme/api", repoSpec{githubHost, "acme", "api"}},
+		{"  acme/api\n", repoSpec{githubHost, "acme", "api"}},
+		{"acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		// https
+		{"https://github.com/acme/api", repoSpec{githubHost, "acme", "api"}},
+		{"https://github.com/acme/api/", repoSpec{githubHost, "acme", "api"}},
+		{"https://github.com/acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		{"https://www.github.com/acme/api", repoSpec{githubHost, "acme", "api"}},
+		{"HTTPS://GitHub.com/acme/api", repoSpec{githubHost, "acme", "api"}},
+		{"http://github.com/acme/api", repoSpec{githubHost, "acme", "api"}},
+		// ssh
+		{"git@github.com:acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		{"git@github.com:acme/api", repoSpec{githubHost, "acme", "api"}},
+		{"ssh://git@github.com/acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		{"ssh://git@github.com:22/acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		{"git+ssh://git@github.com/acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		// git
+		{"git://github.com/acme/api.git", repoSpec{githubHost, "acme", "api"}},
+		{"git://github.com/acme/api", repoSpec{githubHost, "acme", "api"}},
+		// GitHub Enterprise Server（ホスト名を記録する）
+		{"https://github.example.com/acme/api", repoSpec{"github.example.com", "acme", "api"}},
+		{"https://GHE.Example.com:8443/acme/api.git", repoSpec{"ghe.example.com", "acme", "api"}},
+		{"git@ghe.example.com:acme/api.git", repoSpec{"ghe.example.com", "acme", "api"}},
+		{"ssh://git@ghe.example.com/acme/api.git", repoSpec{"ghe.example.com", "acme", "api"}},
+		{"git://ghe.example.com/acme/api.git", repoSpec{"ghe.example.com", "acme", "api"}},
+	}
+	for _, tt := range tests {
+		got, err := parseRepoSpec(tt.spec)
+		if err != nil {
+			t.Errorf("parseRepoSpec(%q): %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRepoSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+// TestParseRepoSpecInvalid は曖昧な指定やGitHub以外のURLがエラーになり、エラーメッセージに受け付ける形式が含まれることを確認します。
+func TestParseRepoSpecInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"acme",
+		"acme/",
+		"/acme/api",
+		"acme//api",
+		"acme/api/extra",
+		"github.com/acme/api", // スキームのないURL
+		"https:/github.com/acme/api",
+		"https://github.com/acme",
+		"https://github.com/acme/api/pull/1",
+		"https://github.com/acme/api?tab=readme",
+		"https://github.com/acme/api#readme",
+		"https:///acme/api",
+		"ftp://github.com/acme/api",
+		"https://gitlab.com/acme/api",
+		"git@bitbucket.org:acme/api.git",
+		"git@:acme/api.git",
+		"ssh://git@ssh.dev.azure.com/acme/api",
+	} {
+		_, err := parseRepoSpec(spec)
+		if err == nil {
+			t.Errorf("parseRepoSpec(%q) succeeded, want an error", spec)
+			continue
+		}
+		if !strings.Contains(err.Error(), repoSpecForms) {
+			t.Errorf("parseRepoSpec(%q) error does not list the accepted forms: %v", spec, err)
+		}
+	}
+}
+
+// TestResolveRepository は位置引数・--repo・--owner の組み合わせから対象のリポジトリを決め、矛盾する指定をエラーにすることを確認します。
+func TestResolveRepository(t *testing.T) {
+	tests := []struct {
+		owner, repo string
+		args        []string
+		want        repoSpec
+		err         string // 空でなければ、エラーメッセージに含まれるべき文字列
+	}{
+		{owner: "acme", repo: "api", want: repoSpec{githubHost, "acme", "api"}},
+		{repo: "acme/api", want: repoSpec{githubHost, "acme", "api"}},
+		{owner: "ACME", repo: "https://github.com/acme/api", want: repoSpec{githubHost, "acme", "api"}},
+		{repo: "git@ghe.example.com:acme/api.git", want: repoSpec{"ghe.example.com", "acme", "api"}},
+		{args: []string{"https://github.example.com/acme/api.git"}, want: repoSpec{"github.example.com", "acme", "api"}},
+		{args: []string{"git://github.com/acme/api.git"}, want: repoSpec{githubHost, "acme", "api"}},
+		{owner: "other", repo: "acme/api", err: "conflicts with"},
+		{repo: "api", args: []string{"acme/api"}, err: "both as an argument"},
+		{args: []string{"acme/api", "acme/web"}, err: "only one repository"},
+		{owner: "acme", err: "a repository is required"},
+		{args: []string{"https://gitlab.com/acme/api"}, err: repoSpecForms},
+	}
+	for _, tt := range tests {
+		got, err := resolveRepository(tt.owner, tt.repo, tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("resolveRepository(%q, %q, %q) = %+v, %v; want an error containing %q", tt.owner, tt.repo, tt.args, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveRepository(%q, %q, %q) = %+v, %v; want %+v", tt.owner, tt.repo, tt.args, got, err, tt.want)
+		}
+	}
+}
+
+// TestEnterpriseRepositoryURL はGitHub Enterprise Server のリポジトリのURLを --api-url と一緒に指定した場合、
+// --api-url のホストと一致すればそのAPIを使い、一致しなければ停止することを確認します。
+func TestEnterpriseRepositoryURL(t *testing.T) {
+	prs, comments := replayFixture()
+	server := newFakeGitHub(t, prs, comments, func(f *fakeGitHub) { f.prefix = "/api/v3" })
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	dir := t.TempDir()
+	code, out := runTool(t, dir, "--api-url", server.apiURL(), "--token", "t-ok", "--count", "1", "--no-cache", "git@"+strings.Split(host, ":")[0]+":o/r.git")
+	if code != 0 {
+		t.Fatalf("run with a repository URL on the --api-url host exited with %d:\n%s", code, out)
+	}
+	if len(readOutputs(t, dir)) == 0 {
+		t.Errorf("run with a repository URL on the --api-url host wrote no output:\n%s", out)
+	}
+
+	code, out = runTool(t, t.TempDir(), "--api-url", server.apiURL(), "--token", "t-ok", "https://ghe.example.com/o/r")
+	if code == 0 || !strings.Contains(out, "o/r is on the host ghe.example.com, but --api-url points to "+server.apiURL()) {
+		t.Errorf("run with a repository on another host exited with %d:\n%s", code, out)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"io"       // 接続を使い回すための本文の読み捨てに使用
+	"net/http" // HTTPクライアントの実装を提供
+	"net/url"  // クエリパラメータの組み立てに使用
+)
+
+// drainBytes は接続を使い回せるよう、閉じる前に読み捨てる本文の最大のバイト数です（これより長い本文は接続ごと閉じます）。
+const drainBytes = 64 << 10
+
+// apiRequest はGitHub APIへのGETリクエストの内容です。
+type apiRequest struct {
+	URL    string     // リクエストするURL（apiURL で作成したもの）
+	Query  url.Values // クエリパラメータ（nilの場合は付けない）
+	Token  string     // GitHub APIアクセス用のトークン
+	Accept string     // Acceptヘッダーに指定するメディアタイプ（空の場合は mediaTypeJSON）
+}
+
+// apiResponse はGitHub APIのレスポンスのうち、本文以外の情報です。本文はすでに閉じられています。
+type apiResponse struct {
+	Status int         // HTTPステータスコード（リクエストを送れなかった場合は0）
+	Header http.Header // レスポンスヘッダー
+	Links  pageLinks   // Linkヘッダーから読み取ったページ送りの情報
+}
+
+// fetchJSON はGitHub APIにGETリクエストを送り、ステータスが200の場合はレスポンスを v にデコードします。
+// レスポンスの本文は、戻る前に必ず閉じます（ページ送りのループの中で呼び出しても、本文と接続が開いたまま残りません）。
+// 接続を使い回せるよう、デコードした後に残った本文も読み捨ててから閉じます。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - client: リクエストに使用するHTTPクライアント
+//   - r: リクエストの内容
+//   - v: デコード先
+//
+// 戻り値:
+//   - apiResponse: ステータスコード・ヘッダー・ページ送りの情報（200以外の場合も設定する）
+//   - error: エラーが発生した場合はエラー情報（ステータスコードが200以外の場合は *apiError）、成功時はnil
+func fetchJSON(ctx context.Context, client *http.Client, r apiRequest, v interface{}) (apiResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.URL, nil)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	if r.Query != nil {
+		req.URL.RawQuery = r.Query.Encode()
+	}
+	accept := r.Accept
+	if accept == "" {
+		accept = mediaTypeJSON
+	}
+	req.Header.Set("Authorization", "token "+r.Token)
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	defer closeBody(resp)
+
+	info := apiResponse{Status: resp.StatusCode, Header: resp.Header, Links: parseLinkHeader(resp.Header.Get("Link"))}
+	if resp.StatusCode != http.StatusOK {
+		return info, newAPIError(resp)
+	}
+	return info, decodeJSON(resp, v)
+}
+
+// closeBody はレスポンスの本文の残りを読み捨ててから閉じます。
+func closeBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, drainBytes))
+	resp.Body.Close()
+}
+
+// getJSON はGitHub APIにGETリクエストを送り、レスポンスをデコードします。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - client: リクエストに使用するHTTPクライアント
+//   - url: リクエストするURL
+//   - token: GitHub APIアクセス用のトークン
+//   - v: デコード先
+//
+// 戻り値:
+//   - int: HTTPステータスコード
+//   - error: エラーが発生した場合はエラー情報（ステータスコードが200以外の場合を含む）、成功時はnil
+func getJSON(ctx context.Context, client *http.Client, url, token string, v interface{}) (int, error) {
+	resp, err := fetchJSON(ctx, client, apiRequest{URL: url, Token: token}, v)
+	return resp.Status, err
+}
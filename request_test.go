@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"  // リクエストのコンテキストに使用
+	"fmt"      // PRの日時の作成に使用
+	"io"       // レスポンスの本文の置き換えに使用
+	"net/http" // テスト用のトランスポートの実装に使用
+	"sync"     // 開いている本文の数の排他制御に使用
+	"testing"  // テストの実行に使用
+)
+
+// countingTransport は返したレスポンスの本文のうち、まだ閉じられていないものの数と、その最大値を数える http.RoundTripper です。
+type countingTransport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	open     int // 閉じられていない本文の数
+	maxOpen  int // 同時に開いていた本文の数の最大値
+	requests int // 送ったリクエストの数
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.requests++
+	t.open++
+	if t.open > t.maxOpen {
+		t.maxOpen = t.open
+	}
+	t.mu.Unlock()
+	resp.Body = &countedBody{ReadCloser: resp.Body, t: t}
+	return resp, nil
+}
+
+// counts は閉じられていない本文の数・その最大値・リクエストの数を返します。
+func (t *countingTransport) counts() (open, maxOpen, requests int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.open, t.maxOpen, t.requests
+}
+
+// countedBody は閉じたときに countingTransport の数を減らす本文です（2回閉じても1回だけ数えます）。
+type countedBody struct {
+	io.ReadCloser
+	t      *countingTransport
+	closed bool
+}
+
+// Close は io.Closer インタフェースの実装です。
+func (b *countedBody) Close() error {
+	b.t.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		b.t.open--
+	}
+	b.t.mu.Unlock()
+	return b.ReadCloser.Close()
+}
+
+// TestFetchClosesBodies はページ送りのループ（PRの一覧・コメント）が、次のページを取得する前に各ページの本文を閉じ、
+// エラーのレスポンスやデコードできない本文も閉じることを確認します。
+func TestFetchClosesBodies(t *testing.T) {
+	var prs []map[string]interface{}
+	for n := 30; n >= 1; n-- {
+		prs = append(prs, fakePR(n, fmt.Sprintf("2024-05-%02dT00:00:00Z", n)))
+	}
+	server := newFakeGitHub(t, prs, map[int][]map[string]interface{}{1: pagedComments(1, 300)}, func(f *fakeGitHub) {
+		f.maxPer = 10
+		f.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+			switch r.URL.Path {
+			case "/repos/o/r/pulls/2/comments":
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"message":"Server Error"}`))
+				return true
+			case "/repos/o/r/pulls/3/comments":
+				w.Write([]byte(`{"not": "an array"`))
+				return true
+			}
+			return false
+		}
+	})
+	withAPIBaseURL(t, server.apiURL())
+	counter := &countingTransport{base: http.DefaultTransport}
+	withTransport(t, counter)
+	ctx := context.Background()
+
+	got, err := fetchMergedPRs(ctx, "o", "r", "t", prQuery{SortBy: prSortMerged, State: stateMerged}, &prFilter{}, newConsole(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 30 {
+		t.Fatalf("listed %d PRs, want 30", len(got))
+	}
+	comments, _, err := fetchReviewComments(ctx, "o", "r", 1, "t", mediaTypeJSON, 0, 1, newConsole(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 300 {
+		t.Fatalf("fetched %d comments, want 300", len(comments))
+	}
+	for _, n := range []int{2, 3} {
+		if _, _, err := fetchReviewComments(ctx, "o", "r", n, "t", mediaTypeJSON, 0, 1, newConsole("")); err == nil {
+			t.Errorf("PR #%d: fetch of a broken page succeeded", n)
+		}
+	}
+
+	open, maxOpen, requests := counter.counts()
+	if requests < 3+30+2 {
+		t.Fatalf("only %d requests were sent", requests)
+	}
+	if open != 0 {
+		t.Errorf("%d response bodies were left open after %d requests", open, requests)
+	}
+	if maxOpen != 1 {
+		t.Errorf("up to %d response bodies were open at once while paging sequentially, want 1", maxOpen)
+	}
+}
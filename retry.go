@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"     // 中断されたリクエストの判定に使用
+	"crypto/tls"  // 証明書の検証エラーの判定に使用
+	"crypto/x509" // 証明書の検証エラーの判定に使用
+	"errors"      // エラーの種類の判定に使用
+	"io"          // 途中で切れた接続の判定に使用
+	"log"         // 送り直しの表示に使用
+	"math/rand"   // 待機時間のばらつき（ジッター）に使用
+	"net"         // 通信エラーの判定に使用
+	"net/http"    // HTTPクライアントの実装を提供
+	"strconv"     // Retry-After の変換に使用
+	"time"        // 待機時間の計算に使用
+)
+
+// retryBaseWait は1回目の送り直しまでの待機時間の基準です（2回目以降は倍々に延ばします）。
+const retryBaseWait = time.Second
+
+// retryTransport は一時的な失敗（通信エラー・5xx・レート制限以外の 429）のリクエストを、
+// ジッター付きの指数バックオフで送り直す http.RoundTripper です。
+// 同じ結果になるリクエストだけを送り直すため、本文のない GET・HEAD 以外と、認証のエラーを含む 4xx は送り直しません。
+type retryTransport struct {
+	base    http.RoundTripper // 実際にリクエストを送るトランスポート
+	retries int               // 送り直す回数の上限（--retries）
+	maxWait time.Duration     // 1回の待機時間の上限（--retry-max-wait）
+	verbose bool              // 送り直しが成功した場合も表示するか（--verbose）
+}
+
+// retryableError は通信エラーが一時的なもの（接続の失敗・DNSの失敗・タイムアウト・途中で切れた接続）かどうかを返します。
+// 証明書の検証エラーとプロキシの認証エラー、中断されたリクエストは送り直しても成功しないため対象外です。
+func retryableError(err error) bool {
+	var (
+		netErr     net.Error
+		verifyErr  *tls.CertificateVerificationError
+		unknownCA  x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+		proxyErr   *proxyError
+	)
+	switch {
+	case errors.Is(err, context.Canceled), errors.As(err, &verifyErr), errors.As(err, &unknownCA), errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return false
+	case errors.As(err, &proxyErr):
+		return errors.As(proxyErr.Err, &netErr)
+	}
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryableStatus はレスポンスが一時的な失敗（5xx、またはレート制限以外の 429）かどうかを返します。
+// レート制限による 429 は rateLimitTransport が解除を待って送り直します。
+func retryableStatus(resp *http.Response) bool {
+	if resp.StatusCode >= 500 {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests && resp.Header.Get("Retry-After") == "" && !rateLimitedResponse(resp)
+}
+
+// backoff は attempt 回目の送り直しまでの待機時間を返します。
+// 1秒・2秒・4秒…と倍々に延ばし、--retry-max-wait を上限として、その半分から全体までの間でばらつかせます。
+// レスポンスに Retry-After がある場合はその秒数を使います（上限は同じ）。
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			if d := time.Duration(secs) * time.Second; d < t.maxWait {
+				return d
+			}
+			return t.maxWait
+		}
+	}
+	d := retryBaseWait << (attempt - 1)
+	if d <= 0 || d > t.maxWait {
+		d = t.maxWait
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。試行ごとにリクエストを作り直して送ります。
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.retries == 0 || (req.Method != http.MethodGet && req.Method != http.MethodHead) || req.Body != nil && req.Body != http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+	endpoint := req.URL.Host + req.URL.Path
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req.Clone(req.Context()))
+		failure := ""
+		switch {
+		case err != nil && retryableError(err):
+			failure = err.Error()
+		case err == nil && retryableStatus(resp):
+			failure = resp.Status
+		}
+		if failure == "" || attempt > t.retries {
+			if failure == "" && attempt > 1 && t.verbose {
+				log.Printf("%s %s succeeded on attempt %d of %d", req.Method, endpoint, attempt, t.retries+1)
+			}
+			return resp, err
+		}
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		log.Printf("%s %s failed (%s); retrying in %s (attempt %d of %d)", req.Method, endpoint, failure, wait.Round(100*time.Millisecond), attempt+1, t.retries+1)
+		if !sleep(req, wait) {
+			return nil, req.Context().Err()
+		}
+	}
+}
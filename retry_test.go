@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"          // テスト用のサーバーの実装に使用
+	"net/http/httptest" // テスト用のサーバーの起動に使用
+	"strings"           // リクエストの本文の作成に使用
+	"sync"              // 試行回数の排他制御に使用
+	"testing"           // テストの実行に使用
+	"time"              // 待機時間の上限に使用
+)
+
+// flakyServer は最初の failures 回のリクエストに fail で応答し、その後は 200 を返すテスト用のサーバーを起動します。
+// fail が nil の場合は、接続を応答せずに閉じます（通信エラー）。
+func flakyServer(t *testing.T, failures int, fail func(w http.ResponseWriter)) (*httptest.Server, func() (int, []string)) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		auth     []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		n := attempts
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if n <= failures {
+			if fail == nil {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			fail(w)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() (int, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts, auth
+	}
+}
+
+// status は指定したステータスコード（とヘッダー）で応答する関数を返します。
+func status(code int, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+		w.Write([]byte(`{"message":"failure"}`))
+	}
+}
+
+// TestRetryTransport は一時的な失敗を N 回返した後に成功するサーバーに対して、送り直す条件と回数を確認します。
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		failures     int
+		fail         func(w http.ResponseWriter)
+		retries      int
+		wantStatus   int // 0 は通信エラー
+		wantAttempts int
+	}{
+		{"502 then success", http.MethodGet, 2, status(http.StatusBadGateway), 3, http.StatusOK, 3},
+		{"503 until retries run out", http.MethodGet, 5, status(http.StatusServiceUnavailable), 2, http.StatusServiceUnavailable, 3},
+		{"429 without rate limit headers", http.MethodGet, 1, status(http.StatusTooManyRequests), 3, http.StatusOK, 2},
+		{"connection closed then success", http.MethodGet, 2, nil, 3, http.StatusOK, 3},
+		{"connection closed until retries run out", http.MethodGet, 5, nil, 1, 0, 2},
+		{"HEAD is retried", http.MethodHead, 1, status(http.StatusBadGateway), 3, http.StatusOK, 2},
+		{"401 is not retried", http.MethodGet, 1, status(http.StatusUnauthorized), 3, http.StatusUnauthorized, 1},
+		{"404 is not retried", http.MethodGet, 1, status(http.StatusNotFound), 3, http.StatusNotFound, 1},
+		{"POST is not retried", http.MethodPost, 1, status(http.StatusBadGateway), 3, http.StatusBadGateway, 1},
+		{"retries disabled", http.MethodGet, 1, status(http.StatusBadGateway), 0, http.StatusBadGateway, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, attempts := flakyServer(t, tt.failures, tt.fail)
+			transport := &retryTransport{base: &http.Transport{}, retries: tt.retries, maxWait: 10 * time.Millisecond}
+			var body *strings.Reader
+			req, err := http.NewRequest(tt.method, server.URL+"/repos/o/r/pulls", nil)
+			if tt.method == http.MethodPost {
+				body = strings.NewReader(`{}`)
+				req, err = http.NewRequest(tt.method, server.URL+"/repos/o/r/pulls", body)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "token t")
+			resp, err := transport.RoundTrip(req)
+			gotStatus := 0
+			if err == nil {
+				gotStatus = resp.StatusCode
+				resp.Body.Close()
+			}
+			if gotStatus != tt.wantStatus {
+				t.Errorf("status = %d (err %v), want %d", gotStatus, err, tt.wantStatus)
+			}
+			n, auth := attempts()
+			if n != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", n, tt.wantAttempts)
+			}
+			// 送り直したリクエストにも同じヘッダーが付く
+			for i, a := range auth {
+				if a != "token t" {
+					t.Errorf("attempt %d sent Authorization %q", i+1, a)
+				}
+			}
+		})
+	}
+}
+
+// TestRetryBackoff は待機時間が倍々に延び、--retry-max-wait と Retry-After に従うことを確認します。
+func TestRetryBackoff(t *testing.T) {
+	transport := &retryTransport{maxWait: 5 * time.Second}
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 40: 5 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if d := transport.backoff(attempt, nil); d < base/2 || d > base {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, d, base/2, base)
+			}
+		}
+	}
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	if d := transport.backoff(1, resp); d != 3*time.Second {
+		t.Errorf("backoff with Retry-After: 3 = %s, want 3s", d)
+	}
+	resp.Header.Set("Retry-After", "60")
+	if d := transport.backoff(1, resp); d != 5*time.Second {
+		t.Errorf("backoff with Retry-After: 60 = %s, want the 5s maximum", d)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"     // 除外したPRの表示に使用
+	"regexp"  // 取り消しのタイトルと本文の参照の判定に使用
+	"strconv" // PR番号の変換に使用
+	"strings" // リポジトリ名の比較に使用
+)
+
+// PRが取り消し（revert）に関係するため除外された理由です。
+const (
+	skipReverted = "--exclude-reverted" // 後から取り消されたPR
+	skipRevertPR = "--exclude-reverted (revert PR)"
+)
+
+var (
+	// revertTitlePattern はGitHubが作成する取り消しのPRのタイトル（Revert "..."）に一致します。
+	revertTitlePattern = regexp.MustCompile(`^Revert\s+"(.*)"`)
+	// revertBodyPattern はGitHubが作成する取り消しのPRの本文の参照（Reverts owner/repo#N）に一致します。
+	// タイトルが翻訳・変更されていても、本文の参照から取り消されたPRを判定できます。
+	revertBodyPattern = regexp.MustCompile(`(?im)^\s*Reverts\s+(?:([\w.-]+/[\w.-]+))?#(\d+)`)
+	// prNumberPattern はタイトル中のPR番号（例: "Add foo (#123)"）に一致します。
+	prNumberPattern = regexp.MustCompile(`#(\d+)`)
+)
+
+// revertedNumbers はPRが取り消しのPRかを判定し、取り消されたPRの番号を返します。
+// 他のリポジトリのPRへの参照は無視します。
+//
+// パラメータ:
+//   - pr: 判定するプルリクエスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//
+// 戻り値:
+//   - []int: 取り消されたPRの番号（タイトルや本文から番号がわからない場合は空）
+//   - bool: 取り消しのPRの場合はtrue
+func revertedNumbers(pr PullRequest, owner, repo string) ([]int, bool) {
+	var numbers []int
+	isRevert := false
+	if m := revertTitlePattern.FindStringSubmatch(pr.Title); m != nil {
+		isRevert = true
+		for _, n := range prNumberPattern.FindAllStringSubmatch(m[1], -1) {
+			if v, err := strconv.Atoi(n[1]); err == nil && !containsInt(numbers, v) {
+				numbers = append(numbers, v)
+			}
+		}
+	}
+	for _, m := range revertBodyPattern.FindAllStringSubmatch(pr.Body, -1) {
+		if m[1] != "" && !strings.EqualFold(m[1], owner+"/"+repo) {
+			continue
+		}
+		isRevert = true
+		if v, err := strconv.Atoi(m[2]); err == nil && !containsInt(numbers, v) {
+			numbers = append(numbers, v)
+		}
+	}
+	return numbers, isRevert
+}
+
+// revertTracker は一覧をたどる間に見つけた取り消しのPRを記録し、取り消されたPRと取り消しのPRを除外します。
+type revertTracker struct {
+	owner       string
+	repo        string
+	keepReverts bool        // 取り消しのPR自体は残すか
+	revertedBy  map[int]int // 取り消されたPRの番号 → 取り消しのPRの番号
+	reverts     map[int]bool
+
+	Excluded []string // 除外したPRとその理由（"#12 (reverted by #15)" など）
+}
+
+// newRevertTracker は取り消しのPRを記録するための構造体を作成します。
+func newRevertTracker(owner, repo string, keepReverts bool) *revertTracker {
+	return &revertTracker{
+		owner:       owner,
+		repo:        repo,
+		keepReverts: keepReverts,
+		revertedBy:  make(map[int]int),
+		reverts:     make(map[int]bool),
+	}
+}
+
+// observe は一覧で見つけたマージ済みPRが取り消しのPRであれば、取り消されたPRを記録します。
+// 絞り込み条件を満たさないPRも含めて、期間内のすべてのマージ済みPRについて呼び出します。
+func (t *revertTracker) observe(pr PullRequest) {
+	numbers, isRevert := revertedNumbers(pr, t.owner, t.repo)
+	if !isRevert {
+		return
+	}
+	t.reverts[pr.Number] = true
+	for _, n := range numbers {
+		if _, ok := t.revertedBy[n]; !ok {
+			t.revertedBy[n] = pr.Number
+		}
+	}
+}
+
+// skipReason は取り消しに関係するPRを除外する理由を返します。残す場合は空文字を返します。
+func (t *revertTracker) skipReason(pr PullRequest) string {
+	if by, ok := t.revertedBy[pr.Number]; ok {
+		t.Excluded = append(t.Excluded, fmt.Sprintf("#%d (reverted by #%d)", pr.Number, by))
+		return skipReverted
+	}
+	if t.reverts[pr.Number] && !t.keepReverts {
+		t.Excluded = append(t.Excluded, fmt.Sprintf("#%d (revert PR)", pr.Number))
+		return skipRevertPR
+	}
+	return ""
+}
+
+// dropLate は選択した後に見つかった取り消しのPRによって取り消されたPRを除きます。
+// 一覧は新しい順にたどるため通常は取り消しのPRが先に見つかりますが、更新日時の順序によっては後になることがあります。
+func (t *revertTracker) dropLate(prs []PullRequest, filter *prFilter) []PullRequest {
+	kept := prs[:0]
+	for _, pr := range prs {
+		if reason := t.skipReason(pr); reason != "" {
+			filter.skip(reason)
+			continue
+		}
+		kept = append(kept, pr)
+	}
+	return kept
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/sha256" // トークンのフィンガープリントの計算に使用
+	"encoding/hex"  // フィンガープリントの文字列化に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"io"            // 集計の出力先に使用
+	"log"           // トークンの切り替えと待機の表示に使用
+	"net/http"      // HTTPクライアントの実装を提供
+	"strconv"       // レート制限のヘッダーの変換に使用
+	"strings"       // Authorization ヘッダーの解析に使用
+	"sync"          // 並行したリクエストからの更新の排他制御に使用
+	"time"          // レート制限が解除される時刻の管理に使用
+)
+
+// tokenSwitchThreshold は、使っているトークンの残りのリクエスト数がこれを下回ったら、残りが最も多いトークンに切り替える閾値です。
+const tokenSwitchThreshold = 100
+
+// tokenFingerprint はログと集計に表示する、トークンの短いフィンガープリントを返します（例: token:1a2b3c4d）。トークンの値は表示しません。
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])[:8]
+}
+
+// pooledToken は複数のトークンを切り替えて使う場合の、1つのトークンのレート制限の状況です。
+type pooledToken struct {
+	token     string    // トークン
+	remaining int       // 直近のレスポンスの X-RateLimit-Remaining（まだわからない場合は -1）
+	reset     time.Time // 直近のレスポンスの X-RateLimit-Reset
+	requests  int       // このトークンで送ったリクエストの数
+}
+
+// available は、トークンがあといくつリクエストを送れるかの見込みを返します（わからない場合やリセット済みの場合は上限なしとみなします）。
+func (t *pooledToken) available(now time.Time) int {
+	if t.remaining < 0 || !t.reset.IsZero() && now.After(t.reset) {
+		return int(^uint(0) >> 1)
+	}
+	return t.remaining
+}
+
+// tokenPool は複数のトークン（--token の繰り返し指定など）のレート制限を追跡し、使うトークンを選ぶ http.RoundTripper です。
+// 各関数は起動時のトークンを Authorization ヘッダーに設定するため、送信時にそのヘッダーを選んだトークンに差し替えます。
+// 使っているトークンの残りが tokenSwitchThreshold を下回ったら残りが最も多いトークンに切り替え、すべてのトークンを使い切った場合だけ、最も早く制限が解除されるまで待ちます。
+type tokenPool struct {
+	base http.RoundTripper // 実際にリクエストを送るトランスポート
+
+	mu      sync.Mutex     // 以下のフィールドを保護する
+	tokens  []*pooledToken // 指定された順のトークン
+	current int            // 使っているトークンの位置
+}
+
+// newTokenPool は指定されたトークンを切り替えて使う tokenPool を作成します。重複したトークンは1つにまとめます。
+func newTokenPool(base http.RoundTripper, tokens []string) *tokenPool {
+	p := &tokenPool{base: base}
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			p.tokens = append(p.tokens, &pooledToken{token: t, remaining: -1})
+		}
+	}
+	return p
+}
+
+// member は指定されたトークンが、切り替えの対象のトークンかどうかを返します。
+func (p *tokenPool) member(token string) bool {
+	for _, t := range p.tokens {
+		if t.token == token {
+			return true
+		}
+	}
+	return false
+}
+
+// pick は次のリクエストに使うトークンを選び、リクエスト数を数えます。
+// すべてのトークンを使い切っている場合は nil と、最も早く制限が解除されるまでの時間を返します。
+func (p *tokenPool) pick() (*pooledToken, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	cur := p.tokens[p.current]
+	if cur.available(now) < tokenSwitchThreshold {
+		best := p.current
+		for i, t := range p.tokens {
+			if t.available(now) > p.tokens[best].available(now) {
+				best = i
+			}
+		}
+		if p.tokens[best].available(now) == 0 {
+			wait := time.Duration(0)
+			for i, t := range p.tokens {
+				if d := t.reset.Sub(now); i == 0 || d < wait {
+					wait = d
+				}
+			}
+			return nil, wait
+		}
+		if best != p.current {
+			log.Printf("Switching from %s (%d requests remaining) to %s", tokenFingerprint(cur.token), cur.remaining, tokenFingerprint(p.tokens[best].token))
+			p.current = best
+			cur = p.tokens[best]
+		}
+	}
+	cur.requests++
+	return cur, 0
+}
+
+// observe はレスポンスのレート制限のヘッダーから、トークンの残りのリクエスト数と制限が解除される時刻を記録します。
+// 検索APIなど、通常のREST APIとは別の制限のレスポンスは記録せず、false を返します。
+func (p *tokenPool) observe(t *pooledToken, h http.Header) bool {
+	if resource := h.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return false
+	}
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t.remaining = remaining
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t.reset = time.Unix(reset, 0)
+	}
+	return true
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。
+// レート制限を使い切ったことによる 403・429 のレスポンスの場合は、ほかのトークンで送り直します（GET の場合のみ、トークンの数まで）。
+func (p *tokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent, found := strings.CutPrefix(req.Header.Get("Authorization"), "token ")
+	if !found || !p.member(sent) {
+		return p.base.RoundTrip(req)
+	}
+	for attempt := 1; ; attempt++ {
+		t, wait := p.pick()
+		if t == nil {
+			log.Printf("All %d tokens are out of requests; waiting %s for the rate limit to reset", len(p.tokens), wait.Round(time.Second))
+			select {
+			case <-time.After(wait + time.Second):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+			continue
+		}
+		r := req.Clone(req.Context())
+		r.Header.Set("Authorization", "token "+t.token)
+		resp, err := p.base.RoundTrip(r)
+		if err != nil {
+			return resp, err
+		}
+		exhausted := p.observe(t, resp.Header) && resp.Header.Get("X-RateLimit-Remaining") == "0"
+		if exhausted && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && req.Method == http.MethodGet && attempt <= len(p.tokens) {
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// report はトークンごとに送ったリクエストの数を、フィンガープリントで表示します。
+func (p *tokenPool) report(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(w, "Requests per token:")
+	for _, t := range p.tokens {
+		remaining := "unknown"
+		if t.remaining >= 0 {
+			remaining = strconv.Itoa(t.remaining)
+		}
+		fmt.Fprintf(w, "  %s: %d requests (%s remaining)\n", tokenFingerprint(t.token), t.requests, remaining)
+	}
+}
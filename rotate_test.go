@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"           // リクエストの期限に使用
+	"fmt"               // レスポンスのヘッダーの作成に使用
+	"net/http"          // テスト用のサーバーの実装に使用
+	"net/http/httptest" // テスト用のサーバーの起動に使用
+	"strings"           // Authorization ヘッダーの解析に使用
+	"sync"              // リクエストの記録の排他制御に使用
+	"testing"           // テストの実行に使用
+	"time"              // レート制限が解除される時刻の作成に使用
+)
+
+// TestTokenPoolSwitchesOnRateLimit は、1つのトークンがレート制限の超過（X-RateLimit-Remaining: 0 の 403）を返した場合に、
+// newAPIStack で重ねた rateLimitTransport がそのトークンの解除を待たず、tokenPool が別のトークンで送り直すことを確認します。
+func TestTokenPoolSwitchesOnRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	var mu sync.Mutex
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+		mu.Lock()
+		used = append(used, token)
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+		if token == "exhausted" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"API rate limit exceeded"}`)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+	withAPIBaseURL(t, server.URL)
+
+	for _, noWait := range []bool{false, true} {
+		t.Run(fmt.Sprintf("no-wait=%v", noWait), func(t *testing.T) {
+			mu.Lock()
+			used = nil
+			mu.Unlock()
+			stack, _, pool := newAPIStack(http.DefaultTransport, apiStackOptions{RateLimitFloor: 10, NoWait: noWait, Tokens: []string{"exhausted", "fresh"}})
+			if pool == nil {
+				t.Fatal("no token pool for two tokens")
+			}
+
+			// 1つ目のトークンの解除（1時間後）を待つと期限を過ぎる
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/repos/o/r/pulls", nil)
+			req.Header.Set("Authorization", "token exhausted")
+			resp, err := (&http.Client{Transport: stack}).Do(req)
+			if err != nil {
+				t.Fatalf("request failed instead of switching tokens: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status %d, want 200 from the second token", resp.StatusCode)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if strings.Join(used, ",") != "exhausted,fresh" {
+				t.Errorf("tokens sent %v, want the exhausted token once and then the fresh one", used)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"     // エラーメッセージの作成に使用
+	"os"      // ルールファイルの読み込みに使用
+	"regexp"  // ルールのパターンのコンパイルに使用
+	"sort"    // ルールの優先度順の並べ替えに使用
+	"strings" // 文字列操作に使用
+)
+
+// categoryUncategorized はルールファイルのどのルールにも一致しなかったコメントの分類です。
+const categoryUncategorized = "uncategorized"
+
+// categoryRule はルールファイルの1つのルールです。
+type categoryRule struct {
+	Name     string           // 分類名（コメントの category に設定される）
+	Patterns []*regexp.Regexp // いずれかに一致すればこのルールに該当する正規表現
+	Priority int              // 優先度（大きいものから順に判定する）
+}
+
+// categoryRules はルールファイルから読み込んだルールの集合です。
+type categoryRules []categoryRule
+
+// loadCategoryRules はYAML形式のルールファイルを読み込みます。
+// ファイルは "rules:" の下（またはトップレベル）に、name・patterns・priority を持つルールを並べた形式です。
+//
+//	rules:
+//	  - name: must
+//	    priority: 10
+//	    patterns: ['^MUST\b', '^\*\*MUST']
+//
+// パラメータ:
+//   - path: ルールファイルのパス
+//
+// 戻り値:
+//   - categoryRules: 優先度順に並べたルール
+//   - error: 読み込みや検証に失敗した場合は、問題のあるルールを示すエラー情報
+func loadCategoryRules(path string) (categoryRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	// "rules:" キーがあればその下を、なければトップレベルをルールの一覧とみなす
+	list := root
+	if root.Kind == yamlMapping {
+		for _, pair := range root.Pairs {
+			if pair.Key != "rules" {
+				return nil, fmt.Errorf("%s:%d: unknown key %q (expected \"rules\")", path, pair.Line, pair.Key)
+			}
+		}
+		if list = root.Get("rules"); list == nil {
+			return nil, fmt.Errorf("%s: no rules defined", path)
+		}
+	}
+	if list.Kind != yamlSequence || len(list.Items) == 0 {
+		return nil, fmt.Errorf("%s:%d: rules must be a non-empty list", path, list.Line)
+	}
+
+	var rules categoryRules
+	names := make(map[string]bool)
+	for i, item := range list.Items {
+		rule, err := parseCategoryRule(item, i)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%v", path, err) // err は "行番号: ルール: 内容" の形式
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("%s:%d: rule %q is defined more than once", path, item.Line, rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+	// 優先度の大きい順、同じ優先度ならファイルに書かれた順に判定する
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority > rules[j].Priority })
+	return rules, nil
+}
+
+// parseCategoryRule はルール1件分のYAMLノードを検証して categoryRule に変換します。
+func parseCategoryRule(node *yamlNode, index int) (categoryRule, error) {
+	label := fmt.Sprintf("rule #%d", index+1) // 名前が読めるまではルールの番号で示す
+	if node.Kind != yamlMapping {
+		return categoryRule{}, fmt.Errorf("%d: %s: expected a mapping with name, patterns and priority", node.Line, label)
+	}
+	var rule categoryRule
+	if n := node.Get("name"); n != nil {
+		name, err := n.String()
+		if err != nil || strings.TrimSpace(name) == "" {
+			return categoryRule{}, fmt.Errorf("%d: %s: name must be a non-empty string", n.Line, label)
+		}
+		rule.Name = strings.TrimSpace(name)
+		label = fmt.Sprintf("rule %q", rule.Name)
+	} else {
+		return categoryRule{}, fmt.Errorf("%d: %s: name is required", node.Line, label)
+	}
+	for _, pair := range node.Pairs {
+		switch pair.Key {
+		case "name":
+		case "priority":
+			p, err := pair.Value.Int()
+			if err != nil {
+				return categoryRule{}, fmt.Errorf("%d: %s: priority: %v", pair.Line, label, err)
+			}
+			rule.Priority = p
+		case "patterns":
+			patterns, err := pair.Value.StringList()
+			if err != nil {
+				return categoryRule{}, fmt.Errorf("%d: %s: patterns: %v", pair.Line, label, err)
+			}
+			for _, p := range patterns {
+				re, err := regexp.Compile(p)
+				if err != nil {
+					return categoryRule{}, fmt.Errorf("%d: %s: invalid pattern %q: %v", pair.Line, label, p, err)
+				}
+				rule.Patterns = append(rule.Patterns, re)
+			}
+		default:
+			return categoryRule{}, fmt.Errorf("%d: %s: unknown key %q", pair.Line, label, pair.Key)
+		}
+	}
+	if len(rule.Patterns) == 0 {
+		return categoryRule{}, fmt.Errorf("%d: %s: at least one pattern is required", node.Line, label)
+	}
+	return rule, nil
+}
+
+// classify は最初に一致したルールの名前を返します。どのルールにも一致しない場合は "uncategorized" を返します。
+func (rules categoryRules) classify(body string) string {
+	for _, rule := range rules {
+		for _, re := range rule.Patterns {
+			if re.MatchString(body) {
+				return rule.Name
+			}
+		}
+	}
+	return categoryUncategorized
+}
+
+// names はルールが付けうる分類名の一覧（"uncategorized" を含む）を返します。
+func (rules categoryRules) names() []string {
+	names := make([]string, 0, len(rules)+1)
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	return append(names, categoryUncategorized)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"            // ルールファイルの書き込みに使用
+	"path/filepath" // ルールファイルのパスの組み立てに使用
+	"strings"       // エラーメッセージの確認に使用
+	"testing"       // テストの実行に使用
+)
+
+// writeRules はルールファイルを一時ディレクトリに書き込み、そのパスを返します。
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadCategoryRules は優先度の大きい順、同じ優先度ならファイルに書かれた順にルールを判定することを確認します。
+func TestLoadCategoryRules(t *testing.T) {
+	path := writeRules(t, `rules:
+  - name: nit
+    patterns: ['(?i)^nit']
+  - name: question
+    patterns: ['\?$']
+  - name: must
+    priority: 10
+    patterns: ['^MUST', '^\*\*MUST']
+`)
+	rules, err := loadCategoryRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(rules.names(), ","); got != "must,nit,question,uncategorized" {
+		t.Errorf("rule order = %s", got)
+	}
+	tests := []struct{ body, want string }{
+		{"MUST fix this?", "must"},
+		{"**MUST** rename", "must"},
+		{"nit: why?", "nit"},
+		{"why?", "question"},
+		{"looks good", categoryUncategorized},
+	}
+	for _, tt := range tests {
+		if got := rules.classify(tt.body); got != tt.want {
+			t.Errorf("classify(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+// TestLoadCategoryRulesErrors は不正なルールファイルのエラーが、ファイル名・問題のある行の番号・ルールを示すことを確認します。
+func TestLoadCategoryRulesErrors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"unknown top-level key", "rule:\n  - name: a\n", `:1: unknown key "rule" (expected "rules")`},
+		{"empty rules", "rules: []\n", ":1: rules must be a non-empty list"},
+		{"not a mapping", "rules:\n  - just text\n", `:2: rule #1: expected a mapping`},
+		{"missing name", "rules:\n  - name: a\n    patterns: [x]\n  - patterns: [y]\n", ":4: rule #2: name is required"},
+		{"empty name", "rules:\n  - name: ''\n    patterns: [x]\n", ":2: rule #1: name must be a non-empty string"},
+		{"bad priority", "rules:\n  - name: must\n    priority: high\n    patterns: [x]\n", `:3: rule "must": priority: line 3: expected an integer, got "high"`},
+		{"bad pattern", "rules:\n  - name: must\n    patterns:\n      - ok\n      - '(unclosed'\n", `:3: rule "must": invalid pattern "(unclosed"`},
+		{"no patterns", "rules:\n  - name: must\n    priority: 1\n", `:2: rule "must": at least one pattern is required`},
+		{"unknown key", "rules:\n  - name: must\n    pattern: [x]\n", `:3: rule "must": unknown key "pattern"`},
+		{"duplicate rule", "rules:\n  - name: a\n    patterns: [x]\n  - name: a\n    patterns: [y]\n", `:4: rule "a" is defined more than once`},
+		{"YAML syntax", "rules:\n  - name: a\n    patterns: [x\n", "line 3: unterminated flow sequence"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeRules(t, tt.content)
+			_, err := loadCategoryRules(path)
+			if err == nil || !strings.HasPrefix(err.Error(), path) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadCategoryRules: %v; want an error starting with %s and containing %q", err, path, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json" // 抽出結果のJSON出力に使用
+	"fmt"           // フォーマット済み入出力に使用
+	"math/rand"     // シードを指定した無作為抽出に使用
+	"os"            // ファイル操作に使用
+	"path/filepath" // ファイルパス操作に使用
+	"sort"          // 抽出したPRを元の順序に戻すために使用
+)
+
+// sampleRecord は無作為抽出の結果（sample.json）を表す構造体です。
+// 同じシードで再実行したり、後から抽出の内容を確認したりするために保存します。
+type sampleRecord struct {
+	Seed       int64 `json:"seed"`       // 抽出に使ったシード
+	Candidates int   `json:"candidates"` // 抽出の対象になったPRの数
+	Sampled    []int `json:"sampled"`    // 抽出したPRの番号（元の並び順）
+}
+
+// samplePRs はPRの中から指定された数を無作為に選びます。
+// 同じシードと同じ候補からは常に同じPRを選び、選んだPRは候補の並び順（新しい順）のまま返します。
+//
+// パラメータ:
+//   - prs: 抽出の対象になるPR
+//   - n: 選ぶPRの数（候補の数以上の場合はすべてを返す）
+//   - seed: 乱数のシード
+//
+// 戻り値:
+//   - []PullRequest: 選んだPRの配列
+func samplePRs(prs []PullRequest, n int, seed int64) []PullRequest {
+	if n >= len(prs) {
+		return prs
+	}
+	picked := rand.New(rand.NewSource(seed)).Perm(len(prs))[:n]
+	sort.Ints(picked)
+	sampled := make([]PullRequest, 0, n)
+	for _, i := range picked {
+		sampled = append(sampled, prs[i])
+	}
+	return sampled
+}
+
+// saveSample は無作為抽出の結果を sample.json として保存します。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - record: 抽出の結果
+//
+// 戻り値:
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func saveSample(owner, repo string, record sampleRecord) error {
+	saveDir := commentsDir(owner, repo)
+	if err := os.MkdirAll(saveDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(saveDir, "sample.json"), append(data, '\n'), 0644)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"errors"   // 検索APIが使えないことを表すエラーの判定に使用
+	"fmt"      // フォーマット済み入出力に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"net/url"  // クエリパラメータの組み立てに使用
+	"strconv"  // ページ番号やレート制限のヘッダーの変換に使用
+	"strings"  // 検索クエリの組み立てに使用
+	"time"     // レート制限の解除時刻の計算に使用
+)
+
+// 検索APIの制限に関する定数です。
+const (
+	searchMaxResults = 1000             // 検索APIが1つのクエリに返す結果の上限
+	searchMaxWait    = 60 * time.Second // レート制限の解除を待つ最大時間（検索APIの制限は1分ごとに解除される）
+)
+
+// errSearchUnavailable は検索APIが使えない（レート制限の超過、無効なクエリ、一時的な障害など）ことを表すエラーです。
+// このエラーの場合は、クローズ済みPRの一覧をたどる方法に切り替えます。
+var errSearchUnavailable = errors.New("search API unavailable")
+
+// searchIssue は検索APIが返すIssue（PR）の情報を格納する構造体です。
+type searchIssue struct {
+	Number      int        `json:"number"`     // プルリクエスト番号
+	Title       string     `json:"title"`      // タイトル
+	User        User       `json:"user"`       // 作成者
+	Labels      []Label    `json:"labels"`     // 付与されているラベル
+	Milestone   *Milestone `json:"milestone"`  // 設定されているマイルストーン
+	Assignees   []User     `json:"assignees"`  // アサインされているユーザー
+	UpdatedAt   string     `json:"updated_at"` // 最後に更新された日時
+	PullRequest struct {
+		MergedAt *string `json:"merged_at"` // マージされた日時
+	} `json:"pull_request"`
+}
+
+// searchResult は検索APIのレスポンスを格納する構造体です。
+type searchResult struct {
+	TotalCount        int           `json:"total_count"`        // 一致した件数（取得できる件数は最大1000件）
+	IncompleteResults bool          `json:"incomplete_results"` // 検索がタイムアウトして結果が不完全な場合はtrue
+	Items             []searchIssue `json:"items"`              // 一致したIssue（PR）
+}
+
+// pullRequest は検索結果のIssueをPRの一覧と同じ形に変換します。
+// 検索結果には含まれない項目（レビューを依頼されているユーザーなど）は空のままです。
+func (s searchIssue) pullRequest() PullRequest {
+	return PullRequest{
+		Number:    s.Number,
+		User:      s.User,
+		MergedAt:  s.PullRequest.MergedAt,
+		UpdatedAt: s.UpdatedAt,
+		Title:     s.Title,
+		Assignees: s.Assignees,
+		Labels:    s.Labels,
+		Milestone: s.Milestone,
+	}
+}
+
+// searchQualifier は検索クエリの値を、空白やカンマを含む場合に引用符で囲みます。
+func searchQualifier(value string) string {
+	if strings.ContainsAny(value, " ,:\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// buildSearchQuery はPRの絞り込み条件を検索APIのクエリに変換します。
+// 検索APIで表現できない条件（正規表現によるタイトルの絞り込み、マージしたユーザーなど）は、
+// 結果を受け取った後に一覧の場合と同じ方法で判定します。
+//
+// パラメータ:
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - query: マージ日時の期間
+//   - filter: PRの絞り込み条件
+//
+// 戻り値:
+//   - string: 検索APIの q パラメータ
+func buildSearchQuery(owner, repo string, query prQuery, filter *prFilter) string {
+	terms := []string{"repo:" + owner + "/" + repo, "is:pr", "is:merged"}
+	for _, l := range filter.labels {
+		terms = append(terms, "label:"+searchQualifier(l)) // 複数の label: はすべて一致する必要がある
+	}
+	if len(filter.anyLabels) > 0 {
+		quoted := make([]string, len(filter.anyLabels))
+		for i, l := range filter.anyLabels {
+			quoted[i] = strconv.Quote(l) // label:"a","b" はいずれかに一致
+		}
+		terms = append(terms, "label:"+strings.Join(quoted, ","))
+	}
+	// 複数の作成者は検索クエリでは表現できないため、1人の場合だけ変換する
+	if len(filter.authors) == 1 {
+		for login := range filter.authors {
+			terms = append(terms, "author:"+searchQualifier(login))
+		}
+	}
+	for login := range filter.excludeAuthors {
+		terms = append(terms, "-author:"+searchQualifier(login))
+	}
+	if filter.milestoneTitle != "" {
+		terms = append(terms, "milestone:"+searchQualifier(filter.milestoneTitle))
+	}
+	if query.Base != "" {
+		terms = append(terms, "base:"+searchQualifier(query.Base))
+	}
+	if query.Since.IsSet {
+		terms = append(terms, "merged:>="+query.Since.Time.UTC().Format(time.RFC3339))
+	}
+	if query.Until.IsSet {
+		terms = append(terms, "merged:<"+query.Until.Time.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(terms, " ")
+}
+
+// searchRateLimited はレスポンスが検索APIのレート制限によるものかを判定し、制限が解除される時刻を返します。
+func searchRateLimited(resp apiResponse) (time.Time, bool) {
+	if resp.Status != http.StatusForbidden && resp.Status != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" && resp.Header.Get("Retry-After") == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(secs) * time.Second), true
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return time.Unix(reset, 0), true
+}
+
+// searchMergedPRs は検索API（/search/issues）を使って、最近マージされたプルリクエストを取得します。
+// クローズ済みPRの一覧をたどる fetchMergedPRs と同じ条件でPRを選びますが、マージされていないPRや
+// 条件を満たさないPRをAPI側で除外するため、PRの多いリポジトリでもリクエスト数を抑えられます。
+//
+// 検索APIは通常のAPIとは別のレート制限（1分あたりの回数）を持ち、1つのクエリに最大1000件しか返しません。
+// レート制限に達した場合は1分以内なら解除を待ち、それ以上かかる場合は errSearchUnavailable を返します。
+// 1000件の上限に達した場合は、その旨を表示してそれまでに見つかったPRを返します。
+// 検索の途中でAPI呼び出しの上限に達した場合は、それまでに見つかったPRと一緒に limitError を返します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名（ユーザー名または組織名）
+//   - repo: リポジトリ名
+//   - token: GitHub APIアクセス用のトークン
+//   - query: 取得する数・マージ日時の期間・たどるページ数の上限・PRを選ぶ順序
+//   - filter: PRの絞り込み条件（条件を満たさないPRは数に含めない）
+//   - out: 進捗の出力先
+//
+// 戻り値:
+//   - []PullRequest: マージ済みプルリクエストの配列
+//   - error: エラーが発生した場合はエラー情報（検索APIが使えない場合は errSearchUnavailable を含む）、成功時はnil
+func searchMergedPRs(ctx context.Context, owner, repo, token string, query prQuery, filter *prFilter, out *console) ([]PullRequest, error) {
+	var mergedPRs []PullRequest // マージ済みPRを格納するスライス
+	count := query.Count
+	// 検索APIはマージ日時での並べ替えに対応していないため、一覧の場合と同じく更新日時順に取得して手元で並べ替える
+	apiSort := prSortUpdated
+	if query.SortBy == prSortCreated {
+		apiSort = prSortCreated
+	}
+	byMerged := query.SortBy == prSortMerged
+	seen := make(map[int]bool) // すでに処理したPRの番号（ページ間での重複の検出に使用）
+	var stopped *limitError    // 検索の途中でAPI呼び出しの上限に達した場合の理由
+	var result searchResult    // 最後に取得したページの検索結果
+
+	// クエリパラメータを設定（ページ番号はページごとに設定する）
+	params := url.Values{}
+	params.Add("q", buildSearchQuery(owner, repo, query, filter)) // 検索クエリ
+	params.Add("sort", apiSort)                                   // 更新日時（または作成日時）でソート
+	params.Add("order", "desc")                                   // 降順（最新順）
+	params.Add("per_page", strconv.Itoa(prsPerPage))              // 1ページあたり100件取得（GitHub APIの上限）
+	r := apiRequest{URL: apiURL("/search/issues"), Query: params, Token: token}
+
+	err := walkPages(func(page int) (pageLinks, error) {
+		for {
+			// リクエストを送信してJSONをデコード（レスポンスボディはページごとに閉じる）
+			result = searchResult{}
+			resp, err := fetchJSON(ctx, apiClient, pageRequest(r, page), &result)
+			if resp.Status == 0 && err != nil {
+				return pageLinks{}, err
+			}
+
+			// 検索APIのレート制限に達した場合は、すぐに解除されるなら待って同じページを取得し直す
+			if reset, limited := searchRateLimited(resp); limited {
+				wait := time.Until(reset)
+				if reset.IsZero() || wait > searchMaxWait {
+					return pageLinks{}, fmt.Errorf("%w: search rate limit exceeded (resets at %s)", errSearchUnavailable, reset.Format(time.RFC3339))
+				}
+				if wait > 0 {
+					out.Printf("Search API rate limit reached; waiting %s for it to reset...\n", wait.Round(time.Second))
+					time.Sleep(wait)
+				}
+				continue
+			}
+			// ステータスコードをチェック（クエリが受け付けられない場合や一時的な障害の場合は一覧に切り替える）
+			switch resp.Status {
+			case http.StatusOK:
+				return resp.Links, err
+			case http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusServiceUnavailable:
+				return pageLinks{}, fmt.Errorf("%w: %w", errSearchUnavailable, err)
+			default:
+				return pageLinks{}, err
+			}
+		}
+	}, func(page int, links pageLinks) (bool, error) {
+		if result.IncompleteResults {
+			out.Printf("Warning: search results for page %d may be incomplete (GitHub timed out)\n", page)
+		}
+
+		// 結果が0件の場合は終了（これ以上PRがない）
+		if len(result.Items) == 0 {
+			return false, nil
+		}
+		var candidates []PullRequest // 変更ファイル以外の条件を満たしたPR
+		for _, item := range result.Items {
+			// 取得中にPRが更新されると、更新日時順の結果で次のページにも現れるため、2回目以降は読み飛ばす
+			if seen[item.Number] {
+				out.Printf("Warning: PR #%d appeared on more than one page of the search results; skipping the repeat\n", item.Number)
+				continue
+			}
+			seen[item.Number] = true
+			pr := item.pullRequest()
+			// 古いGitHubでは検索結果にマージ日時が含まれないため、PRの詳細から補う
+			if pr.MergedAt == nil {
+				detail, err := filter.details.get(pr.Number)
+				if err != nil {
+					return false, err
+				}
+				pr.MergedAt = detail.MergedAt
+			}
+			if pr.MergedAt == nil || !mergedWithin(*pr.MergedAt, query.Since, query.Until) {
+				continue
+			}
+			// 検索クエリで表現できなかった条件は、一覧の場合と同じく手元で判定する
+			filter.observe(pr)
+			if !filter.keep(pr) {
+				continue
+			}
+			candidates = append(candidates, pr)
+			if !filter.checksFiles() && !byMerged && count > 0 && len(mergedPRs)+len(candidates) >= count {
+				break // 指定数に達したらループを終了
+			}
+		}
+		mergedPRs = append(mergedPRs, filter.touching(candidates, remaining(count, len(mergedPRs), byMerged))...)
+		// 定期的に進捗を表示
+		if page%progressEveryPages == 0 {
+			out.Printf("Searched %d pages of PRs, %d merged PRs selected so far...\n", page, len(mergedPRs))
+		}
+		// 最後のページ（Linkヘッダーに rel="next" がない）の場合は終了
+		if !links.HasNext {
+			return false, nil
+		}
+		// マージ日時順の場合、ページの最後の更新日時がN番目のマージ日時より前なら、以降のPRが上位N件に入ることはない
+		last := result.Items[len(result.Items)-1].pullRequest()
+		if byMerged && count > 0 && len(mergedPRs) >= count && updatedBefore(last, nthMergedAt(mergedPRs, count)) {
+			return false, nil
+		}
+		// 検索APIは1000件までしか返さないため、それ以上一致する場合は黙って少ない数を返さないよう明示して終了
+		if page*prsPerPage >= searchMaxResults {
+			if count == 0 || len(mergedPRs) < count || byMerged {
+				out.Printf("Warning: search matched %d PRs but GitHub returns only the first %d; narrow the window with --since/--until or drop --use-search\n", result.TotalCount, searchMaxResults)
+			}
+			return false, nil
+		}
+		// たどるページ数の上限に達した場合は、その旨を表示して終了
+		if query.MaxScanPages > 0 && page >= query.MaxScanPages {
+			if count == 0 || len(mergedPRs) < count {
+				out.Printf("Warning: stopped after scanning %d pages (--max-scan-pages); found %d matching merged PRs\n", page, len(mergedPRs))
+			}
+			return false, nil
+		}
+		return count == 0 || byMerged || len(mergedPRs) < count, nil
+	})
+	// API呼び出しの上限に達した場合は、それまでに選んだPRを返す
+	if err != nil && !errors.As(err, &stopped) {
+		return nil, err
+	}
+
+	// マージ日時の新しい順に並べ替え
+	if byMerged {
+		sortByMergedAt(mergedPRs)
+	}
+	// 指定された数よりも多く取得した場合は切り詰め
+	if count > 0 && len(mergedPRs) > count {
+		mergedPRs = mergedPRs[:count]
+	}
+	if stopped != nil {
+		return mergedPRs, stopped
+	}
+	return mergedPRs, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"sort" // コメントの並べ替えに使用
+	"time" // 日時の比較に使用
+)
+
+// コメントの並び順の基準の定数です。
+const (
+	sortAPI       = "api"       // APIが返した順（デフォルト）
+	sortCreated   = "created"   // 作成日時順
+	sortUpdated   = "updated"   // 更新日時順
+	sortPath      = "path"      // ファイルパスと行番号順（パスのないコメントは最後）
+	sortReactions = "reactions" // リアクション数順
+)
+
+// 並び順の方向の定数です。
+const (
+	orderAsc    = "asc"    // 昇順（デフォルト）
+	orderDesc   = "desc"   // 降順
+	orderChrono = "chrono" // マージモードで、PRに関係なくすべてのコメントを作成日時順に並べる
+)
+
+// validSort は並び順の基準が対応しているものかを判定します。
+func validSort(key string) bool {
+	switch key {
+	case sortAPI, sortCreated, sortUpdated, sortPath, sortReactions:
+		return true
+	}
+	return false
+}
+
+// sortComments はPR内のコメントを指定された基準で並べ替えます。
+// 基準が同じコメントはAPIが返した順を保ちます。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列が直接並べ替えられます）
+//   - key: 並び順の基準（sortAPI など）
+//   - desc: trueの場合は降順（sortPath でもパスのないコメントは最後のまま）
+func sortComments(comments []Comment, key string, desc bool) {
+	if key == sortAPI {
+		if desc {
+			for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+				comments[i], comments[j] = comments[j], comments[i]
+			}
+		}
+		return
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if key == sortPath && (a.Path == "") != (b.Path == "") {
+			return b.Path == ""
+		}
+		c := compareComments(a, b, key)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// compareComments は2件のコメントを基準に従って比較し、a が前なら負、後なら正、同じなら0を返します。
+func compareComments(a, b Comment, key string) int {
+	switch key {
+	case sortCreated:
+		return compareTimestamps(a.CreatedAt, b.CreatedAt)
+	case sortUpdated:
+		return compareTimestamps(a.UpdatedAt, b.UpdatedAt)
+	case sortReactions:
+		return a.Reactions.TotalCount - b.Reactions.TotalCount
+	case sortPath:
+		if a.Path != b.Path {
+			if a.Path < b.Path {
+				return -1
+			}
+			return 1
+		}
+		return lineNumber(a) - lineNumber(b)
+	}
+	return 0
+}
+
+// compareTimestamps はRFC3339形式の日時を比較します。解析できない場合は文字列として比較します。
+func compareTimestamps(a, b string) int {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	return ta.Compare(tb)
+}
+
+// lineNumber はコメントの行番号を返します（行番号のないコメントは0）。
+func lineNumber(c Comment) int {
+	if c.Line == nil {
+		return 0
+	}
+	return *c.Line
+}
+
+// sortChronologically はマージモードのすべてのコメントを、PRに関係なく作成日時の昇順に並べ替えます。
+// 作成日時を解析できないコメントが1件でもある場合は、警告を表示して元の順序のままにします。
+//
+// パラメータ:
+//   - allComments: すべてのPRのコメント（この配列が直接並べ替えられます）
+//   - out: 警告の出力先
+func sortChronologically(allComments []PRComment, out *console) {
+	created := make([]time.Time, len(allComments))
+	invalid := 0
+	for i, pc := range allComments {
+		t, err := time.Parse(time.RFC3339, pc.Comment.CreatedAt)
+		if err != nil {
+			invalid++
+			continue
+		}
+		created[i] = t
+	}
+	if invalid > 0 {
+		out.Logf("Warning: %d comments have an unparsable created_at; keeping the original order instead of --order chrono", invalid)
+		return
+	}
+	// 並べ替えと一緒に作成日時も入れ替えるため、インデックスで並べ替える
+	index := make([]int, len(allComments))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool { return created[index[i]].Before(created[index[j]]) })
+	sorted := make([]PRComment, len(allComments))
+	for i, k := range index {
+		sorted[i] = allComments[k]
+	}
+	copy(allComments, sorted)
+}
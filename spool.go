@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/sha256" // 書き込んだ本文のハッシュの計算に使用
+	"encoding/hex"  // ハッシュの文字列化に使用
+	"hash"          // 書き込んだ本文のハッシュの計算に使用
+	"io"            // レスポンスの本文の読み込みに使用
+	"log"           // 書き込みの失敗の表示に使用
+	"os"            // 一時ファイルの操作に使用
+)
+
+// spooledBody はレスポンスの本文を一時ファイルに書き込んだ結果です。
+type spooledBody struct {
+	Path   string // 本文を書き込んだ一時ファイル（save で移動しなかった場合は削除する）
+	Size   int64  // 本文のバイト数
+	SHA256 string // 本文のハッシュ
+}
+
+// spoolBody はレスポンスの本文を読みながら、受け取ったバイト列をそのまま一時ファイルに書き込む io.ReadCloser です。
+// 本文を最後まで読み終えてから閉じた場合だけ save を呼ぶため、途中で打ち切られた本文は保存しません。
+// 本文を全部メモリに読み込まないため、逐次デコード（decodeJSON）の利点を損ないません。
+type spoolBody struct {
+	io.ReadCloser
+	what string                  // 警告に表示する保存先の種類（例: "cache"）
+	save func(spooledBody) error // 本文を最後まで読んだ場合に、一時ファイルを保存先に移す関数
+	tmp  *os.File                // 書き込み中の本文（書き込みに失敗した場合はnil）
+	hash hash.Hash               // 書き込んだ本文のハッシュ
+	size int64                   // 書き込んだ本文のバイト数
+	eof  bool                    // 本文を最後まで読んだか
+}
+
+// newSpoolBody は本文を dir の一時ファイルに書き込む spoolBody を作成します。一時ファイルを作れない場合は警告を表示し、本文をそのまま返します。
+//
+// パラメータ:
+//   - body: レスポンスの本文
+//   - dir: 一時ファイルを作成するディレクトリ（保存先と同じディレクトリにすると、移動が置き換えになる）
+//   - what: 警告に表示する保存先の種類
+//   - save: 本文を最後まで読んだ場合に呼ぶ関数
+//
+// 戻り値:
+//   - io.ReadCloser: レスポンスの本文の代わりに使う io.ReadCloser
+func newSpoolBody(body io.ReadCloser, dir, what string, save func(spooledBody) error) io.ReadCloser {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Printf("Warning: failed to write %s: %v", what, err)
+		return body
+	}
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		log.Printf("Warning: failed to write %s: %v", what, err)
+		return body
+	}
+	return &spoolBody{ReadCloser: body, what: what, save: save, tmp: tmp, hash: sha256.New()}
+}
+
+// Read は io.Reader インタフェースの実装です。読んだ本文を一時ファイルにも書き込みます。
+func (b *spoolBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.tmp != nil {
+		if _, werr := b.tmp.Write(p[:n]); werr != nil {
+			log.Printf("Warning: failed to write %s: %v", b.what, werr)
+			b.discard()
+		} else {
+			b.hash.Write(p[:n])
+			b.size += int64(n)
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// discard は書き込み中の一時ファイルを削除します。
+func (b *spoolBody) discard() {
+	if b.tmp != nil {
+		b.tmp.Close()
+		os.Remove(b.tmp.Name())
+		b.tmp = nil
+	}
+}
+
+// Close は io.Closer インタフェースの実装です。本文を最後まで読んだ場合は、一時ファイルを save に渡します。
+func (b *spoolBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.tmp == nil {
+		return err
+	}
+	if !b.eof {
+		b.discard()
+		return err
+	}
+	path := b.tmp.Name()
+	cerr := b.tmp.Close()
+	b.tmp = nil
+	if cerr != nil {
+		log.Printf("Warning: failed to write %s: %v", b.what, cerr)
+		os.Remove(path)
+		return err
+	}
+	if serr := b.save(spooledBody{Path: path, Size: b.size, SHA256: hex.EncodeToString(b.hash.Sum(nil))}); serr != nil {
+		log.Printf("Warning: failed to write %s: %v", b.what, serr)
+	}
+	os.Remove(path) // save で移動した場合は何もしない
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"     // 省略マーカーの作成に使用
+	"strings" // 文字列操作に使用
+)
+
+// codeOmittedMarker はコードを取り除いた位置に置くマーカーを返します。
+func codeOmittedMarker(lines int) string {
+	if lines == 1 {
+		return "[code omitted: 1 line]"
+	}
+	return fmt.Sprintf("[code omitted: %d lines]", lines)
+}
+
+// stripCode はコメント本文からフェンスドコードブロック（``` と ~~~、suggestion ブロックを含む）を取り除き、
+// "[code omitted: N lines]" のマーカーに置き換えます。
+// 閉じられていないフェンスは本文の最後までをコードとみなします（CommonMarkと同じ扱い）。
+// フェンスの中に短いフェンスや別の文字のフェンスがあっても、開いたフェンスと同じ文字で同じ長さ以上のフェンスが来るまでは閉じません。
+//
+// パラメータ:
+//   - body: コメント本文（Markdown）
+//   - inline: trueの場合はインラインコード（`code`）も取り除く
+//
+// 戻り値:
+//   - string: コードを取り除いた本文
+//   - bool: 何か取り除いたかどうか
+func stripCode(body string, inline bool) (string, bool) {
+	lines := strings.SplitAfter(body, "\n")
+	var b strings.Builder
+	stripped := false
+	fenceChar, fenceLen := byte(0), 0 // 現在開いているフェンスの文字と長さ（開いていなければ0）
+	codeLines := 0                    // 現在のブロック内のコードの行数
+	for _, line := range lines {
+		if char, n, ok := fenceMarker(line); ok {
+			switch {
+			case fenceLen == 0:
+				// フェンスの開始
+				fenceChar, fenceLen, codeLines = char, n, 0
+				continue
+			case char == fenceChar && n >= fenceLen && strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), string(char))) == "":
+				// フェンスの終了
+				fenceChar, fenceLen = 0, 0
+				b.WriteString(codeOmittedMarker(codeLines) + "\n")
+				stripped = true
+				continue
+			}
+		}
+		if fenceLen > 0 {
+			if line != "" {
+				codeLines++
+			}
+			continue
+		}
+		if inline {
+			var ok bool
+			line, ok = stripInlineCode(line)
+			stripped = stripped || ok
+		}
+		b.WriteString(line)
+	}
+	if fenceLen > 0 {
+		// 閉じられていないフェンスは最後までをコードとして扱う
+		b.WriteString(codeOmittedMarker(codeLines))
+		stripped = true
+	}
+	return b.String(), stripped
+}
+
+// stripInlineCode は1行のうちインラインコードをマーカーに置き換えます。
+// 閉じられていないバッククォートは通常の文字として残すため、文中にバッククォートがあるだけの文章は変わりません。
+func stripInlineCode(line string) (string, bool) {
+	var b strings.Builder
+	stripped := false
+	textStart := 0
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(line) && line[i+n] == '`' {
+			n++
+		}
+		end := findBacktickRun(line, i+n, n)
+		if end < 0 {
+			i += n
+			continue
+		}
+		b.WriteString(line[textStart:i])
+		b.WriteString(codeOmittedMarker(1))
+		stripped = true
+		i = end + n
+		textStart = i
+	}
+	b.WriteString(line[textStart:])
+	return b.String(), stripped
+}
+
+// stripCodeFromComments は各コメントの本文からコードを取り除きます。
+// 取り除いたコメントはレンダリング済みHTMLを破棄し、HTML出力でもコードを取り除いた本文が表示されるようにします。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+//   - inline: trueの場合はインラインコードも取り除く
+func stripCodeFromComments(comments []Comment, inline bool) {
+	for i := range comments {
+		body, stripped := stripCode(comments[i].Body, inline)
+		if stripped {
+			comments[i].Body = body
+			comments[i].BodyHTML = ""
+		}
+	}
+}
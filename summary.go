@@ -0,0 +1,130 @@
+package main
+
+import (
+	"sort"    // 表示順の並べ替えに使用
+	"strings" // 期間の表示の作成に使用
+	"time"    // 期間の表示に使用
+)
+
+// runSummary は実行全体の集計結果を保持する構造体です。
+// 実行の最後に標準出力へ表示します。
+type runSummary struct {
+	PRs                 int            // 処理したPRの数
+	Window              string         // PRを選択したマージ日時の期間（--since/--until/--days 指定時のみ）
+	SkippedPRs          map[string]int // PRの絞り込みで除外された理由ごとのPR数
+	TouchesExamined     int            // --touches のために変更ファイルを調べたPRの数
+	TouchesSkipped      int            // --touches で除外したPRの数
+	TruncatedPRs        int            // --max-comments-per-pr でコメントの取得を打ち切ったPRの数
+	RevertExclusions    []string       // --exclude-reverted で除外したPRとその理由
+	Comments            int            // 保存したコメントの数
+	DeletedUserComments int            // 削除されたユーザーによるコメントの数
+	Dropped             map[string]int // 絞り込みで除外された理由ごとのコメント数
+	Matched             map[string]int // 絞り込み条件ごとの一致したコメント数
+	Redactions          map[string]int // --redact で伏せた種類ごとの件数（--redact 指定時のみ）
+	QuoteCharsRemoved   int            // --strip-quotes で取り除いた引用の文字数
+	UnparsedTimestamps  int            // --timezone で変換できずにそのまま出力した日時の数
+}
+
+// addComments は保存するコメントを集計に加えます。
+func (s *runSummary) addComments(comments []Comment) {
+	s.Comments += len(comments)
+	for _, c := range comments {
+		if c.IsDeletedUser() {
+			s.DeletedUserComments++
+		}
+	}
+}
+
+// addFilterCounts はPRごとの絞り込み結果の件数を集計に加えます。
+func (s *runSummary) addFilterCounts(counts filterCounts) {
+	if s.Dropped == nil {
+		s.Dropped = make(map[string]int)
+		s.Matched = make(map[string]int)
+	}
+	for reason, n := range counts.Dropped {
+		s.Dropped[reason] += n
+	}
+	for cond, n := range counts.Matched {
+		s.Matched[cond] += n
+	}
+}
+
+// addSkippedPRs はPRの絞り込みで除外されたPRの数を集計に加えます。
+func (s *runSummary) addSkippedPRs(skipped map[string]int) {
+	if len(skipped) == 0 {
+		return
+	}
+	if s.SkippedPRs == nil {
+		s.SkippedPRs = make(map[string]int)
+	}
+	for reason, n := range skipped {
+		s.SkippedPRs[reason] += n
+	}
+}
+
+// sortedKeys はマップのキーを名前順に並べて返します。集計を毎回同じ順序で表示するために使用します。
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// print は集計結果を out に表示します。
+func (s *runSummary) print(out *console) {
+	out.Printf("Summary: %d PRs processed, %d comments saved\n", s.PRs, s.Comments)
+	if s.Window != "" {
+		out.Printf("  PRs merged %s\n", s.Window)
+	}
+	if s.TouchesExamined > 0 {
+		out.Printf("  PRs examined for --touches: %d (%d kept)\n", s.TouchesExamined, s.TouchesExamined-s.TouchesSkipped)
+	}
+	for _, reason := range sortedKeys(s.SkippedPRs) {
+		out.Printf("  PRs skipped by %s: %d\n", reason, s.SkippedPRs[reason])
+	}
+	if len(s.RevertExclusions) > 0 {
+		out.Printf("  PRs excluded as reverted: %s\n", strings.Join(s.RevertExclusions, ", "))
+	}
+	if s.TruncatedPRs > 0 {
+		out.Printf("  PRs truncated by --max-comments-per-pr: %d\n", s.TruncatedPRs)
+	}
+	if s.DeletedUserComments > 0 {
+		out.Printf("  Comments from deleted users (shown as %s): %d\n", deletedUserLogin, s.DeletedUserComments)
+	}
+	for _, cond := range sortedKeys(s.Matched) {
+		out.Printf("  Comments matching %s: %d\n", cond, s.Matched[cond])
+	}
+	for _, reason := range sortedKeys(s.Dropped) {
+		out.Printf("  Comments filtered out by %s: %d\n", reason, s.Dropped[reason])
+	}
+	if s.QuoteCharsRemoved > 0 {
+		out.Printf("  Quoted characters removed from replies: %d\n", s.QuoteCharsRemoved)
+	}
+	if s.UnparsedTimestamps > 0 {
+		out.Printf("  Warning: %d timestamps could not be parsed and were left unconverted\n", s.UnparsedTimestamps)
+	}
+	if s.Redactions != nil {
+		total := 0
+		for _, n := range s.Redactions {
+			total += n
+		}
+		out.Printf("  Redactions: %d\n", total)
+		for _, kind := range sortedKeys(s.Redactions) {
+			out.Printf("    <%s>: %d\n", kind, s.Redactions[kind])
+		}
+	}
+}
+
+// formatWindow はPRを選択したマージ日時の期間を "from 2024-05-01T00:00:00Z until 2024-06-01T00:00:00Z" の形式で返します。
+func formatWindow(since, until timeFlag) string {
+	var parts []string
+	if since.IsSet {
+		parts = append(parts, "from "+since.Time.Format(time.RFC3339))
+	}
+	if until.IsSet {
+		parts = append(parts, "until "+until.Time.Format(time.RFC3339))
+	}
+	return strings.Join(parts, " ")
+}
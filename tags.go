@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"fmt"      // フォーマット済み入出力に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"net/url"  // タグ名のエスケープとクエリパラメータの組み立てに使用
+	"sort"     // 近いタグ名の並べ替えに使用
+	"strings"  // タグ名の比較に使用
+	"time"     // コミット日時の解析に使用
+)
+
+// nearestTagCount は存在しないタグを指定した場合に表示する、近い名前のタグの数です。
+const nearestTagCount = 5
+
+// tagRef はタグが指すコミットの情報を格納する構造体です。
+type tagRef struct {
+	Name string    // タグ名
+	SHA  string    // タグが指すコミットのSHA
+	Date time.Time // コミット日時
+}
+
+// gitRef はGit参照APIのレスポンスを格納する構造体です。
+type gitRef struct {
+	Object struct {
+		SHA  string `json:"sha"`  // 参照が指すオブジェクトのSHA
+		Type string `json:"type"` // オブジェクトの種類（commit、または注釈付きタグの場合は tag）
+	} `json:"object"`
+}
+
+// commitDetail はコミットAPIのレスポンスのうち、SHAとコミット日時を格納する構造体です。
+type commitDetail struct {
+	SHA    string `json:"sha"` // コミットSHA
+	Commit struct {
+		Committer struct {
+			Date string `json:"date"` // コミット日時
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// resolveTag はタグが指すコミットとそのコミット日時を取得します。
+// 注釈付きタグの場合は、タグオブジェクトをたどってコミットを求めます。
+// タグが存在しない場合は、名前の近いタグの一覧をエラーに含めます。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - name: タグ名（例: v2.3.0）
+//   - token: GitHub APIアクセス用のトークン
+//   - client: リクエストに使用するHTTPクライアント
+//
+// 戻り値:
+//   - tagRef: タグが指すコミットの情報
+//   - error: タグが存在しない場合や取得に失敗した場合のエラー情報
+func resolveTag(ctx context.Context, owner, repo, name, token string, client *http.Client) (tagRef, error) {
+	base := apiURL("/repos/%s/%s", owner, repo)
+	var ref gitRef
+	status, err := getJSON(ctx, client, base+"/git/ref/tags/"+url.PathEscape(name), token, &ref)
+	if status == http.StatusNotFound {
+		names, listErr := fetchTagNames(ctx, owner, repo, token, client)
+		if listErr != nil || len(names) == 0 {
+			return tagRef{}, fmt.Errorf("unknown tag %q in %s/%s", name, owner, repo)
+		}
+		return tagRef{}, fmt.Errorf("unknown tag %q (nearest: %s)", name, strings.Join(nearestNames(name, names, nearestTagCount), ", "))
+	}
+	if err != nil {
+		return tagRef{}, err
+	}
+	// 注釈付きタグはタグオブジェクトを指すため、その先のコミットを求める
+	sha := ref.Object.SHA
+	if ref.Object.Type == "tag" {
+		var tag gitRef
+		if _, err := getJSON(ctx, client, base+"/git/tags/"+sha, token, &tag); err != nil {
+			return tagRef{}, err
+		}
+		sha = tag.Object.SHA
+	}
+	var commit commitDetail
+	if _, err := getJSON(ctx, client, base+"/commits/"+sha, token, &commit); err != nil {
+		return tagRef{}, err
+	}
+	date, err := time.Parse(time.RFC3339, commit.Commit.Committer.Date)
+	if err != nil {
+		return tagRef{}, fmt.Errorf("tag %q: invalid commit date %q", name, commit.Commit.Committer.Date)
+	}
+	return tagRef{Name: name, SHA: commit.SHA, Date: date}, nil
+}
+
+// tagEntry はタグの一覧APIのレスポンスの1件です。
+type tagEntry struct {
+	Name string `json:"name"` // タグ名
+}
+
+// fetchTagNames はリポジトリのタグ名をすべて取得します。
+func fetchTagNames(ctx context.Context, owner, repo, token string, client *http.Client) ([]string, error) {
+	var names []string
+	q := url.Values{}
+	q.Add("per_page", "100") // 1ページあたり100件取得
+	_, err := paginate(ctx, client, apiRequest{URL: apiURL("/repos/%s/%s/tags", owner, repo), Query: q, Token: token}, func(_ int, tags []tagEntry, _ apiResponse) (bool, error) {
+		for _, t := range tags {
+			names = append(names, t.Name)
+		}
+		return len(tags) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// nearestNames は名前の近い順（編集距離の小さい順、同じ場合は名前順）に最大n件の名前を返します。
+func nearestNames(target string, names []string, n int) []string {
+	sorted := append([]string(nil), names...)
+	distance := make(map[string]int, len(sorted))
+	for _, name := range sorted {
+		distance[name] = editDistance(strings.ToLower(target), strings.ToLower(name))
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if distance[sorted[i]] != distance[sorted[j]] {
+			return distance[sorted[i]] < distance[sorted[j]]
+		}
+		return sorted[i] < sorted[j]
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// editDistance は2つの文字列のレーベンシュタイン距離を返します。
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// minInt は引数の最小値を返します。
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, v := range rest {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// compareResult は2つのコミットの比較APIのレスポンスのうち、範囲に含まれるコミットの一覧です。
+type compareResult struct {
+	Commits []struct {
+		SHA string `json:"sha"` // コミットSHA
+	} `json:"commits"`
+}
+
+// fetchCompareCommits は2つのタグの間（from を含まず、to を含む）のコミットのSHAを取得します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - from: 比較の起点のタグ
+//   - to: 比較の終点のタグ
+//   - token: GitHub APIアクセス用のトークン
+//   - client: リクエストに使用するHTTPクライアント
+//
+// 戻り値:
+//   - map[string]bool: 範囲に含まれるコミットのSHAの集合
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func fetchCompareCommits(ctx context.Context, owner, repo string, from, to tagRef, token string, client *http.Client) (map[string]bool, error) {
+	shas := make(map[string]bool)
+	q := url.Values{}
+	q.Add("per_page", "100") // 1ページあたり100件取得
+	_, err := paginate(ctx, client, apiRequest{URL: apiURL("/repos/%s/%s/compare/%s...%s", owner, repo, from.SHA, to.SHA), Query: q, Token: token}, func(_ int, compare compareResult, _ apiResponse) (bool, error) {
+		for _, c := range compare.Commits {
+			shas[c.SHA] = true
+		}
+		return len(compare.Commits) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shas, nil
+}
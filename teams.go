@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"fmt"      // フォーマット済み入出力に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"net/url"  // クエリパラメータの組み立てに使用
+	"strings"  // 文字列操作に使用
+)
+
+// fetchTeamMembers は指定されたチームのメンバーのユーザー名を取得します。
+// チームは "org/slug" 形式（先頭の "@" は省略可）で指定します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - team: "org/slug" 形式のチーム名
+//   - token: GitHub APIアクセス用のトークン（read:org スコープが必要）
+//
+// 戻り値:
+//   - []string: メンバーのユーザー名の配列
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func fetchTeamMembers(ctx context.Context, team, token string) ([]string, error) {
+	org, slug, ok := strings.Cut(strings.TrimPrefix(team, "@"), "/")
+	if !ok || org == "" || slug == "" {
+		return nil, fmt.Errorf("invalid team %q (expected org/slug)", team)
+	}
+
+	var members []string // メンバーを格納するスライス
+
+	// クエリパラメータを設定（ページ番号は paginate が設定する）
+	q := url.Values{}
+	q.Add("per_page", "100") // 1ページあたり100件取得
+
+	// 全ページのメンバーを取得（レスポンスボディはページごとに閉じる）
+	resp, err := paginate(ctx, apiClient, apiRequest{URL: apiURL("/orgs/%s/teams/%s/members", org, slug), Query: q, Token: token}, func(_ int, users []User, _ apiResponse) (bool, error) {
+		members = append(members, userLogins(users)...)
+		return len(users) > 0, nil
+	})
+	// トークンに read:org スコープがない場合、GitHubは403または404を返す
+	if resp.Status == http.StatusForbidden || resp.Status == http.StatusNotFound {
+		scopes := resp.Header.Get("X-OAuth-Scopes")
+		if scopes != "" && !hasOrgReadScope(scopes) {
+			return nil, fmt.Errorf("cannot read members of team %s: the token lacks the read:org scope (token scopes: %s)", team, scopes)
+		}
+		return nil, fmt.Errorf("cannot read members of team %s: team not found or not visible to this token (read:org scope is required; %v)", team, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// hasOrgReadScope は X-OAuth-Scopes ヘッダーの値に組織のメンバーを読むためのスコープが含まれているかを返します。
+func hasOrgReadScope(scopes string) bool {
+	for _, s := range strings.Split(scopes, ",") {
+		switch strings.TrimSpace(s) {
+		case "read:org", "write:org", "admin:org":
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTeams は複数のチームのメンバーを取得し、大文字小文字を区別しない集合にまとめます。
+// メンバーは実行の開始時に一度だけ取得し、コメントごとには問い合わせません。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - teams: "org/slug" 形式のチーム名の配列
+//   - token: GitHub APIアクセス用のトークン
+//
+// 戻り値:
+//   - map[string]bool: メンバーのユーザー名（小文字）の集合
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func resolveTeams(ctx context.Context, teams []string, token string) (map[string]bool, error) {
+	var members []string
+	for _, team := range teams {
+		m, err := fetchTeamMembers(ctx, team, token)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m...)
+	}
+	return lowerSet(members), nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import "strings" // ユーザー名の比較に使用
+
+// threadRootID はコメントが属するスレッドのルートコメントIDを返します。
+// in_reply_to_id を辿り、返信先がない（または取得済みコメントに存在しない）コメントをルートとみなします。
+//
+// パラメータ:
+//   - c: 対象のコメント
+//   - byID: コメントIDからコメントを引くためのマップ
+//
+// 戻り値:
+//   - int64: ルートコメントのID
+func threadRootID(c Comment, byID map[int64]Comment) int64 {
+	seen := map[int64]bool{c.ID: true} // 不正なデータによる循環を防ぐ
+	for c.InReplyToID != nil {
+		parent, ok := byID[*c.InReplyToID]
+		if !ok || seen[parent.ID] {
+			break
+		}
+		seen[parent.ID] = true
+		c = parent
+	}
+	return c.ID
+}
+
+// annotateReplies はPRのコメントをスレッドとして再構成し、ルートコメントごとに
+// 返信数（ReplyCount）とPR作成者からの返信の有無（AnsweredByAuthor）を設定します。
+//
+// パラメータ:
+//   - pr: コメントが属するプルリクエスト（作成者の判定に使用）
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func annotateReplies(pr PullRequest, comments []Comment) {
+	// IDからコメントを引けるようにしておく
+	byID := make(map[int64]Comment, len(comments))
+	index := make(map[int64]int, len(comments))
+	for i, c := range comments {
+		byID[c.ID] = c
+		index[c.ID] = i
+	}
+
+	// 返信ごとにルートコメントの集計を更新
+	for _, c := range comments {
+		if c.InReplyToID == nil {
+			continue
+		}
+		rootID := threadRootID(c, byID)
+		i, ok := index[rootID]
+		if !ok || rootID == c.ID {
+			continue
+		}
+		comments[i].ReplyCount++
+		if !c.IsDeletedUser() && pr.User.Login != "" && strings.EqualFold(c.User.Login, pr.User.Login) {
+			comments[i].AnsweredByAuthor = true
+		}
+	}
+}
+
+// parentSnippetMaxRunes は返信先のコメントの抜粋の最大文字数です。
+const parentSnippetMaxRunes = 80
+
+// annotateParents は返信コメントに返信先のコメントの投稿者（ParentAuthor）と本文の抜粋（ParentSnippet）を設定します。
+// 返信先が取得済みのコメントに存在しない返信には何も設定しません。
+// 絞り込みで返信先が除外されても文脈を残せるよう、絞り込みの前に呼び出します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func annotateParents(comments []Comment) {
+	byID := make(map[int64]Comment, len(comments))
+	for _, c := range comments {
+		byID[c.ID] = c
+	}
+	for i, c := range comments {
+		if c.InReplyToID == nil {
+			continue
+		}
+		parent, ok := byID[*c.InReplyToID]
+		if !ok {
+			continue
+		}
+		comments[i].ParentAuthor = parent.AuthorLogin()
+		comments[i].ParentSnippet = snippet(parent.Body, parentSnippetMaxRunes)
+	}
+}
+
+// snippet は本文を1行にまとめ、maxRunes 文字を超える場合は末尾を "…" にして切り詰めます。
+func snippet(body string, maxRunes int) string {
+	runes := []rune(normalizeBody(body))
+	if len(runes) <= maxRunes {
+		return string(runes)
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
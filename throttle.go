@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"         // 実際のリクエストの頻度の表示に使用
+	"math"        // 上限なしの判定に使用
+	"net/http"    // HTTPクライアントの実装を提供
+	"strings"     // GitHub APIへのリクエストの判定に使用
+	"sync"        // 並行するリクエストの間でのバケットの排他制御に使用
+	"sync/atomic" // リクエスト数の集計に使用
+	"time"        // 待機時間の計算に使用
+)
+
+// defaultConcurrentRPS は並行して取得する場合（--concurrency・--repo-concurrency が2以上）に、--rps を指定しなかったときの1秒あたりのリクエスト数の上限です。
+const defaultConcurrentRPS = 5
+
+// throttleTransport はGitHub APIへのリクエストを1秒あたり rps 回までに抑える、トークンバケット方式の http.RoundTripper です。
+// 送り直し（retryTransport）の内側に置くため、送り直したリクエストも1回分として数えます。
+// 画像のダウンロードなど、GitHub API以外へのリクエストは抑えません。
+type throttleTransport struct {
+	base  http.RoundTripper // 実際にリクエストを送るトランスポート
+	rps   float64           // 1秒あたりのリクエスト数の上限（--rps）
+	burst float64           // バケットに貯められるリクエスト数（1秒分、少なくとも1回分）
+
+	mu     sync.Mutex // tokens・last を保護する
+	tokens float64    // すぐに送れるリクエスト数（負の場合は予約済みで待機中のリクエストがある）
+	last   time.Time  // tokens を最後に更新した時刻
+
+	start    time.Time    // 最初のリクエストの時刻
+	Requests atomic.Int64 // これまでに送ったリクエスト数
+}
+
+// newThrottleTransport は1秒あたり rps 回までにリクエストを抑える throttleTransport を作成します。
+func newThrottleTransport(base http.RoundTripper, rps float64) *throttleTransport {
+	return &throttleTransport{base: base, rps: rps, burst: math.Max(1, rps), tokens: math.Max(1, rps)}
+}
+
+// reserve はリクエスト1回分の枠を予約し、送ってよい時刻までの待機時間を返します。
+func (t *throttleTransport) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.last.IsZero() {
+		t.start = now
+	} else {
+		t.tokens = math.Min(t.burst, t.tokens+now.Sub(t.last).Seconds()*t.rps)
+	}
+	t.last = now
+	t.tokens--
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rps * float64(time.Second))
+}
+
+// RoundTrip は http.RoundTripper インタフェースの実装です。枠が空くまで待ってからリクエストを送ります。
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.String(), apiBaseURL+"/") {
+		return t.base.RoundTrip(req)
+	}
+	if wait := t.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			// 予約した枠は戻さない（中断後はリクエストを送らないため影響しない）
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	t.Requests.Add(1)
+	return t.base.RoundTrip(req)
+}
+
+// report は --verbose の場合に、実行全体で実際に送った1秒あたりのリクエスト数を表示します。
+func (t *throttleTransport) report() {
+	n := t.Requests.Load()
+	t.mu.Lock()
+	elapsed := time.Since(t.start)
+	t.mu.Unlock()
+	if n == 0 || elapsed <= 0 {
+		return
+	}
+	log.Printf("Sent %d API requests in %s (%.2f per second, --rps %g)", n, elapsed.Round(time.Millisecond), float64(n)/elapsed.Seconds(), t.rps)
+}
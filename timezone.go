@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings" // タイムゾーン名の比較に使用
+	"time"    // 日時の変換に使用
+)
+
+// displayTimeLayout はテキスト・Markdown・HTML出力で日時を表示する形式です（例: 2024-05-02 18:31 JST）。
+const displayTimeLayout = "2006-01-02 15:04 MST"
+
+// loadTimezone は --timezone で指定されたタイムゾーンを読み込みます。
+// "local" はこのコンピュータのタイムゾーンを表します。
+//
+// パラメータ:
+//   - name: タイムゾーン名（"Asia/Tokyo"、"UTC"、"local" など）
+//
+// 戻り値:
+//   - *time.Location: タイムゾーン
+//   - error: 不明なタイムゾーン名の場合のエラー情報
+func loadTimezone(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// timeConverter はGitHubのUTCの日時を指定されたタイムゾーンに変換します。
+type timeConverter struct {
+	loc      *time.Location
+	Unparsed int // 解析できずにそのまま出力した日時の数
+}
+
+// convert はRFC3339形式の日時を、タイムゾーンのオフセット付きのRFC3339形式と表示用の形式に変換します。
+// 解析できない日時はそのまま返し、件数を数えます。
+//
+// パラメータ:
+//   - ts: RFC3339形式の日時
+//
+// 戻り値:
+//   - string: タイムゾーンのオフセット付きのRFC3339形式の日時
+//   - string: 表示用の日時（解析できない場合は空文字）
+func (tc *timeConverter) convert(ts string) (string, string) {
+	if ts == "" {
+		return ts, ""
+	}
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		tc.Unparsed++
+		return ts, ""
+	}
+	t = t.In(tc.loc)
+	return t.Format(time.RFC3339), t.Format(displayTimeLayout)
+}
+
+// applyComments はコメントの作成日時と更新日時を変換します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+func (tc *timeConverter) applyComments(comments []Comment) {
+	for i := range comments {
+		comments[i].CreatedAt, comments[i].CreatedDisplay = tc.convert(comments[i].CreatedAt)
+		comments[i].UpdatedAt, _ = tc.convert(comments[i].UpdatedAt)
+	}
+}
+
+// applyPR はプルリクエストのマージ日時・クローズ日時と、オープン中のPRのコメントを取得した日時を変換します。
+func (tc *timeConverter) applyPR(pr *PullRequest) {
+	if pr.MergedAt != nil {
+		merged, _ := tc.convert(*pr.MergedAt)
+		pr.MergedAt = &merged
+	}
+	if pr.ClosedAt != nil {
+		closed, _ := tc.convert(*pr.ClosedAt)
+		pr.ClosedAt = &closed
+	}
+	if pr.SnapshotAt != "" {
+		pr.SnapshotAt, _ = tc.convert(pr.SnapshotAt)
+	}
+}
+
+// trailingDays は --days で指定された「直近N日」の期間を返します。
+// 集計が日単位でそろうよう、タイムゾーンでの今日の0時を終了（含まない）とし、そのN日前の0時を開始とします。
+//
+// パラメータ:
+//   - days: 日数
+//   - now: 現在の日時
+//   - loc: 日の区切りに使うタイムゾーン
+//
+// 戻り値:
+//   - time.Time: 期間の開始（含む）
+//   - time.Time: 期間の終了（含まない）
+func trailingDays(days int, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	return today.AddDate(0, 0, -days), today
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"  // 実行全体の期限の伝達に使用
+	"net/http" // HTTPクライアントの実装を提供
+	"net/url"  // クエリパラメータの組み立てに使用
+	"sync"     // 並行して判定するための待ち合わせに使用
+)
+
+// skipTouches は --touches のglobに一致するファイルを変更していないPRを除外した理由です。
+const skipTouches = "--touches"
+
+// prFile はPRで変更されたファイルの情報を格納する構造体です。
+type prFile struct {
+	Filename string `json:"filename"` // 変更されたファイルのパス
+}
+
+// checksFiles は変更されたファイルによる絞り込み（--touches）が指定されているかを返します。
+func (f *prFilter) checksFiles() bool {
+	return len(f.touches) > 0
+}
+
+// touching は候補のPRのうち、--touches のglobに一致するファイルを変更したPRを元の順序のまま返します。
+// PRごとに変更ファイルの一覧を取得するため、同時実行数ずつまとめて並行に判定し、必要な数がそろったら残りは判定しません。
+// 絞り込みが指定されていない場合は、候補をそのまま（必要な数まで）返します。
+//
+// パラメータ:
+//   - candidates: 他の条件をすべて満たした候補のPR
+//   - need: 必要なPRの数（0の場合は上限なし）
+//
+// 戻り値:
+//   - []PullRequest: 条件を満たすPRの配列
+func (f *prFilter) touching(candidates []PullRequest, need int) []PullRequest {
+	var kept []PullRequest
+	batch := f.concurrency
+	if batch < 1 {
+		batch = 1
+	}
+	for start := 0; start < len(candidates); start += batch {
+		if need > 0 && len(kept) >= need {
+			break
+		}
+		end := start + batch
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		group := candidates[start:end]
+		matched := make([]bool, len(group))
+		if !f.checksFiles() {
+			for i := range matched {
+				matched[i] = true
+			}
+		} else {
+			var wg sync.WaitGroup
+			for i, pr := range group {
+				wg.Add(1)
+				go func(i int, number int) {
+					defer wg.Done()
+					ok, err := fetchTouches(f.details.ctx, f.details.owner, f.details.repo, number, f.details.token, f.details.client, f.touches)
+					if err != nil {
+						f.out.Logf("Error fetching changed files of PR #%d for --touches: %v", number, err)
+						return
+					}
+					matched[i] = ok
+				}(i, pr.Number)
+			}
+			wg.Wait()
+			f.Examined += len(group)
+		}
+		for i, pr := range group {
+			if !matched[i] {
+				f.skip(skipTouches)
+				continue
+			}
+			kept = append(kept, pr)
+		}
+	}
+	if need > 0 && len(kept) > need {
+		kept = kept[:need]
+	}
+	return kept
+}
+
+// fetchTouches はPRで変更されたファイルの一覧をたどり、globのいずれかに一致するファイルがあるかを判定します。
+// 一致するファイルが見つかった時点で、残りのページは取得せずに終了します。
+//
+// パラメータ:
+//   - ctx: 実行全体の期限（--max-duration・--deadline）を伝えるコンテキスト
+//   - owner: リポジトリのオーナー名
+//   - repo: リポジトリ名
+//   - number: プルリクエスト番号
+//   - token: GitHub APIアクセス用のトークン
+//   - client: リクエストに使用するHTTPクライアント
+//   - globs: 変更ファイルと照合するglobパターン（いずれかに一致すればよい）
+//
+// 戻り値:
+//   - bool: 一致するファイルを変更していた場合はtrue
+//   - error: エラーが発生した場合はエラー情報、成功時はnil
+func fetchTouches(ctx context.Context, owner, repo string, number int, token string, client *http.Client, globs []string) (bool, error) {
+	// クエリパラメータを設定（ページ番号は paginate が設定する）
+	q := url.Values{}
+	q.Add("per_page", "100") // 1ページあたり100件取得
+
+	// リクエストを送信してJSONをデコード（レスポンスボディはページごとに閉じる）
+	found := false
+	_, err := paginate(ctx, client, apiRequest{URL: apiURL("/repos/%s/%s/pulls/%d/files", owner, repo, number), Query: q, Token: token}, func(_ int, files []prFile, _ apiResponse) (bool, error) {
+		// 一致するファイルが見つかったら残りのページは取得しない
+		for _, file := range files {
+			for _, g := range globs {
+				if matchGlob(g, file.Filename) {
+					found = true
+					return false, nil
+				}
+			}
+		}
+		return len(files) > 0, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"           // 実行の中断に使用
+	"crypto/ecdsa"      // テスト用の鍵の作成に使用
+	"crypto/elliptic"   // テスト用の鍵の作成に使用
+	"crypto/rand"       // テスト用の鍵の作成に使用
+	"crypto/tls"        // テスト用のTLSサーバーの設定に使用
+	"crypto/x509"       // 自己署名証明書の作成に使用
+	"crypto/x509/pkix"  // 証明書の名前に使用
+	"encoding/pem"      // 証明書と鍵のファイルの書き込みに使用
+	"errors"            // 上限と中断のエラーの判定に使用
+	"io"                // レスポンスの本文の読み込みに使用
+	"math/big"          // 証明書のシリアル番号に使用
+	"net"               // 証明書のIPアドレスに使用
+	"net/http"          // リクエストの送信に使用
+	"net/http/httptest" // テスト用のサーバーの起動に使用
+	"os"                // 証明書のファイルの書き込みに使用
+	"path/filepath"     // 証明書のファイルのパスの組み立てに使用
+	"strings"           // 出力とエラーメッセージの確認に使用
+	"sync"              // リクエストの数え上げの排他制御に使用
+	"testing"           // テストの実行に使用
+	"time"              // 証明書の有効期間に使用
+)
+
+// selfSignedCert は 127.0.0.1 と localhost に使える自己署名証明書を作成し、証明書と鍵のPEMファイルを dir に書き込みます。
+func selfSignedCert(t *testing.T, dir, name string) (tls.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	certPath, keyPath := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	if err := os.WriteFile(certPath, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certPath, keyPath
+}
+
+// tlsServer は自己署名証明書を使うTLSのテスト用サーバーを起動します。clientCA が空でない場合は、その証明書で署名されたクライアント証明書を要求します（mTLS）。
+func tlsServer(t *testing.T, cert tls.Certificate, clientCA string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(pem)
+		server.TLS.ClientCAs = pool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestTransportTLS は自己署名証明書のサーバーに対して、--ca-cert・--client-cert/--client-key・--insecure-skip-verify の動作を確認します。
+func TestTransportTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCert, serverPEM, _ := selfSignedCert(t, dir, "server")
+	_, clientPEM, clientKey := selfSignedCert(t, dir, "client")
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	server := tlsServer(t, serverCert, "")
+	mtls := tlsServer(t, serverCert, clientPEM)
+
+	tests := []struct {
+		name      string
+		url       string
+		opts      transportOptions
+		wantSetup string // newTransport のエラーに含まれる文字列
+		wantErr   bool   // リクエストが失敗するか
+	}{
+		{"untrusted certificate", server.URL, transportOptions{}, "", true},
+		{"--ca-cert", server.URL, transportOptions{CACert: serverPEM}, "", false},
+		{"--insecure-skip-verify", server.URL, transportOptions{InsecureSkipVerify: true}, "", false},
+		{"mTLS without a client certificate", mtls.URL, transportOptions{CACert: serverPEM}, "", true},
+		{"mTLS with --client-cert", mtls.URL, transportOptions{CACert: serverPEM, ClientCert: clientPEM, ClientKey: clientKey}, "", false},
+		{"--client-cert without --client-key", server.URL, transportOptions{ClientCert: clientPEM}, "must be given together", false},
+		{"--ca-cert without certificates", server.URL, transportOptions{CACert: notPEM}, "contains no PEM certificates", false},
+		{"missing --ca-cert", server.URL, transportOptions{CACert: filepath.Join(dir, "missing.pem")}, "cannot read --ca-cert", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := newTransport(tt.opts)
+			if tt.wantSetup != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantSetup) {
+					t.Fatalf("newTransport error = %v, want %q", err, tt.wantSetup)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest(http.MethodGet, tt.url+"/repos/o/r", nil)
+			resp, err := transport.RoundTrip(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("request error = %v, want error %v", err, tt.wantErr)
+			}
+			// 証明書の検証エラーは送り直しても成功しないため、送り直しの対象にしない
+			if tt.name == "untrusted certificate" && retryableError(err) {
+				t.Errorf("certificate error %v is treated as retryable", err)
+			}
+		})
+	}
+}
+
+// TestInsecureSkipVerifyWarning は --insecure-skip-verify を指定した実行で、毎回警告を表示することを確認します。
+func TestInsecureSkipVerifyWarning(t *testing.T) {
+	dir := t.TempDir()
+	cert, certPath, _ := selfSignedCert(t, dir, "server")
+	server := &fakeGitHub{prs: []map[string]interface{}{fakePR(1, "2024-05-01T00:00:00Z")}, comments: map[int][]map[string]interface{}{1: pagedComments(1, 2)}}
+	server.Server = httptest.NewUnstartedServer(server)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	args := []string{"--owner", "o", "--repo", "r", "--count", "1", "--no-cache", "--token", "t", "--api-url", server.URL}
+	for _, run := range []struct {
+		extra       []string
+		wantWarning bool
+	}{
+		{[]string{"--insecure-skip-verify"}, true},
+		{[]string{"--insecure-skip-verify"}, true},
+		{[]string{"--ca-cert", certPath}, false},
+	} {
+		code, out := runTool(t, dir, append(args, run.extra...)...)
+		if code != 0 {
+			t.Fatalf("%v exited with %d:\n%s", run.extra, code, out)
+		}
+		if got := strings.Contains(out, "WARNING: --insecure-skip-verify"); got != run.wantWarning {
+			t.Errorf("%v: warning shown = %v, want %v:\n%s", run.extra, got, run.wantWarning, out)
+		}
+		if !strings.Contains(out, "Saved 2 comments") {
+			t.Errorf("%v: comments were not fetched over TLS:\n%s", run.extra, out)
+		}
+	}
+}
+
+// stackServer は試行の回数を数え、handle に何回目の試行かを渡して応答させるテスト用のサーバーを起動します。
+func stackServer(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, attempt int)) (*httptest.Server, func() int) {
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		n := attempts
+		mu.Unlock()
+		handle(w, r, n)
+	}))
+	t.Cleanup(server.Close)
+	withAPIBaseURL(t, server.URL)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+}
+
+// getVia は stack を通して url に GET リクエストを送り、レスポンスの本文を読んで返します。
+func getVia(ctx context.Context, stack http.RoundTripper, url string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Authorization", "token t")
+	resp, err := (&http.Client{Transport: stack}).Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+// TestMiddlewareStackRetryAndBudget は main と同じ順に重ねたトランスポートで、送り直したリクエストが
+// 1秒あたりのリクエスト数の制限の枠を試行ごとに使い、API呼び出しの上限には送り直しの上で1回として数えられることを確認します。
+func TestMiddlewareStackRetryAndBudget(t *testing.T) {
+	server, attempts := stackServer(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	requests, throttle, _ := newRequestStack(http.DefaultTransport, requestStackOptions{Timeout: 5 * time.Second, RPS: 1000, Retries: 2, RetryMaxWait: time.Second})
+	stack, _, _ := newAPIStack(requests, apiStackOptions{MaxCalls: 1})
+
+	status, body, err := getVia(context.Background(), stack, server.URL+"/repos/o/r/pulls")
+	if err != nil || status != http.StatusOK || body != "[]" {
+		t.Fatalf("GET through the stack = %d %q, %v; want the retried 200", status, body, err)
+	}
+	if n := attempts(); n != 2 {
+		t.Errorf("server saw %d attempts, want 2", n)
+	}
+	if n := throttle.Requests.Load(); n != 2 {
+		t.Errorf("throttle counted %d requests, want 2 (the retry also takes a slot)", n)
+	}
+
+	// 送り直しを含めて1回と数えるため、2回目の呼び出しで --max-api-calls 1 に達する
+	_, _, err = getVia(context.Background(), stack, server.URL+"/repos/o/r/pulls")
+	var limit *limitError
+	if !errors.As(err, &limit) || limit.Flag != "--max-api-calls" {
+		t.Errorf("second call returned %v, want the --max-api-calls limitError", err)
+	}
+	if n := attempts(); n != 2 {
+		t.Errorf("server saw %d attempts after the budget was spent, want 2", n)
+	}
+}
+
+// TestMiddlewareStackTimeoutBelowRetry は --http-timeout が1回の試行ごとにかかり、時間切れになった試行が送り直されることを確認します。
+func TestMiddlewareStackTimeoutBelowRetry(t *testing.T) {
+	server, attempts := stackServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	requests, _, _ := newRequestStack(http.DefaultTransport, requestStackOptions{Timeout: 200 * time.Millisecond, Retries: 1, RetryMaxWait: 10 * time.Millisecond})
+	stack, _, _ := newAPIStack(requests, apiStackOptions{})
+
+	status, _, err := getVia(context.Background(), stack, server.URL+"/repos/o/r/pulls")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GET through the stack = %d, %v; want the retried 200", status, err)
+	}
+	if n := attempts(); n != 2 {
+		t.Errorf("server saw %d attempts, want 2", n)
+	}
+}
+
+// TestMiddlewareStackCache はHTTPキャッシュが送り直しとレート制限の間にあり、304 のレスポンスをキャッシュした本文の 200 にすることを確認します。
+func TestMiddlewareStackCache(t *testing.T) {
+	server := newFakeGitHub(t, []map[string]interface{}{fakePR(1, "2024-05-01T00:00:00Z")}, nil)
+	withAPIBaseURL(t, server.apiURL())
+	requests, _, cache := newRequestStack(http.DefaultTransport, requestStackOptions{Retries: 1, RetryMaxWait: time.Second, CacheDir: t.TempDir()})
+	stack, _, _ := newAPIStack(requests, apiStackOptions{})
+
+	_, first, err := getVia(context.Background(), stack, server.apiURL()+"/repos/o/r/pulls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, second, err := getVia(context.Background(), stack, server.apiURL()+"/repos/o/r/pulls")
+	if err != nil || status != http.StatusOK || second != first {
+		t.Fatalf("cached GET = %d %q, %v; want 200 with the first body", status, second, err)
+	}
+	if server.notModified() != 1 || cache.Hits.Load() != 1 {
+		t.Errorf("server returned %d 304s and the cache counted %d hits, want 1 each", server.notModified(), cache.Hits.Load())
+	}
+}
+
+// TestMiddlewareStackStop は実行が中断された場合に、送信中のリクエストが送り直されずに limitError になり、
+// 中断の後のリクエストはサーバーに届かずに limitError になることを確認します。
+func TestMiddlewareStackStop(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server, attempts := stackServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		received <- struct{}{}
+		<-r.Context().Done()
+	})
+	requests, _, _ := newRequestStack(http.DefaultTransport, requestStackOptions{Timeout: 5 * time.Second, Retries: 3, RetryMaxWait: 10 * time.Millisecond})
+	stack, _, _ := newAPIStack(requests, apiStackOptions{MaxCalls: 10})
+
+	ctx, stop := context.WithCancelCause(context.Background())
+	go func() {
+		<-received
+		stop(&limitError{Flag: "interrupt", Interrupted: true})
+	}()
+	_, _, err := getVia(ctx, stack, server.URL+"/repos/o/r/pulls")
+	var limit *limitError
+	if !errors.As(err, &limit) || !limit.Interrupted {
+		t.Fatalf("interrupted request returned %v, want an interrupted limitError", err)
+	}
+	if n := attempts(); n != 1 {
+		t.Errorf("server saw %d attempts, want 1 (an interrupted request is not retried)", n)
+	}
+
+	_, _, err = getVia(ctx, stack, server.URL+"/repos/o/r/pulls")
+	if !errors.As(err, &limit) || !limit.Interrupted {
+		t.Errorf("request after the interrupt returned %v, want an interrupted limitError", err)
+	}
+	if n := attempts(); n != 1 {
+		t.Errorf("request after the interrupt reached the server (%d attempts)", n)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"regexp"  // 絵文字のショートコードの検出に使用
+	"strings" // 文字列操作に使用
+	"unicode" // 文字種の判定に使用
+)
+
+// emojiShortcodePattern は :+1: や :tada: のような絵文字のショートコードにマッチします。
+var emojiShortcodePattern = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+
+// trivialPhrases は --skip-trivial で除外する定型的なコメントです（小文字、前後の記号を除いた形）。
+var trivialPhrases = map[string]bool{
+	"lgtm":             true,
+	"nit":              true,
+	"+1":               true,
+	"-1":               true,
+	"ok":               true,
+	"okay":             true,
+	"done":             true,
+	"fixed":            true,
+	"thanks":           true,
+	"thank you":        true,
+	"ty":               true,
+	"ship it":          true,
+	"looks good":       true,
+	"looks good to me": true,
+	"same here":        true,
+	"ditto":            true,
+	"ack":              true,
+	"了解です":             true,
+	"了解しました":           true,
+	"対応しました":           true,
+	"修正しました":           true,
+	"ありがとうございます":       true,
+}
+
+// isEmojiRune は絵文字、または絵文字の組み立てに使われる文字（異体字セレクタ・ZWJ・肌色修飾子など）かどうかを判定します。
+func isEmojiRune(r rune) bool {
+	switch {
+	case r == 0x200D, r == 0x20E3: // ZWJ、囲み記号
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F: // 異体字セレクタ
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // 肌色修飾子
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF: // 絵文字の主なブロック
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // その他の記号・装飾記号
+		return true
+	}
+	return unicode.Is(unicode.So, r)
+}
+
+// meaningfulLength は本文の前後の空白を除き、絵文字（ショートコードを含む）と空白を数えない文字数を返します。
+// 絵文字だけのコメントは0文字になります。
+func meaningfulLength(body string) int {
+	body = emojiShortcodePattern.ReplaceAllString(strings.TrimSpace(body), "")
+	n := 0
+	for _, r := range body {
+		if !unicode.IsSpace(r) && !isEmojiRune(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// isTrivialComment は本文が「LGTM」「nit」「+1」や絵文字だけのような定型的なコメントかどうかを判定します。
+// 大文字小文字と前後の句読点は区別しません。
+func isTrivialComment(body string) bool {
+	if meaningfulLength(body) == 0 {
+		return true
+	}
+	normalized := strings.ToLower(strings.TrimSpace(emojiShortcodePattern.ReplaceAllString(body, "")))
+	normalized = strings.TrimFunc(normalized, func(r rune) bool {
+		return unicode.IsSpace(r) || isEmojiRune(r) || strings.ContainsRune(".!。！、,:：", r)
+	})
+	normalized = strings.Join(strings.Fields(normalized), " ")
+	return trivialPhrases[normalized]
+}
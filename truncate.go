@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"          // 省略マーカーの作成に使用
+	"unicode/utf8" // 文字数（ルーン数）の計算に使用
+)
+
+// truncateBody は本文が maxRunes 文字（バイトではなくルーン単位）を超える場合に切り詰め、省略した文字数とパーマリンクを示すマーカーを付けます。
+//
+// パラメータ:
+//   - body: コメント本文
+//   - maxRunes: 残す最大文字数
+//   - url: コメントのパーマリンク（空の場合はマーカーにURLを含めない）
+//
+// 戻り値:
+//   - string: 切り詰めた本文（切り詰める必要がない場合は元の本文）
+//   - bool: 切り詰めたかどうか
+func truncateBody(body string, maxRunes int, url string) (string, bool) {
+	total := utf8.RuneCountInString(body)
+	if total <= maxRunes {
+		return body, false
+	}
+	// maxRunes 文字目の終わりのバイト位置を求め、マルチバイト文字の途中で切らないようにする
+	cut := 0
+	for i := 0; i < maxRunes; i++ {
+		_, size := utf8.DecodeRuneInString(body[cut:])
+		cut += size
+	}
+	marker := fmt.Sprintf("... [truncated, %d more chars", total-maxRunes)
+	if url != "" {
+		marker += ", see " + url
+	}
+	return body[:cut] + marker + "]", true
+}
+
+// truncateComments は各コメントの本文を maxRunes 文字までに切り詰めます。
+// 切り詰めたコメントは Truncated を設定し、HTML出力でも切り詰めた本文が表示されるようレンダリング済みHTMLを破棄します。
+//
+// パラメータ:
+//   - comments: 対象のコメント（この配列の要素が直接更新されます）
+//   - maxRunes: 残す最大文字数
+func truncateComments(comments []Comment, maxRunes int) {
+	for i := range comments {
+		body, truncated := truncateBody(comments[i].Body, maxRunes, comments[i].HTMLURL)
+		if truncated {
+			comments[i].Body = body
+			comments[i].BodyHTML = ""
+			comments[i].Truncated = true
+		}
+	}
+}
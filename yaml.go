@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"     // エラーメッセージの作成に使用
+	"strconv" // 数値・真偽値の変換に使用
+	"strings" // 文字列操作に使用
+)
+
+// このファイルは設定ファイル用の小さなYAMLパーサーを提供します。
+// 外部ライブラリに依存しないよう、ブロック形式のマッピング・シーケンス、スカラー（引用符付きを含む）、
+// フロー形式のシーケンス（[a, b]）、ブロックスカラー（| と >）、コメントだけをサポートします。
+// アンカー・エイリアス・タグや複数ドキュメントには対応していません。
+
+// yamlKind はYAMLノードの種類です。
+type yamlKind int
+
+const (
+	yamlScalar   yamlKind = iota // スカラー値
+	yamlMapping                  // マッピング（key: value）
+	yamlSequence                 // シーケンス（- item）
+)
+
+// yamlNode はYAMLを解析した結果の1ノードです。
+type yamlNode struct {
+	Kind  yamlKind    // ノードの種類
+	Value string      // スカラー値（Kind が yamlScalar の場合）
+	Null  bool        // 値が空・null・~ の場合はtrue
+	Pairs []yamlPair  // マッピングの要素（記述順）
+	Items []*yamlNode // シーケンスの要素
+	Line  int         // ノードが始まる行番号（1始まり）
+}
+
+// yamlPair はマッピングの1要素です。
+type yamlPair struct {
+	Key   string    // キー
+	Value *yamlNode // 値
+	Line  int       // キーが書かれている行番号
+}
+
+// yamlLine は前処理済みの1行です。
+type yamlLine struct {
+	indent int    // 先頭の空白の数
+	text   string // インデントとコメントを除いた内容
+	raw    string // 元の行（ブロックスカラーの読み込みに使用）
+	num    int    // 行番号（1始まり）
+}
+
+// yamlParser はYAMLの解析状態を保持します。
+type yamlParser struct {
+	lines []yamlLine // 空行とコメント行を除いた行
+	all   []string   // 元のすべての行（ブロックスカラー用）
+	pos   int        // 次に読む行の位置
+}
+
+// parseYAML はYAML文書を解析します。
+//
+// パラメータ:
+//   - data: YAML文書
+//
+// 戻り値:
+//   - *yamlNode: ルートノード（空の文書の場合は空のマッピング）
+//   - error: 解析に失敗した場合は行番号付きのエラー情報
+func parseYAML(data []byte) (*yamlNode, error) {
+	p := &yamlParser{all: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
+	for i, raw := range p.all {
+		if content := strings.TrimLeft(raw, " \t"); content != "" && strings.Contains(raw[:len(raw)-len(content)], "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		text := stripYAMLComment(raw)
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		p.lines = append(p.lines, yamlLine{indent: indent, text: strings.TrimRight(text[indent:], " \t"), raw: raw, num: i + 1})
+	}
+	if len(p.lines) == 0 {
+		return &yamlNode{Kind: yamlMapping, Line: 1}, nil
+	}
+	node, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected content %q", p.lines[p.pos].num, p.lines[p.pos].text)
+	}
+	return node, nil
+}
+
+// stripYAMLComment は引用符の外にある "#" 以降（行頭または空白の直後のもの）を取り除きます。
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t:-[{,", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// isYAMLSequenceItem は行がシーケンスの要素（"- " で始まる）かどうかを返します。
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseBlock は指定されたインデントから始まるブロック（マッピングまたはシーケンス）を解析します。
+func (p *yamlParser) parseBlock(indent int) (*yamlNode, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// parseSequence はブロック形式のシーケンスを解析します。
+func (p *yamlParser) parseSequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{Kind: yamlSequence, Line: p.lines[p.pos].num}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+		l := p.lines[p.pos]
+		rest := strings.TrimLeft(l.text[1:], " ")
+		var item *yamlNode
+		var err error
+		switch {
+		case rest == "":
+			// 要素の中身は次の行以降に書かれている
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				item, err = p.parseBlock(p.lines[p.pos].indent)
+			} else {
+				item = &yamlNode{Kind: yamlScalar, Null: true, Line: l.num}
+			}
+		case isYAMLSequenceItem(rest) || isYAMLMappingLine(rest):
+			// "- key: value" や "- - item" は、"- " の後ろを1段深いブロックの先頭行として扱う
+			offset := len(l.text) - len(rest)
+			p.lines[p.pos] = yamlLine{indent: indent + offset, text: rest, raw: l.raw, num: l.num}
+			item, err = p.parseBlock(indent + offset)
+		default:
+			p.pos++
+			item, err = parseYAMLScalar(rest, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, item)
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return node, nil
+}
+
+// isYAMLMappingLine は行が "key: value" または "key:" の形式かどうかを返します。
+func isYAMLMappingLine(text string) bool {
+	_, _, ok := splitYAMLKeyValue(text)
+	return ok
+}
+
+// splitYAMLKeyValue は "key: value" 形式の行をキーと値に分割します。キーは引用符で囲まれていてもかまいません。
+func splitYAMLKeyValue(text string) (string, string, bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key, rest := text[1:end+1], text[end+2:]
+		if rest == ":" || strings.HasPrefix(rest, ": ") {
+			return key, strings.TrimSpace(rest[1:]), true
+		}
+		return "", "", false
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i == len(text)-1 || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseMapping はブロック形式のマッピングを解析します。
+func (p *yamlParser) parseMapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{Kind: yamlMapping, Line: p.lines[p.pos].num}
+	seen := make(map[string]bool)
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		l := p.lines[p.pos]
+		if isYAMLSequenceItem(l.text) {
+			return nil, fmt.Errorf("line %d: unexpected sequence item in a mapping", l.num)
+		}
+		key, value, ok := splitYAMLKeyValue(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\", got %q", l.num, l.text)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.num, key)
+		}
+		seen[key] = true
+		p.pos++
+
+		var child *yamlNode
+		var err error
+		switch {
+		case value == "":
+			// 値は次の行以降のブロック（同じインデントのシーケンスも許可する）
+			if p.pos < len(p.lines) && (p.lines[p.pos].indent > indent || (p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text))) {
+				child, err = p.parseBlock(p.lines[p.pos].indent)
+			} else {
+				child = &yamlNode{Kind: yamlScalar, Null: true, Line: l.num}
+			}
+		case value == "|" || value == ">" || value == "|-" || value == ">-":
+			child = p.parseBlockScalar(indent, value, l.num)
+		default:
+			child, err = parseYAMLScalar(value, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.Pairs = append(node.Pairs, yamlPair{Key: key, Value: child, Line: l.num})
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return node, nil
+}
+
+// parseBlockScalar は "|"（改行を保持）または ">"（改行を空白に置換）のブロックスカラーを解析します。
+func (p *yamlParser) parseBlockScalar(indent int, style string, line int) *yamlNode {
+	// 親より深くインデントされた行を読み飛ばし、その範囲の元の行を本文とする（"#" で始まる行もそのまま含める）
+	for p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		p.pos++
+	}
+	end := len(p.all)
+	if p.pos < len(p.lines) {
+		end = p.lines[p.pos].num - 1
+	}
+	var lines []string
+	blockIndent := -1 // 本文の1行目のインデントを基準にする
+	for _, raw := range p.all[line:end] {
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n := len(raw) - len(strings.TrimLeft(raw, " "))
+		if n <= indent {
+			break
+		}
+		if blockIndent < 0 || n < blockIndent {
+			blockIndent = n
+		}
+		lines = append(lines, strings.TrimRight(raw[blockIndent:], " "))
+	}
+	// 末尾の空行は取り除く
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	sep := "\n"
+	if strings.HasPrefix(style, ">") {
+		sep = " "
+	}
+	value := strings.Join(lines, sep)
+	if !strings.HasSuffix(style, "-") && value != "" {
+		value += "\n"
+	}
+	return &yamlNode{Kind: yamlScalar, Value: value, Line: line}
+}
+
+// parseYAMLScalar はスカラー値、またはフロー形式のシーケンス（[a, b]）を解析します。
+func parseYAMLScalar(text string, line int) (*yamlNode, error) {
+	switch {
+	case text == "~" || text == "null" || text == "Null" || text == "NULL":
+		return &yamlNode{Kind: yamlScalar, Null: true, Line: line}, nil
+	case text == "{}":
+		return &yamlNode{Kind: yamlMapping, Line: line}, nil
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("line %d: unterminated flow sequence %q", line, text)
+		}
+		node := &yamlNode{Kind: yamlSequence, Line: line}
+		for _, part := range splitYAMLFlow(text[1 : len(text)-1]) {
+			item, err := parseYAMLScalar(part, line)
+			if err != nil {
+				return nil, err
+			}
+			node.Items = append(node.Items, item)
+		}
+		return node, nil
+	case strings.HasPrefix(text, `"`):
+		if len(text) < 2 || !strings.HasSuffix(text, `"`) {
+			return nil, fmt.Errorf("line %d: unterminated double-quoted string", line)
+		}
+		value, err := unquoteYAMLDouble(text[1 : len(text)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		return &yamlNode{Kind: yamlScalar, Value: value, Line: line}, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: unterminated single-quoted string", line)
+		}
+		return &yamlNode{Kind: yamlScalar, Value: strings.ReplaceAll(text[1:len(text)-1], "''", "'"), Line: line}, nil
+	}
+	return &yamlNode{Kind: yamlScalar, Value: text, Line: line}, nil
+}
+
+// splitYAMLFlow はフロー形式のシーケンスの中身を、引用符を考慮してカンマで分割します。
+func splitYAMLFlow(text string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(text[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// unquoteYAMLDouble はダブルクォート文字列のエスケープ（\\ \" \n \t \/ など）を解釈します。
+func unquoteYAMLDouble(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid escape at end of string")
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case '"', '\\', '/', ' ':
+			b.WriteByte(s[i])
+		default:
+			return "", fmt.Errorf("unsupported escape \\%c (use single quotes for regular expressions)", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// String はスカラー値を文字列として返します。
+func (n *yamlNode) String() (string, error) {
+	if n.Kind != yamlScalar {
+		return "", fmt.Errorf("line %d: expected a string", n.Line)
+	}
+	return n.Value, nil
+}
+
+// Int はスカラー値を整数として返します。
+func (n *yamlNode) Int() (int, error) {
+	if n.Kind != yamlScalar {
+		return 0, fmt.Errorf("line %d: expected an integer", n.Line)
+	}
+	v, err := strconv.Atoi(n.Value)
+	if err != nil {
+		return 0, fmt.Errorf("line %d: expected an integer, got %q", n.Line, n.Value)
+	}
+	return v, nil
+}
+
+// StringList は文字列のシーケンスを返します。単一のスカラー値は1要素の配列として扱います。
+func (n *yamlNode) StringList() ([]string, error) {
+	switch n.Kind {
+	case yamlScalar:
+		if n.Null {
+			return nil, nil
+		}
+		return []string{n.Value}, nil
+	case yamlSequence:
+		values := make([]string, 0, len(n.Items))
+		for _, item := range n.Items {
+			if item.Kind != yamlScalar {
+				return nil, fmt.Errorf("line %d: expected a list of strings", item.Line)
+			}
+			values = append(values, item.Value)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("line %d: expected a list of strings", n.Line)
+}
+
+// Get はマッピングから指定されたキーの値を返します。キーがない場合はnilを返します。
+func (n *yamlNode) Get(key string) *yamlNode {
+	for _, pair := range n.Pairs {
+		if pair.Key == key {
+			return pair.Value
+		}
+	}
+	return nil
+}
+
+// quoteYAMLScalar は文字列を、parseYAML で同じ値として読み戻せるYAMLのスカラーに変換します。
+// 英数字と一部の記号だけからなる値はそのまま、それ以外は単一引用符で囲みます。
+func quoteYAMLScalar(s string) string {
+	plain := s != "" && s != "~" && s != "null"
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_./+-", c)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
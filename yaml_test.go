@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"     // ノードの文字列化に使用
+	"strings" // ノードの文字列化とエラーメッセージの確認に使用
+	"testing" // テストの実行に使用
+)
+
+// dumpYAML はノードを比較しやすい1行の文字列にします（マッピングは {k: v}、シーケンスは [a, b]、null は ~、スカラーは引用符付き）。
+func dumpYAML(n *yamlNode) string {
+	switch n.Kind {
+	case yamlMapping:
+		parts := make([]string, 0, len(n.Pairs))
+		for _, p := range n.Pairs {
+			parts = append(parts, p.Key+": "+dumpYAML(p.Value))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case yamlSequence:
+		parts := make([]string, 0, len(n.Items))
+		for _, item := range n.Items {
+			parts = append(parts, dumpYAML(item))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	if n.Null {
+		return "~"
+	}
+	return fmt.Sprintf("%q", n.Value)
+}
+
+// TestParseYAML は設定ファイルとルールファイルで使う書き方を解析できることを確認します。
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+	}{
+		{"empty", "", "{}"},
+		{"comments only", "# a\n\n  # b\n", "{}"},
+		{"document markers", "---\na: 1\n...\n", `{a: "1"}`},
+		{"scalars", "a: 1\nb: two words\nc:\nd: ~\ne: null", `{a: "1", b: "two words", c: ~, d: ~, e: ~}`},
+		{"quoted", `a: "x: #y"` + "\nb: 'it''s'\nc: \"tab\\tnew\\n\"", `{a: "x: #y", b: "it's", c: "tab\tnew\n"}`},
+		{"trailing comment", "a: b # comment\nc: d#not", `{a: "b", c: "d#not"}`},
+		{"quoted key", "\"a b\": 1\n'c:d': 2", `{a b: "1", c:d: "2"}`},
+		{"nested mapping", "a:\n  b:\n    c: 1\n  d: 2", `{a: {b: {c: "1"}, d: "2"}}`},
+		{"block sequence", "a:\n  - x\n  - 'y'\n  -\n", `{a: ["x", "y", ~]}`},
+		{"sequence at the same indent", "a:\n- x\n- y\nb: 1", `{a: ["x", "y"], b: "1"}`},
+		{"flow sequence", "a: [x, 'y, z', \"w\"]\nb: []", `{a: ["x", "y, z", "w"], b: []}`},
+		{"sequence of mappings", "rules:\n  - name: a\n    patterns: [x]\n  - name: b\n    priority: 2", `{rules: [{name: "a", patterns: ["x"]}, {name: "b", priority: "2"}]}`},
+		{"item on the next line", "-\n  a: 1\n- - x\n  - y", `[{a: "1"}, ["x", "y"]]`},
+		{"literal block", "a: |\n  one\n  # not a comment\n\n  two\nb: 1", `{a: "one\n# not a comment\n\ntwo\n", b: "1"}`},
+		{"folded block", "a: >-\n  one\n  two\n", `{a: "one two"}`},
+		{"empty mapping", "a: {}", `{a: {}}`},
+		{"CRLF", "a: 1\r\nb:\r\n  - x\r\n", `{a: "1", b: ["x"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseYAML([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("parseYAML(%q): %v", tt.doc, err)
+			}
+			if got := dumpYAML(node); got != tt.want {
+				t.Errorf("parseYAML(%q) = %s, want %s", tt.doc, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseYAMLErrors は解析できない文書が、問題のある行の番号を含むエラーになることを確認します。
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{"a: 1\n\tb: 2", "line 2: tabs are not allowed"},
+		{"a: 1\na: 2", `line 2: duplicate key "a"`},
+		{"a: 1\njust text", `line 2: expected "key: value", got "just text"`},
+		{"a: 1\n- x", "line 2: unexpected sequence item in a mapping"},
+		{"a: 1\n    b: 2", "line 2: unexpected indentation"},
+		{"a:\n  - x\n    y: 1", "line 3: unexpected indentation"},
+		{"a: 1\nb: [x, y", `line 2: unterminated flow sequence`},
+		{"a: \"x", "line 1: unterminated double-quoted string"},
+		{"a: 'x", "line 1: unterminated single-quoted string"},
+		{`a: "\d+"`, `line 1: unsupported escape \d`},
+		{"a:\n  b: 1\nc", `line 3: expected "key: value"`},
+	}
+	for _, tt := range tests {
+		_, err := parseYAML([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseYAML(%q) error = %v, want one containing %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+// TestQuoteYAMLScalar は quoteYAMLScalar で変換した値を parseYAML で同じ値として読み戻せることを確認します。
+func TestQuoteYAMLScalar(t *testing.T) {
+	for _, value := range []string{"plain", "acme/api", "", "~", "null", "with space", "it's", "a: b", "# x", "[x]", "https://example.com/api/v3", `\d+`} {
+		node, err := parseYAML([]byte("v: " + quoteYAMLScalar(value)))
+		if err != nil {
+			t.Errorf("quoteYAMLScalar(%q) = %s: %v", value, quoteYAMLScalar(value), err)
+			continue
+		}
+		if got := node.Get("v"); got.Null || got.Value != value {
+			t.Errorf("quoteYAMLScalar(%q) = %s, read back as %s", value, quoteYAMLScalar(value), dumpYAML(got))
+		}
+	}
+}